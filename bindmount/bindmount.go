@@ -0,0 +1,49 @@
+// Package bindmount 提供插件和 connector 共用的绑定挂载查找逻辑
+package bindmount
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moby/sys/mountinfo"
+)
+
+// FindBindMounts 返回与 mountPath 是同一个 FUSE 文件系统实例的其他挂载点
+// 每次 FUSE 挂载都会分配新的设备号，设备号相同说明其中一个是另一个的绑定挂载
+func FindBindMounts(mountPath, fsType string) ([]*mountinfo.Info, error) {
+	mounts, err := mountinfo.GetMounts(mountinfo.FSTypeFilter(fsType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the mount point: %w", err)
+	}
+	return findBindMountsIn(mounts, mountPath), nil
+}
+
+func findBindMountsIn(mounts []*mountinfo.Info, mountPath string) []*mountinfo.Info {
+	// 同一个挂载点有多个挂载时以最上层的为准
+	var target *mountinfo.Info
+	for _, mount := range mounts {
+		if mount.Mountpoint == mountPath {
+			target = mount
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	var binds []*mountinfo.Info
+	for _, mount := range mounts {
+		if mount.Mountpoint != mountPath && mount.Major == target.Major && mount.Minor == target.Minor {
+			binds = append(binds, mount)
+		}
+	}
+	return binds
+}
+
+// IsReadOnly 判断挂载点是否以只读方式挂载
+func IsReadOnly(mount *mountinfo.Info) bool {
+	for _, option := range strings.Split(mount.Options, ",") {
+		if option == "ro" {
+			return true
+		}
+	}
+	return false
+}
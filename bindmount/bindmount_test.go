@@ -0,0 +1,37 @@
+package bindmount
+
+import (
+	"testing"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindBindMounts(t *testing.T) {
+	binds, err := FindBindMounts(t.TempDir(), "fuse.rclone")
+	assert.NoError(t, err)
+	assert.Empty(t, binds)
+
+	staging := &mountinfo.Info{Major: 0, Minor: 52, Root: "/", Mountpoint: "/var/lib/kubelet/plugins/globalmount", Options: "rw,nosuid,nodev"}
+	pod1 := &mountinfo.Info{Major: 0, Minor: 52, Root: "/", Mountpoint: "/var/lib/kubelet/pods/1/mount", Options: "rw,nosuid,nodev"}
+	// 绑定挂载 staging path 下的子目录时，Root 为子目录的路径
+	pod2 := &mountinfo.Info{Major: 0, Minor: 52, Root: "/default/pod-2", Mountpoint: "/var/lib/kubelet/pods/2/mount", Options: "ro,nosuid,nodev"}
+	// 同一个存储卷在其他路径上的独立挂载有不同的设备号
+	other := &mountinfo.Info{Major: 0, Minor: 53, Root: "/", Mountpoint: "/var/lib/kubelet/pods/3/mount", Options: "rw,nosuid,nodev"}
+	mounts := []*mountinfo.Info{staging, pod1, pod2, other}
+
+	assert.Equal(t, []*mountinfo.Info{pod1, pod2}, findBindMountsIn(mounts, staging.Mountpoint))
+	assert.Equal(t, []*mountinfo.Info{staging, pod2}, findBindMountsIn(mounts, pod1.Mountpoint))
+	assert.Empty(t, findBindMountsIn(mounts, other.Mountpoint))
+	assert.Empty(t, findBindMountsIn(mounts, "/var/lib/kubelet/pods/4/mount"))
+
+	// 挂载点被重新挂载后，以最上层的挂载为准，之前的绑定挂载不再属于它
+	remounted := &mountinfo.Info{Major: 0, Minor: 54, Root: "/", Mountpoint: staging.Mountpoint, Options: "rw,nosuid,nodev"}
+	assert.Empty(t, findBindMountsIn(append(mounts, remounted), staging.Mountpoint))
+}
+
+func TestIsReadOnly(t *testing.T) {
+	assert.True(t, IsReadOnly(&mountinfo.Info{Options: "ro,nosuid,nodev,relatime"}))
+	assert.False(t, IsReadOnly(&mountinfo.Info{Options: "rw,nosuid,nodev,relatime"}))
+	assert.False(t, IsReadOnly(&mountinfo.Info{Options: "rw,errors=remount-ro"}))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+)
+
+// errCacheBudgetExhausted 节点上 Kodo 挂载点的 VFS 缓存总量已经达到 cache.maxTotalSize
+var errCacheBudgetExhausted = errors.New("VFS cache budget of the node is exhausted")
+
+// cacheBudget 限制节点上所有 Kodo 挂载点 VFS 缓存上限的总和，maxTotalSize 为 0 时不限制
+// 每个挂载点按照其缓存上限占用预算，而不是实际使用的大小，所以总和不会超过预算
+type cacheBudget struct {
+	maxSize      uint64
+	maxTotalSize uint64
+
+	lock      sync.Mutex
+	allocated map[string]uint64
+}
+
+func newCacheBudget(maxSize, maxTotalSize uint64) *cacheBudget {
+	return &cacheBudget{
+		maxSize:      maxSize,
+		maxTotalSize: maxTotalSize,
+		allocated:    make(map[string]uint64),
+	}
+}
+
+// cacheSize 返回挂载点的 VFS 缓存上限，不超过 maxSize 和 maxTotalSize，限制了总量时不使用缓存的挂载点为 0
+func (budget *cacheBudget) cacheSize(c *protocol.InitKodoMountCmd) *uint64 {
+	if budget.maxTotalSize > 0 && (c.VfsCacheMode == "" || c.VfsCacheMode == "off") {
+		// 不使用缓存的挂载点只在缓存中保存元数据，不占用预算
+		zero := uint64(0)
+		return &zero
+	}
+	size := c.VfsCacheMaxSize
+	for _, limit := range []uint64{budget.maxSize, budget.maxTotalSize} {
+		if limit := limit; limit > 0 && (size == nil || *size > limit) {
+			size = &limit
+		}
+	}
+	return size
+}
+
+// Allocate 为挂载点分配缓存预算并限制挂载命令的 VFS 缓存上限，剩余预算不足时返回 errCacheBudgetExhausted
+// 同一个挂载点重新挂载时替换之前的分配
+func (budget *cacheBudget) Allocate(c *protocol.InitKodoMountCmd) error {
+	size := budget.cacheSize(c)
+	if size != nil && *size > 0 {
+		c.VfsCacheMaxSize = size
+	}
+	if budget.maxTotalSize == 0 {
+		return nil
+	}
+
+	budget.lock.Lock()
+	defer budget.lock.Unlock()
+	var used uint64
+	for mountPath, allocated := range budget.allocated {
+		if mountPath != c.MountPath {
+			used += allocated
+		}
+	}
+	if used+*size > budget.maxTotalSize {
+		return fmt.Errorf("%w: %d of %d bytes are allocated, %s needs %d bytes", errCacheBudgetExhausted, used, budget.maxTotalSize, c.MountPath, *size)
+	}
+	budget.allocated[c.MountPath] = *size
+	return nil
+}
+
+// Release 释放挂载点的缓存预算
+func (budget *cacheBudget) Release(mountPath string) {
+	budget.lock.Lock()
+	defer budget.lock.Unlock()
+	delete(budget.allocated, mountPath)
+}
+
+// Allocated 返回已经分配的缓存预算总和
+func (budget *cacheBudget) Allocated() uint64 {
+	budget.lock.Lock()
+	defer budget.lock.Unlock()
+	var used uint64
+	for _, allocated := range budget.allocated {
+		used += allocated
+	}
+	return used
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheBudget(t *testing.T) {
+	size := func(v uint64) *uint64 { return &v }
+
+	// 没有限制总量时只限制每个挂载点的缓存上限
+	budget := newCacheBudget(100, 0)
+	c := &protocol.InitKodoMountCmd{MountPath: "/mnt/1", VfsCacheMode: "writes"}
+	assert.NoError(t, budget.Allocate(c))
+	assert.Equal(t, size(100), c.VfsCacheMaxSize)
+	c = &protocol.InitKodoMountCmd{MountPath: "/mnt/2", VfsCacheMode: "writes", VfsCacheMaxSize: size(50)}
+	assert.NoError(t, budget.Allocate(c))
+	assert.Equal(t, size(50), c.VfsCacheMaxSize)
+	c = &protocol.InitKodoMountCmd{MountPath: "/mnt/3", VfsCacheMode: "full"}
+	assert.NoError(t, newCacheBudget(0, 0).Allocate(c))
+	assert.Nil(t, c.VfsCacheMaxSize)
+
+	budget = newCacheBudget(100, 250)
+	assert.NoError(t, budget.Allocate(&protocol.InitKodoMountCmd{MountPath: "/mnt/1", VfsCacheMode: "writes"}))
+	assert.NoError(t, budget.Allocate(&protocol.InitKodoMountCmd{MountPath: "/mnt/2", VfsCacheMode: "full", VfsCacheMaxSize: size(120)}))
+	// 不使用缓存的挂载点不占用预算
+	c = &protocol.InitKodoMountCmd{MountPath: "/mnt/3"}
+	assert.NoError(t, budget.Allocate(c))
+	assert.Nil(t, c.VfsCacheMaxSize)
+	// 剩余 50，无法满足 100
+	err := budget.Allocate(&protocol.InitKodoMountCmd{MountPath: "/mnt/4", VfsCacheMode: "writes"})
+	assert.True(t, errors.Is(err, errCacheBudgetExhausted))
+	assert.NoError(t, budget.Allocate(&protocol.InitKodoMountCmd{MountPath: "/mnt/4", VfsCacheMode: "writes", VfsCacheMaxSize: size(50)}))
+	// 重新挂载同一个挂载点时替换之前的分配
+	assert.NoError(t, budget.Allocate(&protocol.InitKodoMountCmd{MountPath: "/mnt/1", VfsCacheMode: "writes"}))
+
+	budget.Release("/mnt/1")
+	assert.NoError(t, budget.Allocate(&protocol.InitKodoMountCmd{MountPath: "/mnt/5", VfsCacheMode: "writes"}))
+	assert.Equal(t, map[string]uint64{"/mnt/2": 100, "/mnt/3": 0, "/mnt/4": 50, "/mnt/5": 100}, budget.allocated)
+	assert.Equal(t, uint64(250), budget.Allocated())
+}
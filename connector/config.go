@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFilename entrypoint.sh 将 DaemonSet 中的配置文件复制到宿主机上的这个位置
+const DefaultConfigFilename = "/var/lib/qiniu/storage/csi-plugin/connector.yaml"
+
+// connectorConfig connector 的配置文件，没有配置的字段使用默认值
+type connectorConfig struct {
+	// SocketPath connector 监听的 unix socket，需要与插件的 --connector-socket 一致
+	SocketPath string `yaml:"socketPath"`
+	// PIDFilename 同一个节点上运行多个 connector 时需要使用不同的 PID 文件
+	PIDFilename string `yaml:"pidFile"`
+	// StateDir 保存挂载记录和 rclone 远程控制 socket 的目录
+	StateDir string `yaml:"stateDir"`
+	// RegistryKeyFile 加密挂载记录的密钥文件，不能位于 StateDir 中，不存在时自动生成
+	RegistryKeyFile string            `yaml:"registryKeyFile"`
+	Log             logConfig         `yaml:"log"`
+	Cache           cacheConfig       `yaml:"cache"`
+	Binaries        binariesConfig    `yaml:"binaries"`
+	Rclone          rcloneConfig      `yaml:"rclone"`
+	Concurrency     concurrencyConfig `yaml:"concurrency"`
+	Metrics         metricsConfig     `yaml:"metrics"`
+}
+
+type logConfig struct {
+	Level    string `yaml:"level"`
+	Filename string `yaml:"file"`
+	// StderrFilename 保存标准错误输出（例如 panic）的文件，默认在 Filename 的扩展名前加上 .stderr
+	StderrFilename string `yaml:"stderrFile"`
+	// 单个日志文件的最大大小（MB），超过后轮转
+	MaxSizeMB  int  `yaml:"maxSizeMB"`
+	MaxBackups int  `yaml:"maxBackups"`
+	MaxAgeDays int  `yaml:"maxAgeDays"`
+	Compress   bool `yaml:"compress"`
+}
+
+type cacheConfig struct {
+	// Dir rclone VFS 缓存的根目录，每个挂载点的缓存位于 <Dir>/<VolumeId>/ 下
+	Dir string `yaml:"dir"`
+	// MaxSize 每个 Kodo 挂载点 VFS 缓存的最大字节数，StorageClass 没有设置或设置的值更大时使用该值，0 表示不限制
+	MaxSize uint64 `yaml:"maxSize"`
+	// MaxTotalSize 节点上所有 Kodo 挂载点 VFS 缓存最大字节数的总和，超出时挂载失败，0 表示不限制
+	MaxTotalSize uint64 `yaml:"maxTotalSize"`
+	// MaxFlushAttempts 卸载时等待上传失败的最大次数，达到后强制卸载并保留缓存，0 表示一直等待
+	MaxFlushAttempts int `yaml:"maxFlushAttempts"`
+}
+
+type binariesConfig struct {
+	Rclone     string `yaml:"rclone"`
+	KodoFS     string `yaml:"kodofs"`
+	Fusermount string `yaml:"fusermount"`
+}
+
+type rcloneConfig struct {
+	ConfigDir string `yaml:"configDir"`
+	LogDir    string `yaml:"logDir"`
+	// DefaultFlags 添加到每个 rclone 命令的参数，StorageClass 中设置的参数优先
+	DefaultFlags []string `yaml:"defaultFlags"`
+}
+
+type concurrencyConfig struct {
+	MaxMounts          int `yaml:"maxMounts"`
+	MaxMountsPerVolume int `yaml:"maxMountsPerVolume"`
+	MaxQueued          int `yaml:"maxQueued"`
+}
+
+type metricsConfig struct {
+	// Address 提供 Prometheus 指标 /metrics 的 HTTP 地址，例如 :11263，为空时不提供
+	Address string `yaml:"address"`
+}
+
+func defaultConnectorConfig() *connectorConfig {
+	config := &connectorConfig{
+		SocketPath:      SocketPath,
+		PIDFilename:     PIDFilename,
+		StateDir:        StateDir,
+		RegistryKeyFile: RegistryKeyFilename,
+		Log: logConfig{
+			Level:      log.InfoLevel.String(),
+			Filename:   LogFilename,
+			MaxSizeMB:  100,
+			MaxBackups: 10,
+			MaxAgeDays: 30,
+		},
+		Binaries: binariesConfig{
+			Rclone:     protocol.RcloneCmd,
+			KodoFS:     protocol.KodoFSCmd,
+			Fusermount: FusermountCmd,
+		},
+		Cache: cacheConfig{
+			MaxFlushAttempts: 5,
+		},
+		Concurrency: concurrencyConfig{
+			MaxMounts:          8,
+			MaxMountsPerVolume: 2,
+			MaxQueued:          128,
+		},
+	}
+
+	if userConfigDir, err := os.UserConfigDir(); err != nil {
+		config.Rclone.ConfigDir = filepath.Join(os.TempDir(), ".rclone", "config")
+	} else {
+		config.Rclone.ConfigDir = filepath.Join(userConfigDir, "rclone")
+	}
+	if userCacheDir, err := os.UserCacheDir(); err != nil {
+		config.Cache.Dir = filepath.Join(os.TempDir(), ".rclone", "cache")
+	} else {
+		config.Cache.Dir = filepath.Join(userCacheDir, "rclone")
+	}
+	if userLogDir, err := userLogDir(); err != nil {
+		config.Rclone.LogDir = filepath.Join(os.TempDir(), ".rclone", "log")
+	} else {
+		config.Rclone.LogDir = filepath.Join(userLogDir, "rclone")
+	}
+	return config
+}
+
+// loadConnectorConfig 读取配置文件，文件不存在时使用默认配置
+func loadConnectorConfig(filename string) (*connectorConfig, error) {
+	config := defaultConnectorConfig()
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("loadConnectorConfig: read file %s error: %w", filename, err)
+	}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("loadConnectorConfig: parse file %s error: %w", filename, err)
+	}
+	if err = config.validate(); err != nil {
+		return nil, fmt.Errorf("loadConnectorConfig: invalid config file %s: %w", filename, err)
+	}
+	return config, nil
+}
+
+func (config *connectorConfig) validate() error {
+	for name, path := range map[string]string{
+		"socketPath":          config.SocketPath,
+		"pidFile":             config.PIDFilename,
+		"stateDir":            config.StateDir,
+		"registryKeyFile":     config.RegistryKeyFile,
+		"log.file":            config.Log.Filename,
+		"cache.dir":           config.Cache.Dir,
+		"rclone.configDir":    config.Rclone.ConfigDir,
+		"rclone.logDir":       config.Rclone.LogDir,
+		"binaries.rclone":     config.Binaries.Rclone,
+		"binaries.kodofs":     config.Binaries.KodoFS,
+		"binaries.fusermount": config.Binaries.Fusermount,
+	} {
+		if path == "" {
+			return fmt.Errorf("%s is empty", name)
+		}
+	}
+	if rel, err := filepath.Rel(config.StateDir, config.RegistryKeyFile); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+		return errors.New("registryKeyFile must not be in stateDir")
+	}
+	if config.Log.stderrFilename() == config.Log.Filename {
+		return errors.New("log.stderrFile must be different from log.file")
+	}
+	if _, err := log.ParseLevel(config.Log.Level); err != nil {
+		return fmt.Errorf("log.level: %w", err)
+	}
+	if config.Cache.MaxFlushAttempts < 0 {
+		return errors.New("cache.maxFlushAttempts must not be negative")
+	}
+	if config.Concurrency.MaxMounts < 0 || config.Concurrency.MaxMountsPerVolume < 0 || config.Concurrency.MaxQueued < 0 {
+		return errors.New("concurrency limits must not be negative")
+	}
+	for _, flag := range config.Rclone.DefaultFlags {
+		if flag == "--daemon" || strings.HasPrefix(flag, "--cache-dir") || strings.HasPrefix(flag, "--config") || strings.HasPrefix(flag, "--rc") {
+			return fmt.Errorf("rclone.defaultFlags: %s is managed by connector", flag)
+		}
+	}
+	return nil
+}
+
+// apply 将配置应用到 connector 和 protocol 使用的全局变量上
+func (config *connectorConfig) apply() {
+	protocol.RcloneCmd = config.Binaries.Rclone
+	protocol.KodoFSCmd = config.Binaries.KodoFS
+	FusermountCmd = config.Binaries.Fusermount
+	rcloneConfigDir = config.Rclone.ConfigDir
+	rcloneCacheDir = config.Cache.Dir
+	rcloneLogDir = config.Rclone.LogDir
+	rcloneDefaultFlags = config.Rclone.DefaultFlags
+	nodeCacheBudget = newCacheBudget(config.Cache.MaxSize, config.Cache.MaxTotalSize)
+	unmountFlushAttempts = newFlushAttempts(config.Cache.MaxFlushAttempts)
+	MountRegistryDir = filepath.Join(config.StateDir, "mounts")
+	MountRegistryKeyFilename = config.RegistryKeyFile
+	LegacyMountRegistryKeyFilename = filepath.Join(config.StateDir, "connector.key")
+	RcloneRcDir = filepath.Join(config.StateDir, "rc")
+}
+
+// logWriter 返回按照配置轮转的日志文件
+func (config *logConfig) logWriter() *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   config.Filename,
+		MaxSize:    config.MaxSizeMB,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAgeDays,
+		Compress:   config.Compress,
+	}
+}
+
+// stderrFilename 返回保存标准错误输出的文件
+func (config *logConfig) stderrFilename() string {
+	if config.StderrFilename != "" {
+		return config.StderrFilename
+	}
+	ext := filepath.Ext(config.Filename)
+	return strings.TrimSuffix(config.Filename, ext) + ".stderr" + ext
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConnectorConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// 配置文件不存在时使用默认配置
+	config, err := loadConnectorConfig(filepath.Join(dir, "not-exists.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, SocketPath, config.SocketPath)
+	assert.Equal(t, 8, config.Concurrency.MaxMounts)
+	assert.Equal(t, 5, config.Cache.MaxFlushAttempts)
+	assert.Equal(t, "info", config.Log.Level)
+	assert.Equal(t, "/var/log/qiniu/storage/csi-plugin/connector.stderr.log", config.Log.stderrFilename())
+	assert.Equal(t, StateDir, config.StateDir)
+	assert.Equal(t, RegistryKeyFilename, config.RegistryKeyFile)
+
+	filename := filepath.Join(dir, "connector.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`
+socketPath: /run/qiniu/connector-2.sock
+log:
+  level: info
+cache:
+  dir: /data/rclone-cache
+  maxSize: 10737418240
+  maxTotalSize: 107374182400
+  maxFlushAttempts: 3
+rclone:
+  defaultFlags: ["--log-level", "INFO"]
+concurrency:
+  maxMounts: 4
+metrics:
+  address: 127.0.0.1:11263
+`), 0600))
+	config, err = loadConnectorConfig(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "/run/qiniu/connector-2.sock", config.SocketPath)
+	assert.Equal(t, "info", config.Log.Level)
+	assert.Equal(t, LogFilename, config.Log.Filename)
+	assert.Equal(t, "/data/rclone-cache", config.Cache.Dir)
+	assert.Equal(t, uint64(10737418240), config.Cache.MaxSize)
+	assert.Equal(t, uint64(107374182400), config.Cache.MaxTotalSize)
+	assert.Equal(t, 3, config.Cache.MaxFlushAttempts)
+	assert.Equal(t, []string{"--log-level", "INFO"}, config.Rclone.DefaultFlags)
+	assert.Equal(t, 4, config.Concurrency.MaxMounts)
+	assert.Equal(t, 2, config.Concurrency.MaxMountsPerVolume)
+	assert.Equal(t, "127.0.0.1:11263", config.Metrics.Address)
+
+	for _, content := range []string{
+		"log:\n  level: verbose\n",
+		"rclone:\n  defaultFlags: [\"--cache-dir=/tmp\"]\n",
+		"concurrency:\n  maxQueued: -1\n",
+		"cache:\n  maxFlushAttempts: -1\n",
+		"socketPath: \"\"\n",
+		"log:\n  file: /var/log/connector.log\n  stderrFile: /var/log/connector.log\n",
+		"stateDir: /var/lib/connector\nregistryKeyFile: /var/lib/connector/connector.key\n",
+		"registryKeyFile: \"\"\n",
+	} {
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+		_, err = loadConnectorConfig(filename)
+		assert.Error(t, err, content)
+	}
+}
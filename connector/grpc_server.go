@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC 连接以 HTTP/2 的连接序言开头，v2 协议的连接以 JSON 开头
+const HTTP2_PREFACE_PREFIX = "PRI * HTTP/2.0"
+
+// connectorServer 实现 gRPC 服务，v2 协议的请求仍由 handleConn 和 handleCmd 处理
+type connectorServer struct {
+	connectorpb.UnimplementedConnectorServer
+}
+
+func (server *connectorServer) Version(ctx context.Context, req *connectorpb.VersionRequest) (*connectorpb.VersionResponse, error) {
+	return &connectorpb.VersionResponse{
+		Version:         VERSION,
+		CommitId:        COMMITID,
+		BuildTime:       BUILDTIME,
+		ProtocolVersion: protocol.GRPCVersion,
+	}, nil
+}
+
+func (server *connectorServer) Mount(req *connectorpb.MountRequest, stream connectorpb.Connector_MountServer) error {
+	var record *mountRecord
+	switch mount := req.GetMount().(type) {
+	case *connectorpb.MountRequest_Kodo:
+		record = &mountRecord{Kodo: protocol.NewInitKodoMountCmd(mount.Kodo)}
+	case *connectorpb.MountRequest_Kodofs:
+		record = &mountRecord{KodoFS: protocol.NewInitKodoFSMountCmd(mount.Kodofs)}
+	default:
+		return status.Error(codes.InvalidArgument, "Mount: mount is empty")
+	}
+	if record.volumeId() == "" {
+		return status.Error(codes.InvalidArgument, "Mount: volume id is empty")
+	} else if record.mountPath() == "" {
+		return status.Error(codes.InvalidArgument, "Mount: mount path is empty")
+	} else if record.KodoFS != nil && (record.KodoFS.MasterAddress == "" || record.KodoFS.AccessToken == "") {
+		return status.Error(codes.InvalidArgument, "Mount: master address or access token is empty")
+	}
+	log.Infof("Mount: mounting volume %s to %s", record.volumeId(), record.mountPath())
+
+	syncStream := &syncMountStream{stream: stream, volumeId: record.volumeId()}
+	defer syncStream.Close()
+
+	// 挂载点上相同的并发请求共享同一次挂载的结果，挂载不随发起挂载的请求取消，否则等待的请求都会失败
+	shared, err := inflightMounts.Do(stream.Context(), record, func(ctx context.Context) error {
+		release, err := limiter.Acquire(ctx, record.volumeId(), func(position int) {
+			log.Infof("Mount: volume %s is queued at position %d", record.volumeId(), position)
+			syncStream.Send(&connectorpb.MountResponse{QueuePosition: uint32(position)})
+		})
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		// 挂载命令不随请求取消，避免挂载进程在挂载过程中被杀死
+		ctx, cancel := context.WithTimeout(context.Background(), MOUNT_TIMEOUT)
+		defer cancel()
+		if err := runMountCommand(ctx, record, func(data string, isError bool) {
+			syncStream.Send(&connectorpb.MountResponse{Data: data, IsError: isError})
+		}); err != nil {
+			return err
+		}
+		addMountRecord(record)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Mount: failed to mount volume %s to %s: %s", record.volumeId(), record.mountPath(), err)
+		return mountStatusError(record, err)
+	}
+	if shared {
+		log.Infof("Mount: volume %s is mounted to %s by a concurrent request", record.volumeId(), record.mountPath())
+	} else {
+		log.Infof("Mount: volume %s is mounted to %s", record.volumeId(), record.mountPath())
+	}
+	return nil
+}
+
+// syncMountStream 在 Close 之后丢弃发送的消息，挂载可能在请求结束后继续执行，挂载命令退出后也可能仍在读取输出，
+// 这些消息不能被发送到已经结束的流中
+type syncMountStream struct {
+	lock     sync.Mutex
+	closed   bool
+	stream   connectorpb.Connector_MountServer
+	volumeId string
+}
+
+func (syncStream *syncMountStream) Send(resp *connectorpb.MountResponse) {
+	syncStream.lock.Lock()
+	defer syncStream.lock.Unlock()
+	if syncStream.closed {
+		return
+	}
+	if err := syncStream.stream.Send(resp); err != nil {
+		log.Warnf("Mount: failed to send response of volume %s: %s", syncStream.volumeId, err)
+	}
+}
+
+func (syncStream *syncMountStream) Close() {
+	syncStream.lock.Lock()
+	defer syncStream.lock.Unlock()
+	syncStream.closed = true
+}
+
+// mountStatusError 将挂载失败的原因转换为 gRPC 状态，挂载命令的失败原因通过状态码和 details 返回
+func mountStatusError(record *mountRecord, err error) error {
+	var mountErr *mountError
+	if errors.Is(err, errMountQueueFull) || errors.Is(err, errCacheBudgetExhausted) {
+		return status.Errorf(codes.ResourceExhausted, "Mount: failed to mount volume %s to %s: %s", record.volumeId(), record.mountPath(), err)
+	} else if errors.Is(err, errMountConflict) {
+		return status.Errorf(codes.Aborted, "Mount: failed to mount volume %s to %s: %s", record.volumeId(), record.mountPath(), err)
+	} else if errors.Is(err, errReadOnlyNotEnforced) {
+		return status.Errorf(codes.FailedPrecondition, "Mount: failed to mount volume %s to %s: %s", record.volumeId(), record.mountPath(), err)
+	} else if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	} else if !errors.As(err, &mountErr) {
+		return status.Errorf(codes.Internal, "Mount: failed to mount volume %s to %s: %s", record.volumeId(), record.mountPath(), err)
+	}
+	st := status.New(protocol.MountErrorCode(mountErr.reason), mountErr.message)
+	if withDetails, err := st.WithDetails(mountErr.toProto()); err != nil {
+		log.Warnf("Mount: failed to attach details to status: %s", err)
+	} else {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func (server *connectorServer) Unmount(ctx context.Context, req *connectorpb.UnmountRequest) (*connectorpb.UnmountResponse, error) {
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Unmount: volume id is empty")
+	} else if req.GetMountPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Unmount: mount path is empty")
+	}
+	mountPath := req.GetMountPath()
+	// mountWatchdog 在检查完成前不会重新挂载这个挂载点，卸载完成后记录已经被删除，也不会再被重新挂载
+	unlock := mountPathLocks.Lock(mountPath)
+	defer unlock()
+
+	kodoStatus, err := getMountStatus(mountPath, FuseTypeKodo)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unmount: failed to get status of %s: %s", mountPath, err)
+	}
+	kodofsStatus, err := getMountStatus(mountPath, FuseTypeKodoFS)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Unmount: failed to get status of %s: %s", mountPath, err)
+	}
+
+	resp := &connectorpb.UnmountResponse{}
+	if kodoStatus == mountStatusHealthy {
+		// 等待超时时不卸载，保留记录和缓存，由 kubelet 重试
+		// 上传一直无法完成时（例如凭证失效或 bucket 已被删除），达到最大尝试次数后强制卸载并保留缓存
+		flushTimeout := time.Duration(req.GetFlushTimeoutSeconds()) * time.Second
+		if resp.Flushed, err = flushKodoMount(ctx, mountPath, flushTimeout); err != nil {
+			if status.Code(err) == codes.Canceled || !unmountFlushAttempts.Fail(mountPath) {
+				return nil, err
+			}
+			log.Warnf("Unmount: give up flushing uploads of %s after %d attempts, unmount it and keep the cache: %s",
+				mountPath, unmountFlushAttempts.max, err)
+		}
+	}
+	unmountFlushAttempts.Reset(mountPath)
+	subDir := kodoMountSubDir(mountPath)
+	// 先删除记录，避免卸载后被 mountWatchdog 重新挂载
+	removeMountRecord(mountPath)
+
+	for _, mountStatus := range []mountStatus{kodoStatus, kodofsStatus} {
+		if mountStatus == mountStatusHealthy || mountStatus == mountStatusBroken {
+			if err = unmountFuse(mountPath); err != nil {
+				return nil, status.Errorf(codes.Internal, "Unmount: failed to unmount %s: %s", mountPath, err)
+			}
+			resp.WasMounted = true
+			log.Infof("Unmount: volume %s is unmounted from %s, flushed: %t", req.GetVolumeId(), mountPath, resp.Flushed)
+			break
+		}
+	}
+	// 没有确认上传的缓存中可能有尚未上传的文件，保留缓存，之后挂载同一存储卷的同一子目录时由 rclone 继续上传
+	cleanKodoMount(req.GetVolumeId(), subDir, mountPath, resp.Flushed)
+	return resp, nil
+}
+
+// flushKodoMount 等待 rclone 上传缓存中的文件，返回是否确认已经全部上传
+// 挂载进程没有开启远程控制（由旧版本的 connector 挂载）时无法确认，仍然允许卸载，但会保留缓存
+// 远程控制调用失败时不卸载，返回 Unavailable 由 kubelet 重试
+func flushKodoMount(ctx context.Context, mountPath string, timeout time.Duration) (bool, error) {
+	if timeout <= 0 {
+		return false, nil
+	}
+	socketPath := rcloneRcSocketPath(mountPath)
+	if _, err := os.Stat(socketPath); err != nil {
+		log.Warnf("Unmount: rc socket of %s is not available, unmount without flushing and keep the cache: %s", mountPath, err)
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	pending, err := newRcloneRcClient(socketPath).flushUploads(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false, status.Errorf(codes.DeadlineExceeded, "Unmount: %d files in cache of %s are still being uploaded after %s", pending, mountPath, timeout)
+	} else if errors.Is(err, context.Canceled) {
+		return false, status.Errorf(codes.Canceled, "Unmount: canceled while %d files in cache of %s are being uploaded", pending, mountPath)
+	} else if err != nil {
+		return false, status.Errorf(codes.Unavailable, "Unmount: failed to flush uploads of %s: %s", mountPath, err)
+	}
+	return true, nil
+}
+
+// flushAttempts 记录每个挂载点卸载时等待上传失败的次数
+type flushAttempts struct {
+	lock   sync.Mutex
+	max    int
+	counts map[string]int
+}
+
+// newFlushAttempts max 为 0 时不限制次数
+func newFlushAttempts(max int) *flushAttempts {
+	return &flushAttempts{max: max, counts: make(map[string]int)}
+}
+
+// Fail 记录一次失败，返回是否已经达到最大次数
+func (attempts *flushAttempts) Fail(mountPath string) bool {
+	attempts.lock.Lock()
+	defer attempts.lock.Unlock()
+
+	attempts.counts[mountPath]++
+	return attempts.max > 0 && attempts.counts[mountPath] >= attempts.max
+}
+
+func (attempts *flushAttempts) Reset(mountPath string) {
+	attempts.lock.Lock()
+	defer attempts.lock.Unlock()
+
+	delete(attempts.counts, mountPath)
+}
+
+func (server *connectorServer) ListMounts(ctx context.Context, req *connectorpb.ListMountsRequest) (*connectorpb.ListMountsResponse, error) {
+	records, err := registry.List()
+	if err != nil {
+		log.Warnf("ListMounts: %s", err)
+	}
+	mounts := make([]*connectorpb.MountInfo, 0, len(records))
+	for _, record := range records {
+		mount := &connectorpb.MountInfo{VolumeId: record.volumeId(), MountPath: record.mountPath()}
+		fsType := FuseTypeKodo
+		if record.Kodo != nil {
+			mount.Type = connectorpb.MountType_MOUNT_TYPE_KODO
+		} else {
+			mount.Type = connectorpb.MountType_MOUNT_TYPE_KODOFS
+			fsType = FuseTypeKodoFS
+		}
+		if mountStatus, err := getMountStatus(mount.MountPath, fsType); err != nil {
+			log.Warnf("ListMounts: failed to get status of %s: %s", mount.MountPath, err)
+		} else {
+			mount.Status = mountStatus.toProto()
+		}
+		mounts = append(mounts, mount)
+	}
+	return &connectorpb.ListMountsResponse{Mounts: mounts}, nil
+}
+
+func (server *connectorServer) Stats(ctx context.Context, req *connectorpb.StatsRequest) (*connectorpb.StatsResponse, error) {
+	if req.GetMountPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Stats: mount path is empty")
+	}
+	record, err := registry.Get(req.GetMountPath())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Stats: %s", err)
+	} else if record == nil {
+		return nil, status.Errorf(codes.NotFound, "Stats: %s is not mounted by connector", req.GetMountPath())
+	}
+	fsType := FuseTypeKodo
+	if record.KodoFS != nil {
+		fsType = FuseTypeKodoFS
+	}
+	if mountStatus, err := getMountStatus(req.GetMountPath(), fsType); err != nil {
+		return nil, status.Errorf(codes.Internal, "Stats: failed to get status of %s: %s", req.GetMountPath(), err)
+	} else if mountStatus != mountStatusHealthy {
+		return nil, status.Errorf(codes.FailedPrecondition, "Stats: %s is %s", req.GetMountPath(), mountStatus)
+	}
+
+	var statfs unix.Statfs_t
+	if err = unix.Statfs(req.GetMountPath(), &statfs); err != nil {
+		return nil, status.Errorf(codes.Internal, "Stats: failed to statfs %s: %s", req.GetMountPath(), err)
+	}
+	blockSize := int64(statfs.Bsize)
+	return &connectorpb.StatsResponse{
+		TotalBytes:     int64(statfs.Blocks) * blockSize,
+		AvailableBytes: int64(statfs.Bavail) * blockSize,
+		UsedBytes:      int64(statfs.Blocks-statfs.Bfree) * blockSize,
+		TotalInodes:    int64(statfs.Files),
+		FreeInodes:     int64(statfs.Ffree),
+		UsedInodes:     int64(statfs.Files - statfs.Ffree),
+	}, nil
+}
+
+// Rc 将允许的远程控制命令转发给挂载点上的 rclone
+func (server *connectorServer) Rc(ctx context.Context, req *connectorpb.RcRequest) (*connectorpb.RcResponse, error) {
+	if req.GetMountPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Rc: mount path is empty")
+	} else if !rcloneRcAllowedMethods[req.GetMethod()] {
+		return nil, status.Errorf(codes.InvalidArgument, "Rc: method %q is not allowed", req.GetMethod())
+	}
+	params := make(map[string]interface{})
+	if req.GetParams() != "" {
+		if err := json.Unmarshal([]byte(req.GetParams()), &params); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Rc: params is not a json object: %s", err)
+		}
+	}
+
+	record, err := registry.Get(req.GetMountPath())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Rc: %s", err)
+	} else if record == nil {
+		return nil, status.Errorf(codes.NotFound, "Rc: %s is not mounted by connector", req.GetMountPath())
+	} else if record.Kodo == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "Rc: %s is not a kodo mount point", req.GetMountPath())
+	}
+	if mountStatus, err := getMountStatus(req.GetMountPath(), FuseTypeKodo); err != nil {
+		return nil, status.Errorf(codes.Internal, "Rc: failed to get status of %s: %s", req.GetMountPath(), err)
+	} else if mountStatus != mountStatusHealthy {
+		return nil, status.Errorf(codes.FailedPrecondition, "Rc: %s is %s", req.GetMountPath(), mountStatus)
+	}
+	socketPath := rcloneRcSocketPath(req.GetMountPath())
+	if _, err = os.Stat(socketPath); err != nil {
+		// 由旧版本的 connector 挂载的 rclone 没有开启远程控制
+		return nil, status.Errorf(codes.FailedPrecondition, "Rc: remote control of %s is not enabled, remount it to enable", req.GetMountPath())
+	}
+
+	var result json.RawMessage
+	var rcErr *rcloneRcError
+	if err = newRcloneRcClient(socketPath).call(ctx, req.GetMethod(), params, &result); errors.As(err, &rcErr) {
+		if rcErr.statusCode == http.StatusBadRequest {
+			return nil, status.Errorf(codes.InvalidArgument, "Rc: %s", rcErr)
+		} else if rcErr.statusCode == http.StatusNotFound {
+			// 较早版本的 rclone 不支持这个命令
+			return nil, status.Errorf(codes.Unimplemented, "Rc: %s", rcErr)
+		}
+		return nil, status.Errorf(codes.Internal, "Rc: %s", rcErr)
+	} else if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Rc: %s", err)
+	}
+	return &connectorpb.RcResponse{Result: string(result)}, nil
+}
+
+// bufferedConn 从 reader 中读取已经被预读的数据
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (conn *bufferedConn) Read(b []byte) (int, error) {
+	return conn.reader.Read(b)
+}
+
+// grpcListener 将 socket 上被识别为 gRPC 的连接交给 gRPC 服务
+type grpcListener struct {
+	addr      net.Addr
+	conns     chan net.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newGrpcListener(addr net.Addr) *grpcListener {
+	return &grpcListener{addr: addr, conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+func (listener *grpcListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-listener.conns:
+		return conn, nil
+	case <-listener.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (listener *grpcListener) Close() error {
+	listener.closeOnce.Do(func() { close(listener.closed) })
+	return nil
+}
+
+func (listener *grpcListener) Addr() net.Addr {
+	return listener.addr
+}
+
+// dispatchConn 根据连接的前几个字节判断协议，gRPC 连接交给 grpcListener，其他连接按照 v2 协议处理
+func dispatchConn(conn net.Conn, listener *grpcListener) {
+	conn.SetDeadline(time.Now().Add(MOUNT_TIMEOUT))
+	reader := bufio.NewReader(conn)
+	conn = &bufferedConn{Conn: conn, reader: reader}
+	if prefix, err := reader.Peek(len(HTTP2_PREFACE_PREFIX)); err == nil && string(prefix) == HTTP2_PREFACE_PREFIX {
+		// gRPC 连接的超时由请求自己控制
+		conn.SetDeadline(time.Time{})
+		select {
+		case listener.conns <- conn:
+		case <-listener.closed:
+			conn.Close()
+		}
+		return
+	}
+
+	cmdIn := make(chan protocol.Cmd)
+	cmdOut := make(chan protocol.Cmd)
+	go handleCmd(cmdIn, cmdOut)
+	handleConn(conn, cmdIn, cmdOut)
+}
+
+func newGrpcServer() *grpc.Server {
+	server := grpc.NewServer()
+	connectorpb.RegisterConnectorServer(server, &connectorServer{})
+	return server
+}
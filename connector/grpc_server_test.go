@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestDispatchConn(t *testing.T) {
+	dir := t.TempDir()
+	var err error
+	registry, err = newMountRegistry(filepath.Join(dir, "mounts"), filepath.Join(dir, "connector.key"))
+	assert.NoError(t, err)
+	defer func() { registry = nil }()
+
+	socketPath := filepath.Join(dir, "connector.sock")
+	socket, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	defer socket.Close()
+	listener := newGrpcListener(socket.Addr())
+	grpcServer := newGrpcServer()
+	defer grpcServer.Stop()
+	go grpcServer.Serve(listener)
+	go func() {
+		for {
+			conn, err := socket.Accept()
+			if err != nil {
+				return
+			}
+			go dispatchConn(conn, listener)
+		}
+	}()
+
+	// gRPC 请求
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := connectorpb.NewConnectorClient(conn)
+	version, err := client.Version(ctx, &connectorpb.VersionRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.GRPCVersion, version.GetProtocolVersion())
+	_, err = client.Unmount(ctx, &connectorpb.UnmountRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Stats(ctx, &connectorpb.StatsRequest{MountPath: filepath.Join(dir, "not-mounted")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.Rc(ctx, &connectorpb.RcRequest{MountPath: filepath.Join(dir, "not-mounted"), Method: "config/dump"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Rc(ctx, &connectorpb.RcRequest{MountPath: filepath.Join(dir, "not-mounted"), Method: "vfs/forget", Params: "[]"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Rc(ctx, &connectorpb.RcRequest{MountPath: filepath.Join(dir, "not-mounted"), Method: "vfs/stats"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// v2 协议的请求，被预读的数据不能丢失
+	mountPath := filepath.Join(dir, "kodofs")
+	assert.NoError(t, registry.Add(&mountRecord{KodoFS: &protocol.InitKodoFSMountCmd{VolumeId: "kodofs-1", MountPath: mountPath}}))
+	mounts, err := client.ListMounts(ctx, &connectorpb.ListMountsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, mounts.GetMounts(), 1)
+	assert.Equal(t, connectorpb.MountType_MOUNT_TYPE_KODOFS, mounts.GetMounts()[0].GetType())
+	assert.Equal(t, connectorpb.MountStatus_MOUNT_STATUS_REMOVED, mounts.GetMounts()[0].GetStatus())
+	_, err = client.Rc(ctx, &connectorpb.RcRequest{MountPath: mountPath, Method: "vfs/stats"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	legacyConn, err := net.Dial("unix", socketPath)
+	assert.NoError(t, err)
+	defer legacyConn.Close()
+	payload, err := json.Marshal(&protocol.KodoFSUmountCmd{VolumeId: "kodofs-1", MountPath: mountPath})
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewEncoder(legacyConn).Encode(&protocol.Request{
+		Version: protocol.Version,
+		Cmd:     protocol.KodoFSUmountCmdName,
+		Payload: payload,
+	}))
+	assert.Eventually(t, func() bool {
+		record, err := registry.Get(mountPath)
+		return err == nil && record == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestFlushKodoMount(t *testing.T) {
+	originalRcDir := RcloneRcDir
+	defer func() { RcloneRcDir = originalRcDir }()
+	RcloneRcDir = t.TempDir()
+	mountPath := "/var/lib/kubelet/pods/1/mount"
+
+	// 不等待上传时无法确认
+	flushed, err := flushKodoMount(context.Background(), mountPath, 0)
+	assert.NoError(t, err)
+	assert.False(t, flushed)
+
+	// 旧版本 connector 挂载的进程没有远程控制 socket，允许卸载但无法确认
+	flushed, err = flushKodoMount(context.Background(), mountPath, time.Second)
+	assert.NoError(t, err)
+	assert.False(t, flushed)
+
+	// 远程控制不可用时不允许卸载
+	assert.NoError(t, os.WriteFile(rcloneRcSocketPath(mountPath), nil, 0600))
+	flushed, err = flushKodoMount(context.Background(), mountPath, time.Second)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.False(t, flushed)
+}
+
+func TestFlushAttempts(t *testing.T) {
+	attempts := newFlushAttempts(2)
+	assert.False(t, attempts.Fail("/mnt/a"))
+	assert.False(t, attempts.Fail("/mnt/b"))
+	assert.True(t, attempts.Fail("/mnt/a"))
+	attempts.Reset("/mnt/a")
+	assert.False(t, attempts.Fail("/mnt/a"))
+
+	// 为 0 时不限制次数
+	attempts = newFlushAttempts(0)
+	for i := 0; i < 10; i++ {
+		assert.False(t, attempts.Fail("/mnt/a"))
+	}
+}
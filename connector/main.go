@@ -18,25 +18,30 @@ import (
 	"time"
 
 	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
 	daemon "github.com/sevlyar/go-daemon"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
+	// 以下为配置文件没有设置时的默认值
+
 	// LogFilename name of log file
 	LogFilename = "/var/log/qiniu/storage/csi-plugin/connector.log"
 	// PIDFilename name of pid file
 	PIDFilename = "/var/lib/qiniu/storage/csi-plugin/connector.pid"
 	// SocketPath socket path
 	SocketPath = "/var/lib/qiniu/storage/csi-plugin/connector.sock"
+	// StateDir directory of mount records and rclone remote control sockets
+	StateDir = "/var/lib/qiniu/storage/csi-plugin"
+	// RegistryKeyFilename key file of mount records, outside of StateDir
+	RegistryKeyFilename = "/etc/qiniu/storage/csi-plugin/connector.key"
 	// Connector name
 	ConnectorName = "connector.csi-plugin.storage.qiniu.com"
-	// Fusermount executable name
-	FusermountCmd = "fusermount3"
-	// KodoFS executable name
-	KodoFSCmd = protocol.KodoFSCmd
-	// Rclone executable name
-	RcloneCmd = protocol.RcloneCmd
+	// FUSE type of KodoFS mount points
+	FuseTypeKodoFS = "fuse.KodoFS"
+	// FUSE type of Kodo mount points
+	FuseTypeKodo = "fuse.rclone"
 )
 
 var (
@@ -52,8 +57,23 @@ var (
 	BUILDTIME = ""
 )
 
+// Fusermount executable name
+var FusermountCmd = "fusermount3"
+
 var (
 	isTest                                        = flag.Bool("test", false, "To test whether the connect could start or not")
+	configFilename                                = flag.String("config", DefaultConfigFilename, "Path of the connector config file, default values are used if it does not exist")
+	watchdogInterval                              = flag.Duration("watchdog-interval", time.Minute, "Interval of checking and recovering broken mounts, 0 to check only on startup")
+	maxConcurrentMounts                           = flag.Int("max-concurrent-mounts", 8, "Maximum number of mount commands running at the same time, 0 for unlimited, overrides concurrency.maxMounts of the config file")
+	maxConcurrentMountsPerVolume                  = flag.Int("max-concurrent-mounts-per-volume", 2, "Maximum number of mount commands of the same volume running at the same time, 0 for unlimited, overrides concurrency.maxMountsPerVolume of the config file")
+	maxQueuedMounts                               = flag.Int("max-queued-mounts", 128, "Maximum number of mount requests waiting for the concurrency limits, 0 for unlimited, overrides concurrency.maxQueued of the config file")
+	registry                                      *mountRegistry
+	rcloneDefaultFlags                            []string
+	nodeCacheBudget                               = newCacheBudget(0, 0)
+	limiter                                       = newMountLimiter(0, 0, 0)
+	inflightMounts                                = newMountDeduplicator()
+	mountPathLocks                                = newPathLocks()
+	unmountFlushAttempts                          = newFlushAttempts(0)
 	rcloneConfigDir, rcloneCacheDir, rcloneLogDir string
 	rcloneVersion, osVersion, osKernel            string
 	userAgent                                     string
@@ -78,62 +98,46 @@ func main() {
 
 	log.Infof("CSI Connector Version: %s, CommitID: %s, Build time: %s\n", VERSION, COMMITID, BUILDTIME)
 
-	var err error
-
-	logDir := filepath.Dir(LogFilename)
-	if err = ensureDirectoryExists(logDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to ensure directory %s exists: %s", logDir, err)
-		os.Exit(1)
-	}
-
-	pidDir := filepath.Dir(PIDFilename)
-	if err = ensureDirectoryExists(pidDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to ensure directory %s exists: %s", pidDir, err)
-		os.Exit(1)
-	}
-
-	sockDir := filepath.Dir(SocketPath)
-	if err = ensureDirectoryExists(sockDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to ensure directory %s exists: %s", sockDir, err)
-		os.Exit(1)
-	}
-
-	if userConfigDir, err := os.UserConfigDir(); err != nil {
-		rcloneConfigDir = filepath.Join(os.TempDir(), ".rclone", "config")
-	} else {
-		rcloneConfigDir = filepath.Join(userConfigDir, "rclone")
-	}
-
-	if userCacheDir, err := os.UserCacheDir(); err != nil {
-		rcloneCacheDir = filepath.Join(os.TempDir(), ".rclone", "cache")
-	} else {
-		rcloneCacheDir = filepath.Join(userCacheDir, "rclone")
-	}
-
-	if userLogDir, err := userLogDir(); err != nil {
-		rcloneLogDir = filepath.Join(os.TempDir(), ".rclone", "log")
-	} else {
-		rcloneLogDir = filepath.Join(userLogDir, "rclone")
-	}
-
-	if err = ensureDirectoryExists(rcloneConfigDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to ensure directory %s exists: %s", rcloneConfigDir, err)
-		os.Exit(1)
-	}
-	if err = ensureDirectoryExists(rcloneCacheDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to ensure directory %s exists: %s", rcloneCacheDir, err)
+	config, err := loadConnectorConfig(*configFilename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %s", err)
 		os.Exit(1)
 	}
-	if err = ensureDirectoryExists(rcloneLogDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to ensure directory %s exists: %s", rcloneLogDir, err)
-		os.Exit(1)
+	// 命令行中指定的并发限制优先于配置文件
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "max-concurrent-mounts":
+			config.Concurrency.MaxMounts = *maxConcurrentMounts
+		case "max-concurrent-mounts-per-volume":
+			config.Concurrency.MaxMountsPerVolume = *maxConcurrentMountsPerVolume
+		case "max-queued-mounts":
+			config.Concurrency.MaxQueued = *maxQueuedMounts
+		}
+	})
+	config.apply()
+	level, _ := log.ParseLevel(config.Log.Level)
+	log.SetLevel(level)
+
+	for _, dir := range []string{
+		filepath.Dir(config.Log.Filename),
+		filepath.Dir(config.Log.stderrFilename()),
+		filepath.Dir(config.PIDFilename),
+		filepath.Dir(config.SocketPath),
+		rcloneConfigDir,
+		rcloneCacheDir,
+		rcloneLogDir,
+	} {
+		if err = ensureDirectoryExists(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to ensure directory %s exists: %s", dir, err)
+			os.Exit(1)
+		}
 	}
 
-	if err := ensureCommandExists(KodoFSCmd); err != nil {
+	if err := ensureCommandExists(protocol.KodoFSCmd); err != nil {
 		log.Errorf("Please make sure kodofs is installed in PATH: %s", err)
 		os.Exit(1)
 	}
-	if err := ensureCommandExists(RcloneCmd); err != nil {
+	if err := ensureCommandExists(protocol.RcloneCmd); err != nil {
 		log.Errorf("Please make sure rclone is installed in PATH: %s", err)
 		os.Exit(1)
 	}
@@ -154,13 +158,13 @@ func main() {
 	userAgent = fmt.Sprintf("QiniuCSIDriver/%s/%s/rclone/%s/%s/%s", VERSION, COMMITID, rcloneVersion, osVersion, osKernel)
 
 	daemonCtx := &daemon.Context{
-		PidFileName: PIDFilename,
+		PidFileName: config.PIDFilename,
 		PidFilePerm: 0644,
-		LogFileName: LogFilename,
+		LogFileName: config.Log.stderrFilename(),
 		LogFilePerm: 0640,
 		WorkDir:     "./",
 		Umask:       077,
-		Args:        []string{ConnectorName},
+		Args:        append([]string{ConnectorName}, os.Args[1:]...),
 	}
 	child, err := daemonCtx.Reborn()
 	if err != nil {
@@ -173,22 +177,47 @@ func main() {
 	}
 	defer daemonCtx.Release()
 	// Now we're in the child process, continue
+	// 标准错误输出写入单独的文件，用于记录 panic，日志由 logWriter 轮转
+	// 两者不能是同一个文件，否则轮转后标准错误输出仍然写入被重命名的旧文件
+	log.SetOutput(config.Log.logWriter())
 	log.Infoln("Starting connector as daemon ...")
 
-	if err = ensureDirectoryExists(sockDir); err != nil {
-		log.Errorf("Failed to ensure directory %s exists: %s", sockDir, err)
+	if err = ensureDirectoryExists(filepath.Dir(config.SocketPath)); err != nil {
+		log.Errorf("Failed to ensure directory %s exists: %s", filepath.Dir(config.SocketPath), err)
 		os.Exit(1)
 	}
-	if err = ensureFileNotExists(SocketPath); err != nil {
-		log.Errorf("Failed to ensure file %s not exists: %s", SocketPath, err)
+	if err = ensureFileNotExists(config.SocketPath); err != nil {
+		log.Errorf("Failed to ensure file %s not exists: %s", config.SocketPath, err)
 		os.Exit(1)
 	}
-	socket, err := net.Listen("unix", SocketPath)
+	socket, err := net.Listen("unix", config.SocketPath)
 	if err != nil {
-		log.Errorf("Failed to listen on socket file %s: %s", SocketPath, err)
+		log.Errorf("Failed to listen on socket file %s: %s", config.SocketPath, err)
 		os.Exit(1)
 	}
 	defer socket.Close()
+
+	if err = moveLegacyKey(LegacyMountRegistryKeyFilename, MountRegistryKeyFilename); err != nil {
+		log.Errorf("Failed to move key of mount records: %s", err)
+		os.Exit(1)
+	}
+	if registry, err = newMountRegistry(MountRegistryDir, MountRegistryKeyFilename); err != nil {
+		log.Errorf("Failed to create mount registry: %s", err)
+		os.Exit(1)
+	}
+	limiter = newMountLimiter(config.Concurrency.MaxMounts, config.Concurrency.MaxMountsPerVolume, config.Concurrency.MaxQueued)
+	allocateRecordedCaches(registry)
+	go newMountWatchdog(registry).Run(*watchdogInterval)
+	serveMetrics(config.Metrics.Address)
+
+	// 同一个 socket 上同时提供 gRPC 服务和 v2 协议，滚动升级期间旧版本的插件仍然使用 v2 协议
+	listener := newGrpcListener(socket.Addr())
+	grpcServer := newGrpcServer()
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Errorf("Failed to serve gRPC: %s", err)
+		}
+	}()
 	log.Infoln("Connector daemon is started ...")
 
 	for {
@@ -197,12 +226,7 @@ func main() {
 			log.Infof("Failed to accept connection: %s", err)
 			continue
 		}
-		conn.SetDeadline(time.Now().Add(30 * time.Second))
-
-		cmdIn := make(chan protocol.Cmd)
-		cmdOut := make(chan protocol.Cmd)
-		go handleConn(conn, cmdIn, cmdOut)
-		go handleCmd(cmdIn, cmdOut)
+		go dispatchConn(conn, listener)
 	}
 }
 
@@ -281,7 +305,11 @@ func handleConn(conn net.Conn, cmdIn <-chan protocol.Cmd, cmdOut chan<- protocol
 				log.Warnf("Protocol %s payload parse error: %s", request.Cmd, err)
 				return
 			} else {
-				log.Infof("Received initKodoFsMountCmd: %#v", payload)
+				masked := *payload
+				if masked.AccessToken != "" {
+					masked.AccessToken = "***"
+				}
+				log.Infof("Received initKodoFsMountCmd: %#v", &masked)
 				cmdOut <- payload
 			}
 		case protocol.InitKodoMountCmdName:
@@ -311,6 +339,15 @@ func handleConn(conn net.Conn, cmdIn <-chan protocol.Cmd, cmdOut chan<- protocol
 				log.Infof("Received kodoUmountCmd: %#v", payload)
 				cmdOut <- payload
 			}
+		case protocol.KodoFSUmountCmdName:
+			payload := new(protocol.KodoFSUmountCmd)
+			if err := json.Unmarshal([]byte(request.Payload), payload); err != nil {
+				log.Warnf("Protocol %s payload parse error: %s", request.Cmd, err)
+				return
+			} else {
+				log.Infof("Received kodoFsUmountCmd: %#v", payload)
+				cmdOut <- payload
+			}
 		default:
 			log.Warnf("Unrecognized request cmd: %s", request.Cmd)
 			return
@@ -329,6 +366,8 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 		isClosed         uint32         = 0
 		execCmd          *exec.Cmd      = nil
 		rcloneConfigPath string         = ""
+		rcloneLogFile    string         = ""
+		stderrTail                      = newTailBuffer(MOUNT_OUTPUT_TAIL_SIZE)
 		stdin            io.WriteCloser = nil
 		stdout           io.ReadCloser  = nil
 		stderr           io.ReadCloser  = nil
@@ -354,7 +393,7 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 	outputReader := func(name string, output io.Reader, isError bool) {
 		for {
 			buf := make([]byte, 4096)
-			n, err := stdout.Read(buf)
+			n, err := output.Read(buf)
 			if err != nil {
 				if errors.Is(err, io.EOF) || errors.Is(err, os.ErrClosed) {
 					return
@@ -362,6 +401,9 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 				log.Errorf("Failed to read from %s: %s", name, err)
 				return
 			}
+			if isError {
+				stderrTail.WriteString(string(buf[:n]))
+			}
 			if atomic.LoadUint32(&isClosed) > 0 {
 				return
 			}
@@ -369,7 +411,7 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 		}
 	}
 
-	execCommand := func(ec *exec.Cmd, afterRun func()) bool {
+	execCommand := func(ec *exec.Cmd, afterRun func(exitCode int)) bool {
 		var err error
 		if execCmd != nil {
 			log.Warnf("Received duplicated init cmd, which is unacceptable")
@@ -395,14 +437,19 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 		go outputReader("stderr", stderr, true)
 		go func() {
 			defer cancel()
+			logOffset := fileSize(rcloneLogFile)
 			err := execCmd.Run()
 			if afterRun != nil {
-				afterRun()
+				afterRun(execCmd.ProcessState.ExitCode())
 			}
 			if atomic.LoadUint32(&isClosed) > 0 {
 				return
 			}
-			cmdOut <- &protocol.TerminateCmd{Code: execCmd.ProcessState.ExitCode()}
+			terminateCmd := &protocol.TerminateCmd{Code: execCmd.ProcessState.ExitCode()}
+			if err != nil {
+				terminateCmd.Error = newMountError(ctx, err, stderrTail.String(), rcloneLogFile, logOffset).toLegacy()
+			}
+			cmdOut <- terminateCmd
 			if err != nil {
 				log.Warnf("Failed to run command (%s): %s", execCmd, err)
 			} else {
@@ -413,7 +460,10 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 	}
 
 	for {
-		var err error
+		var (
+			err     error
+			release func()
+		)
 		select {
 		case cmd, ok := <-cmdIn:
 			if !ok {
@@ -422,52 +472,65 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 			log.Infof("Execute cmd: %#v", cmd)
 			switch c := cmd.(type) {
 			case *protocol.InitKodoFSMountCmd:
+				if release, ok = acquireLegacyMount(ctx, c.VolumeId, cmdOut); !ok {
+					return
+				}
 				if c.MayRunOnSystemd {
 					if err := useSystemdOrNot(ctx); err != nil {
 						log.Infof("Failed to detect systemd-run: %s", err)
 						c.MayRunOnSystemd = false
 					}
 				}
-				if ok := execCommand(c.ExecCommand(ctx), nil); !ok {
+				if ok := execCommand(c.ExecCommand(ctx), func(exitCode int) {
+					release()
+					if exitCode == 0 {
+						addMountRecord(&mountRecord{KodoFS: c})
+					}
+				}); !ok {
+					release()
 					return
 				}
 			case *protocol.InitKodoMountCmd:
+				if release, ok = acquireLegacyMount(ctx, c.VolumeId, cmdOut); !ok {
+					return
+				}
 				if c.MayRunOnSystemd {
 					if err := useSystemdOrNot(ctx); err != nil {
 						log.Infof("Failed to detect systemd-run: %s", err)
 						c.MayRunOnSystemd = false
 					}
 				}
-				if rcloneConfigPath, err = writeRcloneConfig(c); err != nil {
-					log.Warnf("Failed to write rclone config: %s", err)
-					return
-				}
-				uuid := rcloneCacheId(c.MountPath)
-				volumeCacheDir := filepath.Join(rcloneCacheDir, c.VolumeId, uuid)
-				if err = ensureDirectoryExists(volumeCacheDir); err != nil {
-					log.Errorf("Failed to ensure directory %s exists: %s", volumeCacheDir, err)
-					return
-				}
-				rcloneLogFile := filepath.Join(rcloneLogDir, c.VolumeId, uuid+".log")
-				if err = ensureDirectoryExists(filepath.Dir(rcloneLogFile)); err != nil {
-					log.Errorf("Failed to ensure directory %s exists: %s", filepath.Dir(rcloneLogFile), err)
+				if ctx, rcloneConfigPath, err = prepareKodoMount(ctx, c); err != nil {
+					log.Errorf("Failed to prepare kodo mount: %s", err)
+					nodeCacheBudget.Release(c.MountPath)
+					release()
 					return
 				}
-				ctx = context.WithValue(ctx, protocol.ContextKeyConfigFilePath, rcloneConfigPath)
-				ctx = context.WithValue(ctx, protocol.ContextKeyUserAgent, userAgent)
-				ctx = context.WithValue(ctx, protocol.ContextKeyLogFilePath, rcloneLogFile)
-				ctx = context.WithValue(ctx, protocol.ContextKeyCacheDirPath, volumeCacheDir)
-				if ok := execCommand(c.ExecCommand(ctx), func() { os.Remove(rcloneConfigPath) }); !ok {
+				rcloneLogFile = ctx.Value(protocol.ContextKeyLogFilePath).(string)
+				if ok := execCommand(c.ExecCommand(ctx), func(exitCode int) {
+					release()
+					os.Remove(rcloneConfigPath)
+					if exitCode == 0 {
+						addMountRecord(&mountRecord{Kodo: c})
+					} else {
+						nodeCacheBudget.Release(c.MountPath)
+					}
+				}); !ok {
+					nodeCacheBudget.Release(c.MountPath)
+					release()
 					return
 				}
 			case *protocol.KodoUmountCmd:
-				uuid := rcloneCacheId(c.MountPath)
-				volumeCacheDir := filepath.Join(rcloneCacheDir, c.VolumeId, uuid)
-				rcloneLogFile := filepath.Join(rcloneLogDir, c.VolumeId, uuid+".log")
-				os.RemoveAll(volumeCacheDir)
-				os.Remove(rcloneLogFile)
-				os.Remove(filepath.Dir(rcloneLogFile))
-				os.Remove(filepath.Dir(volumeCacheDir))
+				unlock := mountPathLocks.Lock(c.MountPath)
+				subDir := kodoMountSubDir(c.MountPath)
+				removeMountRecord(c.MountPath)
+				// v2 协议中由插件直接卸载，无法确认缓存中的文件已经上传
+				cleanKodoMount(c.VolumeId, subDir, c.MountPath, false)
+				unlock()
+			case *protocol.KodoFSUmountCmd:
+				unlock := mountPathLocks.Lock(c.MountPath)
+				removeMountRecord(c.MountPath)
+				unlock()
 			case *protocol.RequestDataCmd:
 				if stdin == nil {
 					log.Warnf("Received RequestDataCmd when process is not started")
@@ -483,3 +546,55 @@ func handleCmd(cmdOut chan<- protocol.Cmd, cmdIn <-chan protocol.Cmd) {
 		}
 	}
 }
+
+// acquireLegacyMount 等待 v2 协议的挂载请求满足并发限制，排队位置作为输出返回给插件
+// 失败时返回 TerminateCmd，排队的时间不超过 v2 协议中连接的超时时间
+func acquireLegacyMount(ctx context.Context, volumeId string, cmdOut chan<- protocol.Cmd) (func(), bool) {
+	ctx, cancel := context.WithTimeout(ctx, MOUNT_TIMEOUT)
+	defer cancel()
+
+	release, err := limiter.Acquire(ctx, volumeId, func(position int) {
+		log.Infof("Mount request of volume %s is queued at position %d", volumeId, position)
+		cmdOut <- &protocol.ResponseDataCmd{Data: fmt.Sprintf("mount request is queued at position %d\n", position)}
+	})
+	if err != nil {
+		log.Warnf("Failed to wait for mount of volume %s: %s", volumeId, err)
+		reason := connectorpb.MountErrorReason_MOUNT_ERROR_REASON_UNKNOWN
+		if errors.Is(err, context.DeadlineExceeded) {
+			reason = connectorpb.MountErrorReason_MOUNT_ERROR_REASON_TIMEOUT
+		}
+		cmdOut <- &protocol.TerminateCmd{Code: 1, Error: &protocol.MountError{Reason: reason.String(), Message: err.Error()}}
+		return nil, false
+	}
+	return release, true
+}
+
+// 挂载成功后记录挂载命令，记录失败不影响挂载结果
+func addMountRecord(record *mountRecord) {
+	if err := registry.Add(record); err != nil {
+		log.Warnf("Failed to record mount of volume %s: %s", record.volumeId(), err)
+	}
+}
+
+func removeMountRecord(mountPath string) {
+	if err := registry.Remove(mountPath); err != nil {
+		log.Warnf("Failed to remove mount record of %s: %s", mountPath, err)
+	}
+}
+
+// allocateRecordedCaches 为启动前已经挂载的 Kodo 挂载点重新分配缓存预算
+func allocateRecordedCaches(registry *mountRegistry) {
+	records, err := registry.List()
+	if err != nil {
+		log.Warnf("Failed to list mount records: %s", err)
+	}
+	for _, record := range records {
+		if record.Kodo == nil {
+			continue
+		}
+		c := *record.Kodo
+		if err = nodeCacheBudget.Allocate(&c); err != nil {
+			log.Warnf("Failed to allocate cache of %s: %s", c.MountPath, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const METRICS_SUBSYSTEM = "connector"
+
+var (
+	mountCommandsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: protocol.MetricsNamespace,
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "mount_commands_total",
+		Help:      "Number of mount commands run by the connector, including remounts, by mount type and gRPC code.",
+	}, []string{"type", "code"})
+	mountCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: protocol.MetricsNamespace,
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "mount_command_duration_seconds",
+		Help:      "Latency of mount commands run by the connector, excluding the time waiting in the queue.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 20, 30},
+	}, []string{"type"})
+	remountsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: protocol.MetricsNamespace,
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "remounts_total",
+		Help:      "Number of mount points remounted by the watchdog, by mount type and gRPC code.",
+	}, []string{"type", "code"})
+	queuedMounts = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: protocol.MetricsNamespace,
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "queued_mounts",
+		Help:      "Number of mount requests waiting for the concurrency limits.",
+	}, func() float64 { return float64(limiter.Queued()) })
+	cacheBudgetAllocatedBytes = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: protocol.MetricsNamespace,
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "cache_budget_allocated_bytes",
+		Help:      "Sum of the VFS cache sizes allocated to Kodo mount points, only counted if cache.maxTotalSize is set.",
+	}, func() float64 { return float64(nodeCacheBudget.Allocated()) })
+)
+
+// newMetricsHandler 返回 connector 的 /metrics 处理函数
+func newMetricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	registry.MustRegister(mountCommandsTotal, mountCommandDuration, remountsTotal, queuedMounts, cacheBudgetAllocatedBytes)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// serveMetrics 在 address 上提供 /metrics，address 为空时不提供
+func serveMetrics(address string) {
+	if address == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", newMetricsHandler())
+	go func() {
+		if err := http.ListenAndServe(address, mux); err != nil {
+			log.Errorf("Failed to serve metrics on %s: %s", address, err)
+		}
+	}()
+}
+
+// observeMountCommand 记录一次挂载命令的结果和耗时
+func observeMountCommand(record *mountRecord, start time.Time, err error) {
+	mountType := record.mountType()
+	mountCommandDuration.WithLabelValues(mountType).Observe(time.Since(start).Seconds())
+	mountCommandsTotal.WithLabelValues(mountType, mountErrorCode(record, err).String()).Inc()
+}
+
+// mountErrorCode 返回挂载失败的原因对应的 gRPC 状态码，与 Mount 返回给插件的一致
+func mountErrorCode(record *mountRecord, err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	return status.Code(mountStatusError(record, err))
+}
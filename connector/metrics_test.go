@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestObserveMountCommand(t *testing.T) {
+	record := &mountRecord{KodoFS: &protocol.InitKodoFSMountCmd{VolumeId: "kodofs-1", MountPath: "/mnt/kodofs-1"}}
+	okBefore := testutil.ToFloat64(mountCommandsTotal.WithLabelValues("kodofs", codes.OK.String()))
+	exhaustedBefore := testutil.ToFloat64(mountCommandsTotal.WithLabelValues("kodofs", codes.ResourceExhausted.String()))
+
+	observeMountCommand(record, time.Now(), nil)
+	observeMountCommand(record, time.Now(), errMountQueueFull)
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(mountCommandsTotal.WithLabelValues("kodofs", codes.OK.String())))
+	assert.Equal(t, exhaustedBefore+1, testutil.ToFloat64(mountCommandsTotal.WithLabelValues("kodofs", codes.ResourceExhausted.String())))
+
+	recorder := httptest.NewRecorder()
+	newMetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	for _, name := range []string{
+		"qiniu_csi_connector_mount_commands_total",
+		"qiniu_csi_connector_mount_command_duration_seconds",
+		"qiniu_csi_connector_queued_mounts",
+		"qiniu_csi_connector_cache_budget_allocated_bytes",
+	} {
+		assert.True(t, strings.Contains(recorder.Body.String(), name), name)
+	}
+}
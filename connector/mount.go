@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/qiniu/kubernetes-csi-driver/bindmount"
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// 与 v2 协议中连接的超时时间一致
+	MOUNT_TIMEOUT = 30 * time.Second
+	// 挂载失败时返回的标准错误输出和 rclone 日志的最大长度
+	MOUNT_OUTPUT_TAIL_SIZE = 4096
+	// 合并后的挂载包括排队在内的最长时间，与插件等待 connector 的超时时间一致，发起挂载的请求被取消后挂载仍然继续
+	SHARED_MOUNT_TIMEOUT = time.Minute
+)
+
+// errReadOnlyNotEnforced 挂载命令成功退出，但挂载点不是只读的
+var errReadOnlyNotEnforced = errors.New("read-only option is not enforced by the mount command")
+
+// outputHandler 处理挂载命令的一段输出
+type outputHandler func(data string, isError bool)
+
+// 使用记录的挂载命令重新挂载，不会修改记录，与其他挂载请求共享并发限制
+func remount(record *mountRecord) (err error) {
+	defer func() { remountsTotal.WithLabelValues(record.mountType(), mountErrorCode(record, err).String()).Inc() }()
+	ctx, cancel := context.WithTimeout(context.Background(), MOUNT_TIMEOUT)
+	defer cancel()
+
+	_, err = inflightMounts.Do(ctx, record, func(ctx context.Context) error {
+		release, err := limiter.Acquire(ctx, record.volumeId(), nil)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), MOUNT_TIMEOUT)
+		defer cancel()
+		return runMountCommand(ctx, record, func(data string, isError bool) {
+			if isError {
+				log.Warnf("Remount volume %s stderr: %s", record.volumeId(), data)
+			} else {
+				log.Infof("Remount volume %s stdout: %s", record.volumeId(), data)
+			}
+		})
+	})
+	return err
+}
+
+// runMountCommand 执行挂载命令直到其退出，KodoFS 询问的 master 地址和 AccessToken 由 connector 回答
+func runMountCommand(ctx context.Context, record *mountRecord, onOutput outputHandler) (runErr error) {
+	defer func(start time.Time) { observeMountCommand(record, start, runErr) }(time.Now())
+
+	var (
+		execCmd       *exec.Cmd
+		answers       map[string]string
+		rcloneLogFile string
+	)
+	if record.Kodo != nil {
+		c := *record.Kodo
+		if c.MayRunOnSystemd {
+			c.MayRunOnSystemd = canUseSystemd(ctx)
+		}
+		ctx, rcloneConfigPath, err := prepareKodoMount(ctx, &c)
+		if err != nil {
+			nodeCacheBudget.Release(c.MountPath)
+			return err
+		}
+		defer os.Remove(rcloneConfigPath)
+		defer func() {
+			if runErr != nil {
+				nodeCacheBudget.Release(c.MountPath)
+			}
+		}()
+		rcloneLogFile = ctx.Value(protocol.ContextKeyLogFilePath).(string)
+		execCmd = c.ExecCommand(ctx)
+	} else if record.KodoFS != nil {
+		c := *record.KodoFS
+		if c.MasterAddress == "" || c.AccessToken == "" {
+			return errors.New("master address or access token is empty")
+		}
+		if c.MayRunOnSystemd {
+			c.MayRunOnSystemd = canUseSystemd(ctx)
+		}
+		execCmd = c.ExecCommand(ctx)
+		answers = map[string]string{
+			protocol.KodoFSMasterAddressPrompt: c.MasterAddress + "\n",
+			protocol.KodoFSAccessTokenPrompt:   c.AccessToken + "\n",
+		}
+	} else {
+		return errors.New("empty mount record")
+	}
+
+	stdin, err := execCmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := execCmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := execCmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	stderrTail := newTailBuffer(MOUNT_OUTPUT_TAIL_SIZE)
+	go readOutput("stdout", stdout, false, onOutput, stdin, answers)
+	go readOutput("stderr", stderr, true, func(data string, isError bool) {
+		stderrTail.WriteString(data)
+		onOutput(data, isError)
+	}, nil, nil)
+
+	logOffset := fileSize(rcloneLogFile)
+	if err = execCmd.Run(); err != nil {
+		return newMountError(ctx, err, stderrTail.String(), rcloneLogFile, logOffset)
+	}
+	if record.readOnly() {
+		// 挂载命令可能忽略只读选项，确认内核中的挂载点是只读的，否则卸载并返回错误
+		if err = verifyReadOnlyMount(record.mountPath()); err != nil {
+			if unmountErr := unmountFuse(record.mountPath()); unmountErr != nil {
+				log.Warnf("Failed to unmount %s which is not read-only: %s", record.mountPath(), unmountErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyReadOnlyMount 检查 mountPath 上最上层的挂载是否为只读，找不到挂载点时同样视为没有生效
+func verifyReadOnlyMount(mountPath string) error {
+	mounts, err := mountinfo.GetMounts(func(info *mountinfo.Info) (skip, stop bool) {
+		return info.Mountpoint != mountPath, false
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the mount point: %w", err)
+	}
+	return checkReadOnlyMount(mounts, mountPath)
+}
+
+func checkReadOnlyMount(mounts []*mountinfo.Info, mountPath string) error {
+	var target *mountinfo.Info
+	for _, mount := range mounts {
+		if mount.Mountpoint == mountPath {
+			target = mount
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("%w: %s is not mounted", errReadOnlyNotEnforced, mountPath)
+	} else if !bindmount.IsReadOnly(target) {
+		return fmt.Errorf("%w: %s is mounted with options %s", errReadOnlyNotEnforced, mountPath, target.Options)
+	}
+	return nil
+}
+
+// mountError 挂载命令执行失败的原因
+type mountError struct {
+	reason  connectorpb.MountErrorReason
+	message string
+	output  string
+}
+
+func (err *mountError) Error() string {
+	return err.message
+}
+
+func (err *mountError) toProto() *connectorpb.MountError {
+	return &connectorpb.MountError{Reason: err.reason, Message: err.message, Output: err.output}
+}
+
+func (err *mountError) toLegacy() *protocol.MountError {
+	return &protocol.MountError{Reason: err.reason.String(), Message: err.message, Output: err.output}
+}
+
+// newMountError 根据挂载命令的标准错误输出和本次挂载写入的 rclone 日志判断失败原因
+func newMountError(ctx context.Context, runErr error, stderr, rcloneLogFile string, logOffset int64) *mountError {
+	output := strings.TrimRight(stderr, "\n")
+	if rcloneLogFile != "" {
+		if tail, err := readFileTail(rcloneLogFile, logOffset, MOUNT_OUTPUT_TAIL_SIZE); err != nil {
+			log.Warnf("Failed to read rclone log file %s: %s", rcloneLogFile, err)
+		} else if tail != "" {
+			output = strings.TrimLeft(output+"\n"+strings.TrimRight(tail, "\n"), "\n")
+		}
+	}
+	reason, line := protocol.ClassifyMountError(output)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = connectorpb.MountErrorReason_MOUNT_ERROR_REASON_TIMEOUT
+	}
+	if line == "" {
+		line = runErr.Error()
+	}
+	return &mountError{
+		reason:  reason,
+		message: fmt.Sprintf("%s: %s", protocol.DescribeMountError(reason), line),
+		output:  output,
+	}
+}
+
+// 文件不存在时返回 0
+func fileSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	if fileInfo, err := os.Stat(path); err == nil {
+		return fileInfo.Size()
+	}
+	return 0
+}
+
+// readFileTail 读取文件从 offset 开始的内容，最多读取最后 size 字节
+func readFileTail(path string, offset, size int64) (string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if fileInfo.Size() < offset {
+		// 日志文件被截断过
+		offset = 0
+	}
+	if fileInfo.Size()-offset > size {
+		offset = fileInfo.Size() - size
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, offset, fileInfo.Size()-offset))
+	return string(data), err
+}
+
+// tailBuffer 只保留最后写入的 size 字节
+type tailBuffer struct {
+	lock sync.Mutex
+	size int
+	data []byte
+}
+
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{size: size}
+}
+
+func (buffer *tailBuffer) WriteString(s string) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	buffer.data = append(buffer.data, s...)
+	if len(buffer.data) > buffer.size {
+		buffer.data = append([]byte(nil), buffer.data[len(buffer.data)-buffer.size:]...)
+	}
+}
+
+func (buffer *tailBuffer) String() string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	return string(buffer.data)
+}
+
+// 读取命令的输出，输出中出现 answers 中的询问时，将对应的回答写入 stdin，每个询问只回答一次
+func readOutput(name string, output io.Reader, isError bool, onOutput outputHandler, stdin io.Writer, answers map[string]string) {
+	var (
+		received strings.Builder
+		answered = make(map[string]bool)
+	)
+	buf := make([]byte, 4096)
+	for {
+		n, err := output.Read(buf)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) {
+				log.Warnf("Failed to read from %s: %s", name, err)
+			}
+			return
+		}
+		onOutput(string(buf[:n]), isError)
+		if len(answers) == 0 {
+			continue
+		}
+		received.Write(buf[:n])
+		for prompt, answer := range answers {
+			if answered[prompt] || !strings.Contains(received.String(), prompt) {
+				continue
+			}
+			answered[prompt] = true
+			if _, err = io.WriteString(stdin, answer); err != nil {
+				log.Warnf("Failed to write answer into stdin: %s", err)
+				return
+			}
+		}
+	}
+}
+
+func canUseSystemd(ctx context.Context) bool {
+	if err := useSystemdOrNot(ctx); err != nil {
+		log.Infof("Failed to detect systemd-run: %s", err)
+		return false
+	}
+	return true
+}
+
+// 写入 rclone 配置，创建缓存和日志目录，并将它们的路径和配置文件中的默认参数放入 ctx 中
+// 缓存的最大大小不超过配置文件中的限制，并从节点的缓存预算中分配，失败或挂载命令失败时需要调用 nodeCacheBudget.Release
+func prepareKodoMount(ctx context.Context, c *protocol.InitKodoMountCmd) (context.Context, string, error) {
+	if err := nodeCacheBudget.Allocate(c); err != nil {
+		return ctx, "", err
+	}
+	rcloneConfigPath, err := writeRcloneConfig(c)
+	if err != nil {
+		return ctx, "", fmt.Errorf("failed to write rclone config: %w", err)
+	}
+	uuid := rcloneCacheId(c.MountPath)
+	volumeCacheDir := filepath.Join(rcloneCacheDir, c.VolumeId, uuid)
+	if !c.ReadOnly {
+		adoptRetainedCache(c.VolumeId, c.SubDir, volumeCacheDir)
+	}
+	if err = ensureDirectoryExists(volumeCacheDir); err != nil {
+		os.Remove(rcloneConfigPath)
+		return ctx, "", fmt.Errorf("failed to ensure directory %s exists: %w", volumeCacheDir, err)
+	}
+	rcloneLogFile := filepath.Join(rcloneLogDir, c.VolumeId, uuid+".log")
+	if err = ensureDirectoryExists(filepath.Dir(rcloneLogFile)); err != nil {
+		os.Remove(rcloneConfigPath)
+		return ctx, "", fmt.Errorf("failed to ensure directory %s exists: %w", filepath.Dir(rcloneLogFile), err)
+	}
+	rcSocketPath := rcloneRcSocketPath(c.MountPath)
+	if err = ensureDirectoryExists(filepath.Dir(rcSocketPath)); err != nil {
+		os.Remove(rcloneConfigPath)
+		return ctx, "", fmt.Errorf("failed to ensure directory %s exists: %w", filepath.Dir(rcSocketPath), err)
+	} else if err = ensureFileNotExists(rcSocketPath); err != nil {
+		// 上一个挂载进程退出后遗留的 socket
+		os.Remove(rcloneConfigPath)
+		return ctx, "", fmt.Errorf("failed to remove stale rc socket %s: %w", rcSocketPath, err)
+	}
+	ctx = context.WithValue(ctx, protocol.ContextKeyConfigFilePath, rcloneConfigPath)
+	ctx = context.WithValue(ctx, protocol.ContextKeyUserAgent, userAgent)
+	ctx = context.WithValue(ctx, protocol.ContextKeyLogFilePath, rcloneLogFile)
+	ctx = context.WithValue(ctx, protocol.ContextKeyCacheDirPath, volumeCacheDir)
+	ctx = context.WithValue(ctx, protocol.ContextKeyRcSocketPath, rcSocketPath)
+	ctx = context.WithValue(ctx, protocol.ContextKeyDefaultFlags, rcloneDefaultFlags)
+	return ctx, rcloneConfigPath, nil
+}
+
+// 删除 Kodo 存储卷在挂载点上的日志和远程控制 socket，只有 rclone 确认缓存中的文件已经全部上传时才删除缓存
+// 没有确认上传的缓存被移动到按存储卷和子目录区分的目录中，由之后挂载同一存储卷同一子目录的 rclone 继续上传
+func cleanKodoMount(volumeId, subDir, mountPath string, flushed bool) {
+	uuid := rcloneCacheId(mountPath)
+	volumeCacheDir := filepath.Join(rcloneCacheDir, volumeId, uuid)
+	rcloneLogFile := filepath.Join(rcloneLogDir, volumeId, uuid+".log")
+	if flushed {
+		os.RemoveAll(volumeCacheDir)
+		os.Remove(filepath.Dir(volumeCacheDir))
+	} else if _, err := os.Stat(volumeCacheDir); err == nil {
+		retainCache(volumeId, subDir, volumeCacheDir)
+	}
+	os.Remove(rcloneLogFile)
+	os.Remove(filepath.Dir(rcloneLogFile))
+	os.Remove(rcloneRcSocketPath(mountPath))
+	nodeCacheBudget.Release(mountPath)
+}
+
+// kodoMountSubDir 从挂载记录中读取 Kodo 挂载点的子目录，需要在删除记录前调用
+func kodoMountSubDir(mountPath string) string {
+	record, err := registry.Get(mountPath)
+	if err != nil {
+		log.Warnf("Failed to get mount record of %s: %s", mountPath, err)
+		return ""
+	} else if record == nil || record.Kodo == nil {
+		return ""
+	}
+	return record.Kodo.SubDir
+}
+
+// retainedCacheDir 返回存储卷子目录被保留的缓存目录，rclone 的缓存路径包含 bucket 和子目录，只能由挂载同一子目录的 rclone 使用
+func retainedCacheDir(volumeId, subDir string) string {
+	return filepath.Join(rcloneCacheDir, volumeId, "retained-"+rcloneCacheId(volumeId, subDir))
+}
+
+// retainCache 保留没有确认上传的缓存，已经有被保留的缓存时留在原处
+func retainCache(volumeId, subDir, volumeCacheDir string) {
+	retainedDir := retainedCacheDir(volumeId, subDir)
+	if _, err := os.Stat(retainedDir); err == nil {
+		log.Warnf("Uploads in cache %s of volume %s are not confirmed, keep the cache since %s already exists", volumeCacheDir, volumeId, retainedDir)
+	} else if err = os.Rename(volumeCacheDir, retainedDir); err != nil {
+		log.Warnf("Uploads in cache %s of volume %s are not confirmed, failed to move it to %s, keep the cache: %s", volumeCacheDir, volumeId, retainedDir, err)
+	} else {
+		log.Warnf("Uploads in cache of volume %s are not confirmed, keep the cache in %s", volumeId, retainedDir)
+	}
+}
+
+// adoptRetainedCache 挂载点没有自己的缓存时，使用之前卸载时保留的缓存，rclone 启动后会继续上传其中的文件
+func adoptRetainedCache(volumeId, subDir, volumeCacheDir string) {
+	if _, err := os.Stat(volumeCacheDir); err == nil {
+		return
+	}
+	retainedDir := retainedCacheDir(volumeId, subDir)
+	if err := os.Rename(retainedDir, volumeCacheDir); err == nil {
+		log.Infof("Retained cache %s of volume %s is used by %s", retainedDir, volumeId, volumeCacheDir)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("Failed to use retained cache %s of volume %s: %s", retainedDir, volumeId, err)
+	}
+}
+
+// unmountFuse 使用 fusermount3 卸载，挂载点仍被占用时延迟卸载
+func unmountFuse(mountPath string) error {
+	output, err := exec.Command(FusermountCmd, "-u", mountPath).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	log.Warnf("Failed to unmount %s: %s: %s, unmount it lazily", mountPath, err, strings.TrimSpace(string(output)))
+	if output, err = exec.Command(FusermountCmd, "-uz", mountPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var (
+	// errMountQueueFull 排队的挂载请求过多，插件应当稍后重试
+	errMountQueueFull = errors.New("too many mount requests are queued")
+	// errMountConflict 同一个挂载点上正在执行另一个不同的挂载命令
+	errMountConflict = errors.New("another mount with different arguments is in progress")
+)
+
+type mountWaiter struct {
+	volumeId string
+	ready    chan struct{}
+}
+
+// mountLimiter 限制同时执行的挂载命令数量，包括全局的数量和每个存储卷的数量，值为 0 时不限制
+// 超出限制的请求按照先进先出的顺序排队，一个存储卷达到限制时不会阻塞其他存储卷的请求
+type mountLimiter struct {
+	maxMounts          int
+	maxMountsPerVolume int
+	maxQueued          int
+
+	lock             sync.Mutex
+	running          int
+	runningPerVolume map[string]int
+	waiters          *list.List
+}
+
+func newMountLimiter(maxMounts, maxMountsPerVolume, maxQueued int) *mountLimiter {
+	return &mountLimiter{
+		maxMounts:          maxMounts,
+		maxMountsPerVolume: maxMountsPerVolume,
+		maxQueued:          maxQueued,
+		runningPerVolume:   make(map[string]int),
+		waiters:            list.New(),
+	}
+}
+
+// Acquire 等待直到可以执行存储卷的挂载命令，需要排队时以排队位置（从 1 开始）调用 onQueued
+// 成功时返回的 release 必须在挂载命令退出后调用
+func (limiter *mountLimiter) Acquire(ctx context.Context, volumeId string, onQueued func(position int)) (release func(), err error) {
+	release = func() { limiter.release(volumeId) }
+
+	limiter.lock.Lock()
+	// 排队中的请求都因为达到限制而无法执行，所以可以执行时无需排队
+	if limiter.canRun(volumeId) {
+		limiter.start(volumeId)
+		limiter.lock.Unlock()
+		return release, nil
+	}
+	if limiter.maxQueued > 0 && limiter.waiters.Len() >= limiter.maxQueued {
+		limiter.lock.Unlock()
+		return nil, errMountQueueFull
+	}
+	waiter := &mountWaiter{volumeId: volumeId, ready: make(chan struct{})}
+	element := limiter.waiters.PushBack(waiter)
+	position := limiter.waiters.Len()
+	limiter.lock.Unlock()
+
+	if onQueued != nil {
+		onQueued(position)
+	}
+	select {
+	case <-waiter.ready:
+		return release, nil
+	case <-ctx.Done():
+		limiter.lock.Lock()
+		defer limiter.lock.Unlock()
+		select {
+		case <-waiter.ready:
+			// 取消的同时已经轮到这个请求，需要让给下一个请求
+			limiter.finish(volumeId)
+			limiter.dispatch()
+		default:
+			limiter.waiters.Remove(element)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Queued 返回正在排队的请求数量
+func (limiter *mountLimiter) Queued() int {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	return limiter.waiters.Len()
+}
+
+func (limiter *mountLimiter) release(volumeId string) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	limiter.finish(volumeId)
+	limiter.dispatch()
+}
+
+func (limiter *mountLimiter) canRun(volumeId string) bool {
+	if limiter.maxMounts > 0 && limiter.running >= limiter.maxMounts {
+		return false
+	} else if limiter.maxMountsPerVolume > 0 && limiter.runningPerVolume[volumeId] >= limiter.maxMountsPerVolume {
+		return false
+	}
+	return true
+}
+
+func (limiter *mountLimiter) start(volumeId string) {
+	limiter.running++
+	limiter.runningPerVolume[volumeId]++
+}
+
+func (limiter *mountLimiter) finish(volumeId string) {
+	limiter.running--
+	if limiter.runningPerVolume[volumeId]--; limiter.runningPerVolume[volumeId] <= 0 {
+		delete(limiter.runningPerVolume, volumeId)
+	}
+}
+
+// dispatch 按照排队顺序唤醒可以执行的请求
+func (limiter *mountLimiter) dispatch() {
+	for element := limiter.waiters.Front(); element != nil; {
+		next := element.Next()
+		waiter := element.Value.(*mountWaiter)
+		if limiter.canRun(waiter.volumeId) {
+			limiter.waiters.Remove(element)
+			limiter.start(waiter.volumeId)
+			close(waiter.ready)
+		}
+		element = next
+	}
+}
+
+// pathLocks 为每个挂载点提供一个互斥锁，串行化卸载和 mountWatchdog 的检查，避免挂载点在卸载期间被重新挂载
+type pathLocks struct {
+	lock  sync.Mutex
+	locks map[string]*pathLock
+}
+
+type pathLock struct {
+	sync.Mutex
+	// 持有或等待这个锁的数量，为 0 时删除
+	refs int
+}
+
+func newPathLocks() *pathLocks {
+	return &pathLocks{locks: make(map[string]*pathLock)}
+}
+
+// Lock 锁定挂载点，返回的 unlock 必须被调用
+func (locks *pathLocks) Lock(mountPath string) (unlock func()) {
+	locks.lock.Lock()
+	lock, ok := locks.locks[mountPath]
+	if !ok {
+		lock = &pathLock{}
+		locks.locks[mountPath] = lock
+	}
+	lock.refs++
+	locks.lock.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		locks.lock.Lock()
+		defer locks.lock.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(locks.locks, mountPath)
+		}
+	}
+}
+
+type mountCall struct {
+	record *mountRecord
+	done   chan struct{}
+	err    error
+}
+
+// mountDeduplicator 合并同一个挂载点上相同的并发挂载请求，只执行一次挂载命令
+type mountDeduplicator struct {
+	lock  sync.Mutex
+	calls map[string]*mountCall
+}
+
+func newMountDeduplicator() *mountDeduplicator {
+	return &mountDeduplicator{calls: make(map[string]*mountCall)}
+}
+
+// Do 执行挂载，如果挂载点上正在执行相同的挂载则等待其结果，shared 表示结果来自另一个请求
+// 挂载点上正在执行不同的挂载时返回 errMountConflict
+// mount 在不随请求取消的 ctx 中执行，发起挂载的请求被取消时只有它自己返回 ctx.Err()，挂载继续执行，等待的请求仍然得到挂载的结果
+func (deduplicator *mountDeduplicator) Do(ctx context.Context, record *mountRecord, mount func(ctx context.Context) error) (shared bool, err error) {
+	mountPath := record.mountPath()
+
+	deduplicator.lock.Lock()
+	call, shared := deduplicator.calls[mountPath]
+	if shared {
+		deduplicator.lock.Unlock()
+		if !reflect.DeepEqual(call.record, record) {
+			return false, errMountConflict
+		}
+	} else {
+		call = &mountCall{record: record, done: make(chan struct{})}
+		deduplicator.calls[mountPath] = call
+		deduplicator.lock.Unlock()
+
+		go func() {
+			mountCtx, cancel := context.WithTimeout(context.Background(), SHARED_MOUNT_TIMEOUT)
+			defer cancel()
+			call.err = mount(mountCtx)
+
+			deduplicator.lock.Lock()
+			delete(deduplicator.calls, mountPath)
+			deduplicator.lock.Unlock()
+			close(call.done)
+		}()
+	}
+
+	select {
+	case <-call.done:
+		return shared, call.err
+	case <-ctx.Done():
+		return shared, ctx.Err()
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountLimiter(t *testing.T) {
+	limiter := newMountLimiter(2, 1, 1)
+	ctx := context.Background()
+
+	releaseA, err := limiter.Acquire(ctx, "volume-a", nil)
+	assert.NoError(t, err)
+	// volume-a 达到每个存储卷的限制，但不影响 volume-b
+	releaseB, err := limiter.Acquire(ctx, "volume-b", nil)
+	assert.NoError(t, err)
+
+	// 全局限制已满，volume-c 需要排队
+	positions := make(chan int, 1)
+	acquired := make(chan func())
+	go func() {
+		release, err := limiter.Acquire(ctx, "volume-c", func(position int) { positions <- position })
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	assert.Equal(t, 1, <-positions)
+
+	// 队列已满
+	_, err = limiter.Acquire(ctx, "volume-d", nil)
+	assert.ErrorIs(t, err, errMountQueueFull)
+
+	releaseA()
+	releaseC := <-acquired
+	assert.Equal(t, 0, limiter.Queued())
+
+	// 排队超时后离开队列
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(timeoutCtx, "volume-a", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, limiter.Queued())
+
+	releaseB()
+	releaseC()
+	release, err := limiter.Acquire(ctx, "volume-a", nil)
+	assert.NoError(t, err)
+	release()
+}
+
+func TestMountDeduplicator(t *testing.T) {
+	deduplicator := newMountDeduplicator()
+	record := &mountRecord{Kodo: &protocol.InitKodoMountCmd{VolumeId: "kodo-1", MountPath: "/mnt/kodo"}}
+	mountErr := errors.New("mount failed")
+
+	var calls int32
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	results := make(chan bool, 2)
+	go func() {
+		shared, err := deduplicator.Do(context.Background(), record, func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-finish
+			return mountErr
+		})
+		assert.ErrorIs(t, err, mountErr)
+		results <- shared
+	}()
+	<-started
+
+	// 不同的挂载命令不能合并
+	other := &mountRecord{Kodo: &protocol.InitKodoMountCmd{VolumeId: "kodo-2", MountPath: "/mnt/kodo"}}
+	_, err := deduplicator.Do(context.Background(), other, func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, errMountConflict)
+
+	go func() {
+		same := &mountRecord{Kodo: &protocol.InitKodoMountCmd{VolumeId: "kodo-1", MountPath: "/mnt/kodo"}}
+		shared, err := deduplicator.Do(context.Background(), same, func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		})
+		assert.ErrorIs(t, err, mountErr)
+		results <- shared
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(finish)
+
+	assert.ElementsMatch(t, []bool{false, true}, []bool{<-results, <-results})
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestMountDeduplicatorLeaderCanceled(t *testing.T) {
+	deduplicator := newMountDeduplicator()
+	record := &mountRecord{Kodo: &protocol.InitKodoMountCmd{VolumeId: "kodo-1", MountPath: "/mnt/kodo"}}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderResult := make(chan error, 1)
+	go func() {
+		_, err := deduplicator.Do(leaderCtx, record, func(ctx context.Context) error {
+			close(started)
+			select {
+			case <-finish:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		leaderResult <- err
+	}()
+	<-started
+
+	waiterResult := make(chan error, 1)
+	go func() {
+		shared, err := deduplicator.Do(context.Background(), record, func(ctx context.Context) error {
+			return errors.New("should not run")
+		})
+		assert.True(t, shared)
+		waiterResult <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	// 发起挂载的请求被取消时只有它自己失败，挂载继续执行，等待的请求得到挂载的结果
+	cancelLeader()
+	assert.ErrorIs(t, <-leaderResult, context.Canceled)
+	close(finish)
+	assert.NoError(t, <-waiterResult)
+}
+
+func TestPathLocks(t *testing.T) {
+	locks := newPathLocks()
+	unlock := locks.Lock("/mnt/kodo-1")
+
+	// 不同的挂载点互不影响
+	locks.Lock("/mnt/kodo-2")()
+
+	locked := make(chan struct{})
+	go func() {
+		unlockAgain := locks.Lock("/mnt/kodo-1")
+		close(locked)
+		unlockAgain()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("mount path is locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+
+	// 所有锁都被释放后不再保留
+	assert.Eventually(t, func() bool {
+		locks.lock.Lock()
+		defer locks.lock.Unlock()
+		return len(locks.locks) == 0
+	}, time.Second, 10*time.Millisecond)
+}
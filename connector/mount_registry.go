@@ -0,0 +1,282 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+)
+
+var (
+	// MountRegistryDir 保存已挂载存储卷的挂载命令，用于在 connector 重启或挂载进程崩溃后恢复挂载
+	MountRegistryDir = "/var/lib/qiniu/storage/csi-plugin/mounts"
+	// MountRegistryKeyFilename 加密挂载命令的密钥文件，挂载命令中包含密钥等敏感信息
+	// 密钥不与挂载记录保存在同一个目录中，只复制或备份了记录的目录时无法解密
+	MountRegistryKeyFilename = "/etc/qiniu/storage/csi-plugin/connector.key"
+	// LegacyMountRegistryKeyFilename 之前的版本保存在挂载记录旁边的密钥文件，启动时被移动到 MountRegistryKeyFilename
+	LegacyMountRegistryKeyFilename = "/var/lib/qiniu/storage/csi-plugin/connector.key"
+)
+
+const (
+	MOUNT_REGISTRY_KEY_SIZE    = 32
+	MOUNT_REGISTRY_FILE_SUFFIX = ".mount"
+)
+
+// mountRecord 记录一个挂载点的挂载命令，Kodo 和 KodoFS 有且只有一个不为空
+type mountRecord struct {
+	Kodo   *protocol.InitKodoMountCmd   `json:"kodo,omitempty"`
+	KodoFS *protocol.InitKodoFSMountCmd `json:"kodofs,omitempty"`
+}
+
+func (record *mountRecord) mountPath() string {
+	if record.Kodo != nil {
+		return record.Kodo.MountPath
+	} else if record.KodoFS != nil {
+		return record.KodoFS.MountPath
+	}
+	return ""
+}
+
+func (record *mountRecord) volumeId() string {
+	if record.Kodo != nil {
+		return record.Kodo.VolumeId
+	} else if record.KodoFS != nil {
+		return record.KodoFS.VolumeId
+	}
+	return ""
+}
+
+// mountType 返回挂载点的类型，作为指标的标签
+func (record *mountRecord) mountType() string {
+	if record.Kodo != nil {
+		return "kodo"
+	} else if record.KodoFS != nil {
+		return "kodofs"
+	}
+	return "unknown"
+}
+
+func (record *mountRecord) readOnly() bool {
+	if record.Kodo != nil {
+		return record.Kodo.ReadOnly
+	} else if record.KodoFS != nil {
+		return record.KodoFS.ReadOnly
+	}
+	return false
+}
+
+// mountRegistry 将每个挂载点的挂载命令以 AES-GCM 加密后保存在一个文件中
+type mountRegistry struct {
+	dir  string
+	aead cipher.AEAD
+	lock sync.Mutex
+}
+
+func newMountRegistry(dir, keyFilename string) (*mountRegistry, error) {
+	if err := ensureDirectoryExists(dir); err != nil {
+		return nil, fmt.Errorf("newMountRegistry: ensure directory %s exists error: %w", dir, err)
+	}
+	if err := ensureDirectoryExists(filepath.Dir(keyFilename)); err != nil {
+		return nil, fmt.Errorf("newMountRegistry: ensure directory %s exists error: %w", filepath.Dir(keyFilename), err)
+	}
+	key, err := loadOrGenerateKey(keyFilename)
+	if err != nil {
+		return nil, fmt.Errorf("newMountRegistry: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("newMountRegistry: create cipher error: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("newMountRegistry: create gcm error: %w", err)
+	}
+	return &mountRegistry{dir: dir, aead: aead}, nil
+}
+
+// 读取密钥文件，如果不存在则随机生成一个只有 root 可读的密钥文件
+func loadOrGenerateKey(keyFilename string) ([]byte, error) {
+	if key, err := os.ReadFile(keyFilename); err == nil {
+		if len(key) != MOUNT_REGISTRY_KEY_SIZE {
+			return nil, fmt.Errorf("invalid key size %d of %s", len(key), keyFilename)
+		}
+		return key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read key file %s error: %w", keyFilename, err)
+	}
+
+	key := make([]byte, MOUNT_REGISTRY_KEY_SIZE)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generate key error: %w", err)
+	}
+	file, err := os.OpenFile(keyFilename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("create key file %s error: %w", keyFilename, err)
+	}
+	defer file.Close()
+	if _, err = file.Write(key); err != nil {
+		return nil, fmt.Errorf("write key file %s error: %w", keyFilename, err)
+	}
+	return key, nil
+}
+
+// moveLegacyKey 将之前的版本保存在挂载记录旁边的密钥文件移动到 keyFilename，已经记录的挂载命令仍然可以被解密
+// keyFilename 已经存在或者没有旧的密钥文件时不做任何事
+func moveLegacyKey(legacyKeyFilename, keyFilename string) error {
+	if legacyKeyFilename == keyFilename {
+		return nil
+	}
+	if _, err := os.Stat(keyFilename); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("moveLegacyKey: stat key file %s error: %w", keyFilename, err)
+	}
+	key, err := os.ReadFile(legacyKeyFilename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("moveLegacyKey: read key file %s error: %w", legacyKeyFilename, err)
+	}
+
+	if err = ensureDirectoryExists(filepath.Dir(keyFilename)); err != nil {
+		return fmt.Errorf("moveLegacyKey: ensure directory %s exists error: %w", filepath.Dir(keyFilename), err)
+	}
+	file, err := os.OpenFile(keyFilename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("moveLegacyKey: create key file %s error: %w", keyFilename, err)
+	}
+	// 新的密钥文件写入磁盘之后才删除旧的密钥文件
+	if _, err = file.Write(key); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(keyFilename)
+		return fmt.Errorf("moveLegacyKey: write key file %s error: %w", keyFilename, err)
+	}
+	if err = os.Remove(legacyKeyFilename); err != nil {
+		return fmt.Errorf("moveLegacyKey: remove key file %s error: %w", legacyKeyFilename, err)
+	}
+	return nil
+}
+
+// Add 记录挂载点的挂载命令，同一个挂载点的记录会被覆盖
+func (registry *mountRegistry) Add(record *mountRecord) error {
+	mountPath := record.mountPath()
+	if mountPath == "" {
+		return errors.New("mountRegistry.Add: mount path is empty")
+	}
+	plaintext, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("mountRegistry.Add: marshal record of %s error: %w", mountPath, err)
+	}
+	nonce := make([]byte, registry.aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("mountRegistry.Add: generate nonce error: %w", err)
+	}
+	ciphertext := registry.aead.Seal(nonce, nonce, plaintext, nil)
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	// 先写入临时文件再重命名，避免 connector 崩溃时留下不完整的记录
+	filename := registry.filename(mountPath)
+	tmpFilename := filename + ".tmp"
+	if err = os.WriteFile(tmpFilename, ciphertext, 0600); err != nil {
+		return fmt.Errorf("mountRegistry.Add: write record of %s error: %w", mountPath, err)
+	}
+	if err = os.Rename(tmpFilename, filename); err != nil {
+		os.Remove(tmpFilename)
+		return fmt.Errorf("mountRegistry.Add: rename record of %s error: %w", mountPath, err)
+	}
+	return nil
+}
+
+// Remove 删除挂载点的记录，记录不存在时不返回错误
+func (registry *mountRegistry) Remove(mountPath string) error {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	if err := os.Remove(registry.filename(mountPath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("mountRegistry.Remove: remove record of %s error: %w", mountPath, err)
+	}
+	return nil
+}
+
+// Get 获取挂载点的记录，记录不存在时返回 nil
+func (registry *mountRegistry) Get(mountPath string) (*mountRecord, error) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	record, err := registry.read(registry.filename(mountPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("mountRegistry.Get: %w", err)
+	}
+	return record, nil
+}
+
+// List 列出所有挂载点的记录，无法解密的记录会被跳过并返回错误
+func (registry *mountRegistry) List() ([]*mountRecord, error) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	entries, err := os.ReadDir(registry.dir)
+	if err != nil {
+		return nil, fmt.Errorf("mountRegistry.List: read directory %s error: %w", registry.dir, err)
+	}
+	var (
+		records []*mountRecord
+		errs    []string
+	)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), MOUNT_REGISTRY_FILE_SUFFIX) {
+			continue
+		}
+		if record, err := registry.read(filepath.Join(registry.dir, entry.Name())); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			records = append(records, record)
+		}
+	}
+	if len(errs) > 0 {
+		return records, fmt.Errorf("mountRegistry.List: %s", strings.Join(errs, "; "))
+	}
+	return records, nil
+}
+
+func (registry *mountRegistry) read(filename string) (*mountRecord, error) {
+	ciphertext, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read record %s error: %w", filename, err)
+	}
+	nonceSize := registry.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("record %s is too short", filename)
+	}
+	plaintext, err := registry.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt record %s error: %w", filename, err)
+	}
+	var record mountRecord
+	if err = json.Unmarshal(plaintext, &record); err != nil {
+		return nil, fmt.Errorf("unmarshal record %s error: %w", filename, err)
+	}
+	return &record, nil
+}
+
+func (registry *mountRegistry) filename(mountPath string) string {
+	return filepath.Join(registry.dir, rcloneCacheId(mountPath)+MOUNT_REGISTRY_FILE_SUFFIX)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountRegistry(t *testing.T) {
+	dir := t.TempDir()
+	keyFilename := filepath.Join(dir, "connector.key")
+	registryDir := filepath.Join(dir, "mounts")
+
+	registry, err := newMountRegistry(registryDir, keyFilename)
+	assert.NoError(t, err)
+
+	kodo := &mountRecord{Kodo: &protocol.InitKodoMountCmd{VolumeId: "kodo-1", MountPath: "/mnt/kodo", SecretKey: "kodo-secret-key"}}
+	kodofs := &mountRecord{KodoFS: &protocol.InitKodoFSMountCmd{VolumeId: "kodofs-1", MountPath: "/mnt/kodofs", AccessToken: "kodofs-access-token"}}
+	assert.NoError(t, registry.Add(kodo))
+	assert.NoError(t, registry.Add(kodofs))
+	assert.Error(t, registry.Add(&mountRecord{}))
+
+	// 记录中的密钥必须被加密
+	for _, record := range []*mountRecord{kodo, kodofs} {
+		data, err := os.ReadFile(registry.filename(record.mountPath()))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "kodo-secret-key")
+		assert.NotContains(t, string(data), "kodofs-access-token")
+	}
+
+	// 重新打开时使用同一个密钥
+	registry, err = newMountRegistry(registryDir, keyFilename)
+	assert.NoError(t, err)
+	records, err := registry.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*mountRecord{kodo, kodofs}, records)
+
+	assert.NoError(t, registry.Remove("/mnt/kodo"))
+	assert.NoError(t, registry.Remove("/mnt/not-exists"))
+	records, err = registry.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []*mountRecord{kodofs}, records)
+
+	// 其他密钥无法解密的记录会返回错误
+	assert.NoError(t, os.Remove(keyFilename))
+	registry, err = newMountRegistry(registryDir, keyFilename)
+	assert.NoError(t, err)
+	records, err = registry.List()
+	assert.Error(t, err)
+	assert.Empty(t, records)
+}
+
+func TestMoveLegacyKey(t *testing.T) {
+	dir := t.TempDir()
+	legacyKeyFilename := filepath.Join(dir, "state", "connector.key")
+	keyFilename := filepath.Join(dir, "etc", "connector.key")
+	registryDir := filepath.Join(dir, "state", "mounts")
+
+	// 之前的版本在挂载记录旁边生成密钥
+	registry, err := newMountRegistry(registryDir, legacyKeyFilename)
+	assert.NoError(t, err)
+	kodo := &mountRecord{Kodo: &protocol.InitKodoMountCmd{VolumeId: "kodo-1", MountPath: "/mnt/kodo", SecretKey: "kodo-secret-key"}}
+	assert.NoError(t, registry.Add(kodo))
+
+	// 移动后旧的密钥文件被删除，已有的记录仍然可以被解密
+	assert.NoError(t, moveLegacyKey(legacyKeyFilename, keyFilename))
+	_, err = os.Stat(legacyKeyFilename)
+	assert.True(t, os.IsNotExist(err))
+	registry, err = newMountRegistry(registryDir, keyFilename)
+	assert.NoError(t, err)
+	records, err := registry.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []*mountRecord{kodo}, records)
+
+	// 没有旧的密钥文件，或者新的密钥文件已经存在时不做任何事
+	assert.NoError(t, moveLegacyKey(legacyKeyFilename, keyFilename))
+	assert.NoError(t, os.WriteFile(legacyKeyFilename, []byte("other key"), 0600))
+	assert.NoError(t, moveLegacyKey(legacyKeyFilename, keyFilename))
+	key, err := os.ReadFile(keyFilename)
+	assert.NoError(t, err)
+	assert.Len(t, key, MOUNT_REGISTRY_KEY_SIZE)
+}
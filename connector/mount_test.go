@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMountError(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "rclone.log")
+	assert.NoError(t, os.WriteFile(logFile, []byte("ERROR : NoSuchBucket: old error of last mount\n"), 0600))
+	logOffset := fileSize(logFile)
+
+	// 只读取本次挂载写入的日志
+	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0600)
+	assert.NoError(t, err)
+	_, err = file.WriteString("NOTICE: mounting\nERROR : InvalidAccessKeyId: The AWS Access Key Id you provided does not exist\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	mountErr := newMountError(context.Background(), errors.New("exit status 1"), "Fatal error: failed to mount FUSE fs\n", logFile, logOffset)
+	assert.Equal(t, connectorpb.MountErrorReason_MOUNT_ERROR_REASON_AUTH_FAILED, mountErr.reason)
+	assert.Equal(t, "authentication failed: ERROR : InvalidAccessKeyId: The AWS Access Key Id you provided does not exist", mountErr.Error())
+	assert.NotContains(t, mountErr.output, "old error")
+	assert.True(t, strings.HasPrefix(mountErr.output, "Fatal error: failed to mount FUSE fs\n"))
+
+	// 没有输出时使用命令的错误
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	mountErr = newMountError(ctx, errors.New("signal: killed"), "", "", 0)
+	assert.Equal(t, connectorpb.MountErrorReason_MOUNT_ERROR_REASON_TIMEOUT, mountErr.reason)
+	assert.Equal(t, "mount timed out: signal: killed", mountErr.Error())
+}
+
+func TestTailBuffer(t *testing.T) {
+	buffer := newTailBuffer(5)
+	buffer.WriteString("abc")
+	assert.Equal(t, "abc", buffer.String())
+	buffer.WriteString("defg")
+	assert.Equal(t, "cdefg", buffer.String())
+}
+
+func TestCleanKodoMount(t *testing.T) {
+	originalCacheDir, originalLogDir := rcloneCacheDir, rcloneLogDir
+	defer func() { rcloneCacheDir, rcloneLogDir = originalCacheDir, originalLogDir }()
+	rcloneCacheDir, rcloneLogDir = t.TempDir(), t.TempDir()
+
+	mountPath := "/var/lib/kubelet/pods/1/mount"
+	volumeCacheDir := filepath.Join(rcloneCacheDir, "pv-1", rcloneCacheId(mountPath))
+	rcloneLogFile := filepath.Join(rcloneLogDir, "pv-1", rcloneCacheId(mountPath)+".log")
+	assert.NoError(t, os.MkdirAll(filepath.Join(volumeCacheDir, "vfs"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(rcloneLogFile), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(volumeCacheDir, "vfs", "dirty"), []byte("data"), 0644))
+	assert.NoError(t, os.WriteFile(rcloneLogFile, []byte("log"), 0644))
+
+	// 没有确认上传时按存储卷和子目录保留缓存，只删除日志
+	retainedDir := retainedCacheDir("pv-1", "data")
+	cleanKodoMount("pv-1", "data", mountPath, false)
+	assert.NoDirExists(t, volumeCacheDir)
+	assert.FileExists(t, filepath.Join(retainedDir, "vfs", "dirty"))
+	assert.NoFileExists(t, rcloneLogFile)
+
+	// 已经有被保留的缓存时留在原处
+	assert.NoError(t, os.MkdirAll(volumeCacheDir, 0755))
+	cleanKodoMount("pv-1", "data", mountPath, false)
+	assert.DirExists(t, volumeCacheDir)
+
+	cleanKodoMount("pv-1", "data", mountPath, true)
+	assert.NoDirExists(t, volumeCacheDir)
+	assert.DirExists(t, retainedDir)
+
+	// 挂载同一子目录的其他挂载点使用被保留的缓存
+	otherCacheDir := filepath.Join(rcloneCacheDir, "pv-1", rcloneCacheId("/var/lib/kubelet/pods/2/mount"))
+	adoptRetainedCache("pv-1", "other", otherCacheDir)
+	assert.NoDirExists(t, otherCacheDir)
+	adoptRetainedCache("pv-1", "data", otherCacheDir)
+	assert.FileExists(t, filepath.Join(otherCacheDir, "vfs", "dirty"))
+	assert.NoDirExists(t, retainedDir)
+}
+
+func TestCheckReadOnlyMount(t *testing.T) {
+	const mountPath = "/var/lib/kubelet/plugins/kubernetes.io/csi/pv/kodo-1/globalmount"
+	mounts := []*mountinfo.Info{
+		{Mountpoint: "/", Options: "rw,relatime"},
+		{Mountpoint: mountPath, Options: "ro,nosuid,nodev,relatime"},
+	}
+	assert.NoError(t, checkReadOnlyMount(mounts, mountPath))
+
+	// 以最上层的挂载为准
+	mounts = append(mounts, &mountinfo.Info{Mountpoint: mountPath, Options: "rw,nosuid,nodev,relatime"})
+	assert.ErrorIs(t, checkReadOnlyMount(mounts, mountPath), errReadOnlyNotEnforced)
+
+	assert.ErrorIs(t, checkReadOnlyMount(mounts[:1], mountPath), errReadOnlyNotEnforced)
+}
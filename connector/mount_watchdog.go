@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/qiniu/kubernetes-csi-driver/bindmount"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+type mountStatus int
+
+const (
+	mountStatusHealthy mountStatus = iota
+	// 挂载点上存在 FUSE 挂载，但是挂载进程已经退出
+	mountStatusBroken
+	// 挂载点存在，但是没有被挂载
+	mountStatusNotMounted
+	// 挂载点已经被删除，说明 Pod 已经被删除
+	mountStatusRemoved
+	// 挂载点上是其他文件系统
+	mountStatusUnknown
+)
+
+func (status mountStatus) String() string {
+	switch status {
+	case mountStatusHealthy:
+		return "healthy"
+	case mountStatusBroken:
+		return "broken"
+	case mountStatusNotMounted:
+		return "not mounted"
+	case mountStatusRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+func (status mountStatus) toProto() connectorpb.MountStatus {
+	switch status {
+	case mountStatusHealthy:
+		return connectorpb.MountStatus_MOUNT_STATUS_HEALTHY
+	case mountStatusBroken:
+		return connectorpb.MountStatus_MOUNT_STATUS_BROKEN
+	case mountStatusNotMounted:
+		return connectorpb.MountStatus_MOUNT_STATUS_NOT_MOUNTED
+	case mountStatusRemoved:
+		return connectorpb.MountStatus_MOUNT_STATUS_REMOVED
+	default:
+		return connectorpb.MountStatus_MOUNT_STATUS_UNKNOWN
+	}
+}
+
+// mountWatchdog 在 connector 启动时和之后每隔一段时间检查所有记录的挂载点，并重新挂载损坏的挂载点
+type mountWatchdog struct {
+	registry *mountRegistry
+	// 上一轮检查时没有被挂载的挂载点，连续两轮检查都没有被挂载才会重新挂载，
+	// 避免插件先卸载再通知 connector 删除记录时，挂载点在这期间被重新挂载
+	notMounted map[string]bool
+}
+
+func newMountWatchdog(registry *mountRegistry) *mountWatchdog {
+	return &mountWatchdog{registry: registry, notMounted: make(map[string]bool)}
+}
+
+// Run 立即检查一次，interval 大于 0 时之后每隔 interval 检查一次
+func (watchdog *mountWatchdog) Run(interval time.Duration) {
+	// connector 启动时插件尚未开始处理请求，不会有正在进行的卸载
+	watchdog.check(true)
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		watchdog.check(false)
+	}
+}
+
+func (watchdog *mountWatchdog) check(onStartup bool) {
+	records, err := watchdog.registry.List()
+	if err != nil {
+		log.Warnf("Failed to list mount records: %s", err)
+	}
+	notMounted := make(map[string]bool)
+	for _, record := range records {
+		if watchdog.checkMountPoint(record.mountPath(), onStartup) {
+			notMounted[record.mountPath()] = true
+		}
+	}
+	watchdog.notMounted = notMounted
+}
+
+// checkMountPoint 检查一个挂载点并在需要时重新挂载，挂载点第一次被发现没有挂载时返回 true
+// 检查期间持有挂载点的锁，卸载会等待检查完成，列出记录之后发生的变化在持有锁之后重新读取记录得到
+func (watchdog *mountWatchdog) checkMountPoint(mountPath string, onStartup bool) (notMounted bool) {
+	unlock := mountPathLocks.Lock(mountPath)
+	defer unlock()
+
+	// 挂载点可能已经被卸载，或者被以不同的参数重新挂载
+	record, err := watchdog.registry.Get(mountPath)
+	if err != nil {
+		log.Warnf("Failed to get mount record of %s: %s", mountPath, err)
+		return false
+	} else if record == nil {
+		return false
+	}
+	fsType := FuseTypeKodo
+	if record.KodoFS != nil {
+		fsType = FuseTypeKodoFS
+	}
+	status, err := getMountStatus(mountPath, fsType)
+	if err != nil {
+		log.Warnf("Failed to get status of mount point %s: %s", mountPath, err)
+		return false
+	}
+	switch status {
+	case mountStatusRemoved:
+		log.Infof("Mount point %s of volume %s is removed, forget it", mountPath, record.volumeId())
+		if err = watchdog.registry.Remove(mountPath); err != nil {
+			log.Warnf("Failed to remove mount record: %s", err)
+		}
+	case mountStatusNotMounted:
+		if !onStartup && !watchdog.notMounted[mountPath] {
+			return true
+		}
+		log.Warnf("Mount point %s of volume %s is not mounted, remount it", mountPath, record.volumeId())
+		if err = remount(record); err != nil {
+			log.Errorf("Failed to remount volume %s to %s: %s", record.volumeId(), mountPath, err)
+		} else {
+			log.Infof("Volume %s is remounted to %s", record.volumeId(), mountPath)
+		}
+	case mountStatusBroken:
+		log.Warnf("Mount point %s of volume %s is broken, remount it", mountPath, record.volumeId())
+		// 插件将 staging path 绑定挂载到各个 Pod 的目标路径，重新挂载后需要重新绑定
+		binds, err := bindmount.FindBindMounts(mountPath, fsType)
+		if err != nil {
+			log.Warnf("Failed to find bind mounts of %s: %s", mountPath, err)
+		}
+		if output, err := exec.Command(FusermountCmd, "-uz", mountPath).CombinedOutput(); err != nil {
+			log.Errorf("Failed to unmount broken mount point %s: %s: %s", mountPath, err, output)
+		} else if err = remount(record); err != nil {
+			log.Errorf("Failed to remount volume %s to %s: %s", record.volumeId(), mountPath, err)
+		} else {
+			log.Infof("Volume %s is remounted to %s", record.volumeId(), mountPath)
+			for _, bind := range binds {
+				if err = rebindMount(mountPath, bind); err != nil {
+					log.Errorf("Failed to bind mount %s to %s again: %s", mountPath, bind.Mountpoint, err)
+				} else {
+					log.Infof("Mount point %s is bind mounted to %s again", mountPath, bind.Mountpoint)
+				}
+			}
+		}
+	case mountStatusUnknown:
+		log.Warnf("Mount point %s of volume %s is mounted by other file system, skip it", mountPath, record.volumeId())
+	}
+	return false
+}
+
+// 通过 mountinfo 和 statfs 检查挂载点的状态，同一个挂载点有多个挂载时以最上层的为准
+func getMountStatus(mountPath, fsType string) (mountStatus, error) {
+	mounts, err := mountinfo.GetMounts(func(info *mountinfo.Info) (skip, stop bool) {
+		return info.Mountpoint != mountPath, false
+	})
+	if err != nil {
+		return mountStatusUnknown, err
+	}
+	if len(mounts) == 0 {
+		if _, err = os.Stat(mountPath); os.IsNotExist(err) {
+			return mountStatusRemoved, nil
+		} else if err != nil {
+			return mountStatusUnknown, err
+		}
+		return mountStatusNotMounted, nil
+	} else if mounts[len(mounts)-1].FSType != fsType {
+		return mountStatusUnknown, nil
+	}
+
+	var statfs unix.Statfs_t
+	if err = unix.Statfs(mountPath, &statfs); errors.Is(err, unix.ENOTCONN) || errors.Is(err, unix.ECONNABORTED) || errors.Is(err, unix.EIO) {
+		return mountStatusBroken, nil
+	} else if err != nil {
+		return mountStatusUnknown, err
+	}
+	return mountStatusHealthy, nil
+}
+
+// rebindMount 懒卸载指向已退出 FUSE 进程的绑定挂载，将重新挂载的 mountPath 绑定到原来的位置，并保持只读选项
+func rebindMount(mountPath string, bind *mountinfo.Info) error {
+	if err := unix.Unmount(bind.Mountpoint, unix.MNT_DETACH); err != nil && !errors.Is(err, unix.EINVAL) {
+		return err
+	}
+	// 插件可能绑定挂载 mountPath 下的子目录，Root 为子目录相对于 FUSE 文件系统根目录的路径
+	// 子目录中没有文件时，重新挂载后子目录不再存在
+	sourcePath := filepath.Join(mountPath, bind.Root)
+	if err := os.MkdirAll(sourcePath, 0755); err != nil {
+		return err
+	}
+	if err := unix.Mount(sourcePath, bind.Mountpoint, "", unix.MS_BIND, ""); err != nil {
+		return err
+	}
+	if bindmount.IsReadOnly(bind) {
+		return unix.Mount("", bind.Mountpoint, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY, "")
+	}
+	return nil
+}
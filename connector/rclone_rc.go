@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RcloneRcDir 保存每个 rclone 挂载进程的远程控制 socket
+var RcloneRcDir = "/var/lib/qiniu/storage/csi-plugin/rc"
+
+const (
+	// 等待 rclone 上传缓存中文件时查询上传状态的间隔
+	RCLONE_FLUSH_POLL_INTERVAL = time.Second
+)
+
+// 允许通过 connector 调用的远程控制命令，其他命令可能修改配置或访问任意文件
+var rcloneRcAllowedMethods = map[string]bool{
+	"vfs/stats":    true,
+	"vfs/refresh":  true,
+	"vfs/forget":   true,
+	"core/bwlimit": true,
+	"core/stats":   true,
+}
+
+// 挂载点对应的 rclone 远程控制 socket 路径
+func rcloneRcSocketPath(mountPath string) string {
+	return filepath.Join(RcloneRcDir, rcloneCacheId(mountPath)+".sock")
+}
+
+// rcloneRcClient 通过 unix socket 调用 rclone 的远程控制接口
+type rcloneRcClient struct {
+	socketPath string
+	client     *http.Client
+}
+
+func newRcloneRcClient(socketPath string) *rcloneRcClient {
+	return &rcloneRcClient{
+		socketPath: socketPath,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// call 调用远程控制命令，参数和结果都是 JSON
+func (c *rcloneRcClient) call(ctx context.Context, method string, in, out interface{}) error {
+	if in == nil {
+		in = struct{}{}
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("rcloneRcClient.call: marshal params of %s error: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://rclone/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("rcloneRcClient.call: create request of %s error: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("rcloneRcClient.call: call %s on %s error: %w", method, c.socketPath, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("rcloneRcClient.call: read response of %s error: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		rcErr := &rcloneRcError{method: method, statusCode: resp.StatusCode}
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &body) == nil {
+			rcErr.message = body.Error
+		}
+		return fmt.Errorf("rcloneRcClient.call: %w", rcErr)
+	}
+	if out != nil {
+		if err = json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("rcloneRcClient.call: unmarshal response of %s error: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// rcloneRcError rclone 执行远程控制命令失败时返回的错误
+type rcloneRcError struct {
+	method     string
+	statusCode int
+	message    string
+}
+
+func (err *rcloneRcError) Error() string {
+	if err.message == "" {
+		return fmt.Sprintf("%s returns status %d", err.method, err.statusCode)
+	}
+	return fmt.Sprintf("%s returns status %d: %s", err.method, err.statusCode, err.message)
+}
+
+// pendingUploads 返回正在上传和等待上传的文件数量，没有开启 VFS 缓存时总是返回 0
+func (c *rcloneRcClient) pendingUploads(ctx context.Context) (int, error) {
+	var stats struct {
+		DiskCache *struct {
+			UploadsInProgress int `json:"uploadsInProgress"`
+			UploadsQueued     int `json:"uploadsQueued"`
+		} `json:"diskCache"`
+	}
+	if err := c.call(ctx, "vfs/stats", nil, &stats); err != nil {
+		return 0, err
+	}
+	if stats.DiskCache == nil {
+		return 0, nil
+	}
+	return stats.DiskCache.UploadsInProgress + stats.DiskCache.UploadsQueued, nil
+}
+
+// uploadNow 让写回队列中还在等待 --vfs-write-back 的文件立即开始上传
+func (c *rcloneRcClient) uploadNow(ctx context.Context) error {
+	var queue struct {
+		Queue []struct {
+			Id        int64 `json:"id"`
+			Uploading bool  `json:"uploading"`
+		} `json:"queue"`
+	}
+	if err := c.call(ctx, "vfs/queue", nil, &queue); err != nil {
+		return err
+	}
+	for _, item := range queue.Queue {
+		if item.Uploading {
+			continue
+		}
+		if err := c.call(ctx, "vfs/queue-set-expiry", map[string]interface{}{"id": item.Id, "expiry": 0}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushUploads 等待 rclone 将缓存中的文件全部上传，ctx 结束时返回仍未上传的文件数量
+func (c *rcloneRcClient) flushUploads(ctx context.Context) (int, error) {
+	pending, err := c.pendingUploads(ctx)
+	if err != nil || pending == 0 {
+		return pending, err
+	}
+	// 较早版本的 rclone 不支持 vfs/queue，此时只能等待 --vfs-write-back 到期
+	if err = c.uploadNow(ctx); err != nil {
+		log.Debugf("Failed to upload queued files of %s immediately: %s", c.socketPath, err)
+	}
+
+	ticker := time.NewTicker(RCLONE_FLUSH_POLL_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return pending, ctx.Err()
+		case <-ticker.C:
+		}
+		if current, err := c.pendingUploads(ctx); err != nil {
+			return pending, err
+		} else if pending = current; pending == 0 {
+			return 0, nil
+		}
+	}
+}
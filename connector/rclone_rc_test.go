@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRclone 模拟 rclone 的远程控制接口，每次查询 vfs/stats 后完成一个文件的上传
+type fakeRclone struct {
+	lock       sync.Mutex
+	pending    int
+	expiredIds []int64
+}
+
+func (rclone *fakeRclone) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rclone.lock.Lock()
+	defer rclone.lock.Unlock()
+
+	switch r.URL.Path {
+	case "/vfs/stats":
+		fmt.Fprintf(w, `{"diskCache":{"uploadsInProgress":0,"uploadsQueued":%d}}`, rclone.pending)
+		if rclone.pending > 0 {
+			rclone.pending--
+		}
+	case "/vfs/queue":
+		fmt.Fprint(w, `{"queue":[{"id":1,"uploading":true},{"id":2,"uploading":false}]}`)
+	case "/vfs/queue-set-expiry":
+		var params struct {
+			Id     int64   `json:"id"`
+			Expiry float64 `json:"expiry"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		rclone.expiredIds = append(rclone.expiredIds, params.Id)
+		fmt.Fprint(w, `{}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"couldn't find method","status":404}`)
+	}
+}
+
+func startFakeRclone(t *testing.T, rclone *fakeRclone) string {
+	socketPath := filepath.Join(t.TempDir(), "rc.sock")
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	server := &http.Server{Handler: rclone}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return socketPath
+}
+
+func TestRcloneRcClientFlushUploads(t *testing.T) {
+	rclone := &fakeRclone{pending: 2}
+	client := newRcloneRcClient(startFakeRclone(t, rclone))
+
+	pending, err := client.flushUploads(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+	assert.Equal(t, []int64{2}, rclone.expiredIds)
+
+	// 超时时返回仍未上传的文件数量
+	rclone.lock.Lock()
+	rclone.pending = 100
+	rclone.lock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	pending, err = client.flushUploads(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 99, pending)
+
+	err = client.call(context.Background(), "vfs/unknown", nil, nil)
+	assert.EqualError(t, err, "rcloneRcClient.call: vfs/unknown returns status 404: couldn't find method")
+}
@@ -167,7 +167,7 @@ func init() {
 
 // 获取并解析 rclone 版本信息
 func getRcloneVersion() (rcloneVersion, osVersion, osKernel string, err error) {
-	output, err := exec.Command(RcloneCmd, "version").Output()
+	output, err := exec.Command(protocol.RcloneCmd, "version").Output()
 	if err != nil {
 		return
 	}
@@ -4,16 +4,27 @@ set -e
 
 HOST_CMD="nsenter --all --target 1 --"
 
+# 每个插件可以使用独立的 connector 实例，systemd 单元名和配置文件路径由 DaemonSet 中的环境变量指定
+# 配置文件中的 socketPath、pidFile、stateDir、registryKeyFile 和 log.file 也需要与其他实例不同
+CONNECTOR_UNIT=${CONNECTOR_UNIT:-csiplugin-connector}
+CONNECTOR_CONFIG=${CONNECTOR_CONFIG:-/var/lib/qiniu/storage/csi-plugin/connector.yaml}
+
 rm -f /host/usr/local/bin/kodofs /host/usr/local/bin/connector.plugin.storage.qiniu.com /host/usr/local/bin/rclone
 cp /usr/local/bin/kodofs /host/usr/local/bin/kodofs
 cp /usr/local/bin/rclone /host/usr/local/bin/rclone
 cp /usr/local/bin/connector.plugin.storage.qiniu.com /host/usr/local/bin/connector.plugin.storage.qiniu.com
-cp /csiplugin-connector.service /host/etc/systemd/system/csiplugin-connector.service
+sed "s#@CONNECTOR_CONFIG@#${CONNECTOR_CONFIG}#g" /csiplugin-connector.service > /host/etc/systemd/system/${CONNECTOR_UNIT}.service
+
+# /var/lib/qiniu 与宿主机共享，connector 的配置文件由 DaemonSet 中的 ConfigMap 提供
+if [ -f /etc/qiniu/connector/connector.yaml ]; then
+    mkdir -p "$(dirname "${CONNECTOR_CONFIG}")"
+    cp /etc/qiniu/connector/connector.yaml "${CONNECTOR_CONFIG}"
+fi
 
-$HOST_CMD /usr/local/bin/connector.plugin.storage.qiniu.com -test
+$HOST_CMD /usr/local/bin/connector.plugin.storage.qiniu.com -config "${CONNECTOR_CONFIG}" -test
 
 $HOST_CMD systemctl daemon-reload
-$HOST_CMD systemctl enable csiplugin-connector
-$HOST_CMD systemctl restart csiplugin-connector
+$HOST_CMD systemctl enable "${CONNECTOR_UNIT}"
+$HOST_CMD systemctl restart "${CONNECTOR_UNIT}"
 
 /usr/local/bin/plugin.storage.qiniu.com $@
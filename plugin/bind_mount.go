@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	k8smount "k8s.io/utils/mount"
+)
+
+// isStaged 判断存储卷是否已经在 NodeStageVolume 中被挂载到 stagingPath
+// stagingPath 为空或没有被挂载时，NodePublishVolume 直接将存储卷挂载到目标路径
+// FUSE 进程已经退出导致 stagingPath 失效时返回 Unavailable，绑定挂载失效的 stagingPath 会使 Pod 无法访问存储卷，kubelet 将在 connector 重新挂载后重试
+func isStaged(stagingPath, fsType string) (bool, error) {
+	if stagingPath == "" {
+		return false, nil
+	}
+	if mounted, err := isMounted(stagingPath, fsType); err != nil || !mounted {
+		return false, err
+	}
+	if _, err := os.Stat(stagingPath); isStaleMountError(err) {
+		return false, status.Errorf(codes.Unavailable, "staging path %s is stale and waiting to be remounted: %s", stagingPath, err)
+	} else if err != nil {
+		return false, fmt.Errorf("stat staging path %s error: %w", stagingPath, err)
+	}
+	return true, nil
+}
+
+// bindMount 将 NodeStageVolume 挂载的 stagingPath 绑定挂载到 targetPath
+func bindMount(mounter k8smount.Interface, functionName, stagingPath, targetPath, fsType string, readOnly bool) error {
+	if mounted, err := prepareMountPoint(functionName, targetPath, fsType); err != nil {
+		return err
+	} else if mounted {
+		log.Infof("%s: %s is already mounted", functionName, targetPath)
+		return nil
+	}
+	options := []string{"bind"}
+	if readOnly {
+		options = append(options, "ro")
+	}
+	if err := mounter.Mount(stagingPath, targetPath, "", options); err != nil {
+		return fmt.Errorf("%s: failed to bind mount %s to %s: %w", functionName, stagingPath, targetPath, err)
+	}
+	return nil
+}
+
+// unbindMount 卸载绑定挂载，FUSE 进程已经退出时懒卸载
+func unbindMount(mounter k8smount.Interface, functionName, targetPath string) error {
+	if err := mounter.Unmount(targetPath); err != nil {
+		log.Warnf("%s: failed to unmount %s: %s, unmount it lazily", functionName, targetPath, err)
+		if err = lazyUmount(targetPath); err != nil {
+			return fmt.Errorf("%s: failed to unmount %s: %w", functionName, targetPath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsStaged(t *testing.T) {
+	// kubelet 没有传入 staging path 时直接挂载到目标路径
+	staged, err := isStaged("", FuseTypeKodo)
+	assert.NoError(t, err)
+	assert.False(t, staged)
+
+	staged, err = isStaged(t.TempDir(), FuseTypeKodo)
+	assert.NoError(t, err)
+	assert.False(t, staged)
+}
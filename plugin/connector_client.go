@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SocketPath connector 默认监听的 socket，可以通过 --connector-socket 修改
+	SocketPath = "/var/lib/qiniu/storage/csi-plugin/connector.sock"
+
+	// 旧版本的 connector 收到 gRPC 请求后会直接关闭连接，很快就能判断出来
+	CONNECTOR_VERSION_TIMEOUT = 5 * time.Second
+	CONNECTOR_TIMEOUT         = time.Minute
+	// 协商结果为 v2 协议时，每隔一段时间重新协商，以便在 connector 升级后使用 gRPC 服务
+	CONNECTOR_LEGACY_RENEGOTIATE_INTERVAL = time.Minute
+)
+
+// connectorSession 缓存与 connector 的 gRPC 连接和协商得到的协议版本，避免每次请求都重新连接并发送 Version 请求
+type connectorSession struct {
+	lock         sync.Mutex
+	conn         *grpc.ClientConn
+	legacy       bool
+	negotiatedAt time.Time
+}
+
+var connector = &connectorSession{}
+
+// getConn 返回共享的 gRPC 连接，连接断开后由 gRPC 自动重连
+func (session *connectorSession) getConn() (*grpc.ClientConn, error) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	return session.getConnLocked()
+}
+
+func (session *connectorSession) getConnLocked() (*grpc.ClientConn, error) {
+	if session.conn == nil {
+		conn, err := grpc.Dial("unix://"+*connectorSocket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial connector %s: %w", *connectorSocket, err)
+		}
+		session.conn = conn
+	}
+	return session.conn, nil
+}
+
+// negotiate 返回 connector 是否只支持 v2 协议，只有确认 connector 是旧版本时才回退，其他错误直接返回
+func (session *connectorSession) negotiate(ctx context.Context) (connectorpb.ConnectorClient, bool, error) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	conn, err := session.getConnLocked()
+	if err != nil {
+		return nil, false, err
+	}
+	client := connectorpb.NewConnectorClient(conn)
+	if !session.negotiatedAt.IsZero() && (!session.legacy || time.Since(session.negotiatedAt) < CONNECTOR_LEGACY_RENEGOTIATE_INTERVAL) {
+		return client, session.legacy, nil
+	}
+
+	versionCtx, cancel := context.WithTimeout(ctx, CONNECTOR_VERSION_TIMEOUT)
+	defer cancel()
+	if version, err := client.Version(versionCtx, &connectorpb.VersionRequest{}); err == nil {
+		log.Infof("connector version: %s, commit id: %s, protocol: %s", version.GetVersion(), version.GetCommitId(), version.GetProtocolVersion())
+		session.legacy = false
+	} else if isLegacyConnectorError(err) {
+		log.Infof("connector does not support protocol %s, fall back to protocol %s: %s", protocol.GRPCVersion, protocol.Version, err)
+		session.legacy = true
+	} else {
+		return nil, false, fmt.Errorf("failed to get version of connector %s: %w", *connectorSocket, err)
+	}
+	session.negotiatedAt = time.Now()
+	return client, session.legacy, nil
+}
+
+// reset 丢弃协商结果，下次请求时重新协商
+func (session *connectorSession) reset() {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	session.negotiatedAt = time.Time{}
+}
+
+// isLegacyConnectorError 判断 gRPC 请求失败是否是因为 connector 只支持 v2 协议
+// 旧版本的 connector 无法解析 HTTP/2 的连接前言，会直接关闭连接，gRPC 客户端因此收不到服务端的前言
+func isLegacyConnectorError(err error) bool {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.Unimplemented:
+		return true
+	case codes.Unavailable:
+		return strings.Contains(st.Message(), "server preface") || strings.Contains(st.Message(), "connection reset by peer")
+	}
+	return false
+}
+
+// withConnector 与 connector 的 gRPC 服务通信，滚动升级期间 connector 可能仍然是只支持 v2 协议的旧版本，
+// 此时回退到 v2 协议，method 用于记录请求结果的指标
+func withConnector(ctx context.Context, method string, f func(client connectorpb.ConnectorClient) error, fallback func() error) (err error) {
+	protocolVersion := protocol.GRPCVersion
+	defer func() {
+		connectorRequestsTotal.WithLabelValues(method, protocolVersion, status.Code(err).String()).Inc()
+	}()
+
+	client, legacy, err := connector.negotiate(ctx)
+	if err != nil {
+		return err
+	} else if legacy {
+		protocolVersion = protocol.Version
+		return fallback()
+	}
+	if err = f(client); isLegacyConnectorError(err) {
+		// connector 被降级为旧版本，下次请求时重新协商
+		connector.reset()
+	}
+	return err
+}
+
+// mountByConnector 请求 connector 挂载，legacyCmd 和 legacyAnswers 用于 v2 协议，
+// v2 协议中 kodofs mount 的询问需要由插件回答
+func mountByConnector(name string, req *connectorpb.MountRequest, legacyCmdName string, legacyCmd protocol.Cmd, legacyAnswers map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), CONNECTOR_TIMEOUT)
+	defer cancel()
+
+	return withConnector(ctx, "Mount", func(client connectorpb.ConnectorClient) error {
+		stream, err := client.Mount(ctx, req)
+		if err != nil {
+			return mountStatusError(name, err)
+		}
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return mountStatusError(name, err)
+			}
+			if resp.GetQueuePosition() > 0 {
+				log.Infof("%s mount is queued by connector at position %d", name, resp.GetQueuePosition())
+			} else if resp.GetIsError() {
+				log.Warnf("%s mount stderr prompt: %s", name, resp.GetData())
+			} else {
+				log.Infof("%s mount stdout prompt: %s", name, resp.GetData())
+			}
+		}
+	}, func() error {
+		// v2 的 connector 会忽略 ReadOnly，挂载后的文件系统实际上可写，所以拒绝只读挂载
+		if req.GetKodo().GetReadOnly() || req.GetKodofs().GetReadOnly() {
+			return status.Errorf(codes.FailedPrecondition, "%s mount error: connector of protocol v2 does not support read-only mount, please upgrade the connector", name)
+		}
+		return runLegacyCmd(name, legacyCmdName, legacyCmd, legacyAnswers)
+	})
+}
+
+// mountStatusError 将 connector 返回的挂载失败原因转换为带有对应状态码的错误，完整的输出只记录在日志中
+func mountStatusError(name string, err error) error {
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if mountError, ok := detail.(*connectorpb.MountError); ok {
+			return newMountStatusError(name, mountError.GetReason(), mountError.GetMessage(), mountError.GetOutput())
+		}
+	}
+	return status.Errorf(st.Code(), "%s mount error: %s", name, st.Message())
+}
+
+func newMountStatusError(name string, reason connectorpb.MountErrorReason, message, output string) error {
+	if output != "" {
+		log.Warnf("%s mount failed with reason %s, output:\n%s", name, reason, output)
+	}
+	return status.Errorf(protocol.MountErrorCode(reason), "%s mount error: %s", name, message)
+}
+
+// wrapStatusError 为错误信息加上前缀，并保留错误的 gRPC 状态码
+func wrapStatusError(err error, format string, args ...interface{}) error {
+	prefix := fmt.Sprintf(format, args...)
+	if st, ok := status.FromError(err); ok {
+		return status.Errorf(st.Code(), "%s: %s", prefix, st.Message())
+	}
+	return fmt.Errorf("%s: %w", prefix, err)
+}
+
+// unmountByConnector 请求 connector 卸载并清理缓存和日志，挂载点没有被挂载时同样返回成功
+// flushTimeout 为卸载前等待 rclone 上传缓存中文件的最长时间，超时时返回 DeadlineExceeded，远程控制不可用时返回 Unavailable，两种情况都不会卸载
+// 多次等待失败后 connector 会强制卸载并保留缓存，此时返回的 flushed 为 false
+func unmountByConnector(name, volumeId, mountPath, fsType string, flushTimeout time.Duration, legacyCmdName string, legacyCmd protocol.Cmd) (flushed bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONNECTOR_TIMEOUT+flushTimeout)
+	defer cancel()
+
+	err = withConnector(ctx, "Unmount", func(client connectorpb.ConnectorClient) error {
+		resp, err := client.Unmount(ctx, &connectorpb.UnmountRequest{
+			VolumeId:            volumeId,
+			MountPath:           mountPath,
+			FlushTimeoutSeconds: uint32(flushTimeout / time.Second),
+		})
+		if err != nil {
+			return wrapStatusError(err, "failed to unmount %s from %s", name, mountPath)
+		}
+		flushed = !resp.GetWasMounted() || resp.GetFlushed()
+		if !resp.GetWasMounted() {
+			log.Warnf("%s is not mounted by %s", mountPath, name)
+		} else if flushTimeout > 0 && fsType == FuseTypeKodo && !resp.GetFlushed() {
+			log.Warnf("%s is unmounted from %s, but connector could not confirm that cached files were uploaded, the cache is kept on the node", name, mountPath)
+		}
+		return nil
+	}, func() error {
+		// v2 协议中由插件卸载，再通知 connector 清理，无法确认缓存中的文件已经上传
+		flushed = true
+		if mounted, err := isMounted(mountPath, fsType); err != nil {
+			log.Warnf("failed to detect mount point: %s", err)
+		} else if !mounted {
+			log.Warnf("%s is not mounted by %s", mountPath, name)
+		} else if err = umount(mountPath); err != nil {
+			return fmt.Errorf("failed to unmount %s from %s: %w", name, mountPath, err)
+		} else {
+			flushed = false
+		}
+		if err := sendLegacyCmd(legacyCmdName, legacyCmd); err != nil {
+			log.Warnf("failed to clean after %s is unmounted: %s", name, err)
+		}
+		return nil
+	})
+	return
+}
+
+// sendLegacyCmd 通过 v2 协议发送一个不需要响应的命令
+func sendLegacyCmd(cmdName string, cmd protocol.Cmd) error {
+	conn, err := net.Dial("unix", *connectorSocket)
+	if err != nil {
+		return fmt.Errorf("failed to dial unix socket %s: %w", *connectorSocket, err)
+	}
+	defer conn.Close()
+
+	return writeLegacyCmd(json.NewEncoder(conn), cmdName, cmd)
+}
+
+// runLegacyCmd 通过 v2 协议执行命令直到其结束，输出中出现 answers 中的询问时回答
+func runLegacyCmd(name, cmdName string, cmd protocol.Cmd, answers map[string]string) error {
+	conn, err := net.Dial("unix", *connectorSocket)
+	if err != nil {
+		return fmt.Errorf("failed to dial unix socket %s: %w", *connectorSocket, err)
+	}
+	defer conn.Close()
+
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+	if err = writeLegacyCmd(encoder, cmdName, cmd); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		var request protocol.Request
+		if err = decoder.Decode(&request); err != nil {
+			return fmt.Errorf("failed to decode json request: %w", err)
+		}
+		if request.Version != protocol.Version {
+			return fmt.Errorf("unrecognized protocol version: %s", request.Version)
+		}
+		switch request.Cmd {
+		case protocol.ResponseDataCmdName:
+			var cmd protocol.ResponseDataCmd
+			if err = json.Unmarshal([]byte(request.Payload), &cmd); err != nil {
+				return fmt.Errorf("failed to marshal json payload: %w", err)
+			}
+			if cmd.IsError {
+				log.Warnf("%s mount stderr prompt: %s", name, cmd.Data)
+				continue
+			}
+			answered := false
+			for prompt, answer := range answers {
+				if strings.Contains(cmd.Data, prompt) {
+					if err = writeLegacyCmd(encoder, protocol.RequestDataCmdName, &protocol.RequestDataCmd{Data: answer}); err != nil {
+						return fmt.Errorf("failed to answer %q: %w", prompt, err)
+					}
+					answered = true
+				}
+			}
+			if !answered {
+				log.Infof("%s mount stdout prompt: %s", name, cmd.Data)
+			}
+		case protocol.TerminateCmdName:
+			var cmd protocol.TerminateCmd
+			if err = json.Unmarshal([]byte(request.Payload), &cmd); err != nil {
+				return fmt.Errorf("failed to marshal json payload: %w", err)
+			}
+			if cmd.Code == 0 {
+				return nil
+			} else if cmd.Error != nil {
+				reason := connectorpb.MountErrorReason(connectorpb.MountErrorReason_value[cmd.Error.Reason])
+				return newMountStatusError(name, reason, cmd.Error.Message, cmd.Error.Output)
+			} else {
+				return fmt.Errorf("unexpected command returns code: %d", cmd.Code)
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeLegacyCmd(encoder *json.Encoder, cmdName string, cmd protocol.Cmd) error {
+	buf, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json payload: %w", err)
+	}
+	if err = encoder.Encode(makeRequest(cmdName, buf)); err != nil {
+		return fmt.Errorf("failed to write command to unix socket %s: %w", *connectorSocket, err)
+	}
+	return nil
+}
+
+func makeRequest(cmdName string, buf []byte) *protocol.Request {
+	return &protocol.Request{
+		Version: protocol.Version,
+		Cmd:     cmdName,
+		Payload: json.RawMessage(buf),
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMountStatusError(t *testing.T) {
+	st, err := status.New(codes.PermissionDenied, "authentication failed").WithDetails(&connectorpb.MountError{
+		Reason:  connectorpb.MountErrorReason_MOUNT_ERROR_REASON_AUTH_FAILED,
+		Message: "authentication failed: InvalidAccessKeyId",
+		Output:  "ERROR : InvalidAccessKeyId",
+	})
+	assert.NoError(t, err)
+	err = mountStatusError("kodo", st.Err())
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "kodo mount error: authentication failed: InvalidAccessKeyId", status.Convert(err).Message())
+
+	// 没有 details 时保留原来的状态码
+	err = mountStatusError("kodofs", status.Error(codes.Unavailable, "connection closed"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, "kodofs mount error: connection closed", status.Convert(err).Message())
+}
+
+func TestWrapStatusError(t *testing.T) {
+	err := wrapStatusError(status.Error(codes.NotFound, "bucket not found"), "NodePublishVolume: failed to mount %s", "pv")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "NodePublishVolume: failed to mount pv: bucket not found", status.Convert(err).Message())
+
+	cause := errors.New("failed")
+	err = wrapStatusError(cause, "NodePublishVolume")
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "NodePublishVolume: failed", err.Error())
+}
+
+func TestIsLegacyConnectorError(t *testing.T) {
+	assert.True(t, isLegacyConnectorError(status.Error(codes.Unimplemented, "unknown service")))
+	assert.True(t, isLegacyConnectorError(status.Error(codes.Unavailable, "connection error: desc = \"error reading server preface: EOF\"")))
+	assert.True(t, isLegacyConnectorError(status.Error(codes.Unavailable, "read unix @->/connector.sock: read: connection reset by peer")))
+	assert.False(t, isLegacyConnectorError(status.Error(codes.Unavailable, "dial unix /connector.sock: connect: no such file or directory")))
+	assert.False(t, isLegacyConnectorError(status.Error(codes.DeadlineExceeded, "context deadline exceeded")))
+	assert.False(t, isLegacyConnectorError(nil))
+}
+
+type fakeConnectorServer struct {
+	connectorpb.UnimplementedConnectorServer
+}
+
+func (server *fakeConnectorServer) Version(ctx context.Context, req *connectorpb.VersionRequest) (*connectorpb.VersionResponse, error) {
+	return &connectorpb.VersionResponse{Version: "test"}, nil
+}
+
+func TestConnectorSessionNegotiate(t *testing.T) {
+	originalSocket := *connectorSocket
+	defer func() { *connectorSocket = originalSocket }()
+
+	// 旧版本的 connector 无法解析 gRPC 请求，直接关闭连接
+	*connectorSocket = filepath.Join(t.TempDir(), "legacy.sock")
+	legacyListener, err := net.Listen("unix", *connectorSocket)
+	assert.NoError(t, err)
+	defer legacyListener.Close()
+	go func() {
+		for {
+			conn, err := legacyListener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	session := &connectorSession{}
+	_, legacy, err := session.negotiate(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, legacy)
+	// 协商结果被缓存
+	negotiatedAt := session.negotiatedAt
+	_, legacy, err = session.negotiate(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, legacy)
+	assert.Equal(t, negotiatedAt, session.negotiatedAt)
+
+	*connectorSocket = filepath.Join(t.TempDir(), "grpc.sock")
+	listener, err := net.Listen("unix", *connectorSocket)
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	connectorpb.RegisterConnectorServer(server, &fakeConnectorServer{})
+	go server.Serve(listener)
+	defer server.Stop()
+	session = &connectorSession{}
+	_, legacy, err = session.negotiate(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, legacy)
+
+	// connector 没有运行时返回错误，不回退到 v2 协议
+	*connectorSocket = filepath.Join(t.TempDir(), "missing.sock")
+	session = &connectorSession{}
+	_, _, err = session.negotiate(context.Background())
+	assert.Error(t, err)
+	assert.True(t, session.negotiatedAt.IsZero())
+}
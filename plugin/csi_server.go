@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// nonBlockingGRPCServer 与 csicommon.NewNonBlockingGRPCServer 相同，但是会为每个 CSI 请求记录指标
+type nonBlockingGRPCServer struct {
+	wg     sync.WaitGroup
+	server *grpc.Server
+}
+
+func newNonBlockingGRPCServer() *nonBlockingGRPCServer {
+	return &nonBlockingGRPCServer{}
+}
+
+func (s *nonBlockingGRPCServer) Start(endpoint string, ids csi.IdentityServer, cs csi.ControllerServer, ns csi.NodeServer) {
+	proto, addr, err := csicommon.ParseEndpoint(endpoint)
+	if err != nil {
+		log.Fatalf("nonBlockingGRPCServer.Start: %s", err)
+	}
+	if proto == "unix" {
+		addr = "/" + addr
+		if err = os.Remove(addr); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("nonBlockingGRPCServer.Start: failed to remove %s: %s", addr, err)
+		}
+	}
+	listener, err := net.Listen(proto, addr)
+	if err != nil {
+		log.Fatalf("nonBlockingGRPCServer.Start: failed to listen: %s", err)
+	}
+
+	s.server = grpc.NewServer(grpc.ChainUnaryInterceptor(observeCSIRequest, logCSIRequest))
+	if ids != nil {
+		csi.RegisterIdentityServer(s.server, ids)
+	}
+	if cs != nil {
+		csi.RegisterControllerServer(s.server, cs)
+	}
+	if ns != nil {
+		csi.RegisterNodeServer(s.server, ns)
+	}
+	log.Infof("Listening for connections on address: %s", listener.Addr())
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.server.Serve(listener); err != nil {
+			log.Errorf("nonBlockingGRPCServer: serve error: %s", err)
+		}
+	}()
+}
+
+func (s *nonBlockingGRPCServer) Wait() {
+	s.wg.Wait()
+}
+
+func logCSIRequest(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	log.Debugf("GRPC call: %s", info.FullMethod)
+	resp, err := handler(ctx, req)
+	if err != nil {
+		log.Errorf("GRPC error: %s: %s", info.FullMethod, err)
+	}
+	return resp, err
+}
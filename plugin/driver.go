@@ -16,17 +16,25 @@ type Runnable interface {
 
 type KodoFSDriver struct {
 	csiDriver *csicommon.CSIDriver
+	nodeID    string
 	endpoint  string
+	checker   *healthChecker
 }
 
-func newKodoFSDriver(nodeID, endpoint, version string) *KodoFSDriver {
-	driver := &KodoFSDriver{endpoint: endpoint}
+func newKodoFSDriver(nodeID, endpoint, version string, checker *healthChecker) *KodoFSDriver {
+	driver := &KodoFSDriver{nodeID: nodeID, endpoint: endpoint, checker: checker}
 
 	csiDriver := csicommon.NewCSIDriver(TypePluginKodoFS, version, nodeID)
-	csiDriver.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER})
+	csiDriver.AddVolumeCapabilityAccessModes(SUPPORTED_ACCESS_MODES)
 	csiDriver.AddControllerServiceCapabilities([]csi.ControllerServiceCapability_RPC_Type{
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
 		csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+		csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
 	})
 	driver.csiDriver = csiDriver
 
@@ -34,28 +42,40 @@ func newKodoFSDriver(nodeID, endpoint, version string) *KodoFSDriver {
 }
 
 func (driver *KodoFSDriver) Run() {
-	s := csicommon.NewNonBlockingGRPCServer()
+	s := newNonBlockingGRPCServer()
 	s.Start(driver.endpoint,
-		csicommon.NewDefaultIdentityServer(driver.csiDriver),
+		newIdentityServer(driver.csiDriver, driver.checker),
 		newKodoFSControllerServer(driver.csiDriver),
-		newKodoFSNodeServer(driver.csiDriver),
+		newKodoFSNodeServer(driver.csiDriver, driver.nodeID),
 	)
 	s.Wait()
 }
 
 type KodoDriver struct {
 	csiDriver *csicommon.CSIDriver
+	nodeID    string
 	endpoint  string
+	gcConfig  kodoGarbageCollectorConfig
+	checker   *healthChecker
 }
 
-func newKodoDriver(nodeID, endpoint, version string) *KodoDriver {
-	driver := &KodoDriver{endpoint: endpoint}
+func newKodoDriver(nodeID, endpoint, version string, gcConfig kodoGarbageCollectorConfig, checker *healthChecker) *KodoDriver {
+	driver := &KodoDriver{nodeID: nodeID, endpoint: endpoint, gcConfig: gcConfig, checker: checker}
 
 	csiDriver := csicommon.NewCSIDriver(TypePluginKodo, version, nodeID)
-	csiDriver.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER})
+	csiDriver.AddVolumeCapabilityAccessModes(SUPPORTED_ACCESS_MODES)
 	csiDriver.AddControllerServiceCapabilities([]csi.ControllerServiceCapability_RPC_Type{
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
 		csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+		csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
+		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+		csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
 	})
 	driver.csiDriver = csiDriver
 
@@ -63,11 +83,11 @@ func newKodoDriver(nodeID, endpoint, version string) *KodoDriver {
 }
 
 func (driver *KodoDriver) Run() {
-	s := csicommon.NewNonBlockingGRPCServer()
+	s := newNonBlockingGRPCServer()
 	s.Start(driver.endpoint,
-		csicommon.NewDefaultIdentityServer(driver.csiDriver),
-		newKodoControllerServer(driver.csiDriver),
-		newKodoNodeServer(driver.csiDriver),
+		newIdentityServer(driver.csiDriver, driver.checker),
+		newKodoControllerServer(driver.csiDriver, driver.gcConfig.clusterId),
+		newKodoNodeServer(driver.csiDriver, driver.nodeID),
 	)
 	s.Wait()
 }
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	// 每项检查的超时时间，需要小于探针的 timeoutSeconds
+	HEALTH_CHECK_TIMEOUT = 3 * time.Second
+	// entrypoint.sh 将 rclone 和 kodofs 复制到宿主机的这个目录中，供 connector 使用
+	HOST_BIN_DIR = "/host/usr/local/bin"
+	FUSE_DEVICE  = "/dev/fuse"
+
+	HEALTH_STATUS_OK     = "ok"
+	HEALTH_STATUS_FAILED = "failed"
+)
+
+// healthScope 检查的范围，范围越大包含的检查越多
+type healthScope int
+
+const (
+	// /healthz 只执行存活检查
+	healthScopeLiveness healthScope = iota
+	// /readyz 执行存活检查和节点就绪检查
+	healthScopeReadiness
+	// CSI Probe 只由 controller 的 sidecar 调用，额外检查七牛服务地址的连通性
+	healthScopeController
+)
+
+// healthCheck 一项健康检查，scope 为 healthScopeLiveness 的检查失败时说明插件需要被重启，
+// healthScopeReadiness 的检查只影响节点的就绪状态，healthScopeController 的检查只影响 Probe
+type healthCheck struct {
+	name  string
+	scope healthScope
+	check func(ctx context.Context) (string, error)
+}
+
+type healthCheckResult struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type healthReport struct {
+	Status string                        `json:"status"`
+	Checks map[string]*healthCheckResult `json:"checks"`
+}
+
+// healthChecker 检查插件依赖的 connector、二进制文件、FUSE 设备和七牛服务地址
+type healthChecker struct {
+	checks []healthCheck
+}
+
+// newHealthChecker 根据驱动类型创建检查项，endpoints 为需要检查连通性的 UC 或 KodoFS master 地址
+func newHealthChecker(driverName string, endpoints []*url.URL) *healthChecker {
+	checks := []healthCheck{{name: "connector", scope: healthScopeLiveness, check: checkConnector}}
+	switch driverName {
+	case KodoDriverName:
+		checks = append(checks, healthCheck{name: "rclone", scope: healthScopeLiveness, check: func(ctx context.Context) (string, error) {
+			return checkBinary(ctx, protocol.RcloneCmd, "version")
+		}})
+	case KodoFSDriverName:
+		checks = append(checks, healthCheck{name: "kodofs", scope: healthScopeLiveness, check: func(ctx context.Context) (string, error) {
+			return checkBinary(ctx, protocol.KodoFSCmd, "--version")
+		}})
+	}
+	checks = append(checks, healthCheck{name: "fuse", scope: healthScopeReadiness, check: checkFuseDevice})
+	for _, endpoint := range endpoints {
+		endpoint := endpoint
+		// 节点挂载不依赖这些地址，不影响节点的就绪状态
+		checks = append(checks, healthCheck{name: "endpoint " + endpoint.Host, scope: healthScopeController, check: func(ctx context.Context) (string, error) {
+			return checkEndpoint(ctx, endpoint)
+		}})
+	}
+	return &healthChecker{checks: checks}
+}
+
+// Check 并发执行 scope 范围内的检查
+func (checker *healthChecker) Check(ctx context.Context, scope healthScope) *healthReport {
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+	report := &healthReport{Status: HEALTH_STATUS_OK, Checks: make(map[string]*healthCheckResult)}
+	for _, check := range checker.checks {
+		if check.scope > scope {
+			continue
+		}
+		wg.Add(1)
+		go func(check healthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, HEALTH_CHECK_TIMEOUT)
+			defer cancel()
+
+			result := &healthCheckResult{Status: HEALTH_STATUS_OK}
+			message, err := check.check(ctx)
+			if err != nil {
+				result.Status = HEALTH_STATUS_FAILED
+				result.Message = err.Error()
+			} else {
+				result.Message = message
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			report.Checks[check.name] = result
+			if err != nil {
+				report.Status = HEALTH_STATUS_FAILED
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+// Handler 返回 /healthz 或 /readyz 的处理函数，检查失败时返回 503
+func (checker *healthChecker) Handler(scope healthScope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context(), scope)
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != HEALTH_STATUS_OK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// identityServer 在 Probe 中执行所有检查，包括只影响 controller 的检查
+type identityServer struct {
+	*csicommon.DefaultIdentityServer
+	checker *healthChecker
+}
+
+func newIdentityServer(csiDriver *csicommon.CSIDriver, checker *healthChecker) *identityServer {
+	return &identityServer{DefaultIdentityServer: csicommon.NewDefaultIdentityServer(csiDriver), checker: checker}
+}
+
+func (ids *identityServer) Probe(ctx context.Context, req *csi.ProbeRequest) (*csi.ProbeResponse, error) {
+	report := ids.checker.Check(ctx, healthScopeController)
+	for name, result := range report.Checks {
+		if result.Status != HEALTH_STATUS_OK {
+			log.Warnf("Probe: check %s failed: %s", name, result.Message)
+		}
+	}
+	return &csi.ProbeResponse{Ready: wrapperspb.Bool(report.Status == HEALTH_STATUS_OK)}, nil
+}
+
+// parseProbeEndpoints 解析以逗号分隔的服务地址
+func parseProbeEndpoints(s string) ([]*url.URL, error) {
+	var endpoints []*url.URL
+	for _, rawURL := range strings.Split(s, ",") {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			continue
+		}
+		endpoint, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("parseProbeEndpoints: invalid endpoint %s: %w", rawURL, err)
+		} else if endpoint.Host == "" {
+			return nil, fmt.Errorf("parseProbeEndpoints: endpoint %s has no host", rawURL)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+// checkConnector 请求 connector 的 Version，旧版本的 connector 只检查 socket 是否可以连接
+func checkConnector(ctx context.Context) (string, error) {
+	conn, err := connector.getConn()
+	if err != nil {
+		return "", err
+	}
+	version, err := connectorpb.NewConnectorClient(conn).Version(ctx, &connectorpb.VersionRequest{})
+	if err == nil {
+		return fmt.Sprintf("version %s, protocol %s", version.GetVersion(), version.GetProtocolVersion()), nil
+	} else if !isLegacyConnectorError(err) {
+		return "", fmt.Errorf("failed to get version of connector %s: %w", *connectorSocket, err)
+	}
+	var dialer net.Dialer
+	legacyConn, legacyErr := dialer.DialContext(ctx, "unix", *connectorSocket)
+	if legacyErr != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", *connectorSocket, legacyErr)
+	}
+	legacyConn.Close()
+	return fmt.Sprintf("protocol %s", protocol.Version), nil
+}
+
+// checkBinary 检查可执行文件是否存在，并且已经被复制到宿主机上供 connector 使用，返回其版本
+// 可执行文件不支持查询版本时只检查其是否存在
+func checkBinary(ctx context.Context, path string, versionArgs ...string) (string, error) {
+	if fileInfo, err := os.Stat(path); err != nil {
+		return "", err
+	} else if fileInfo.Mode()&0111 == 0 {
+		return "", fmt.Errorf("%s is not executable", path)
+	}
+	if _, err := os.Stat(HOST_BIN_DIR); err == nil {
+		hostPath := filepath.Join(HOST_BIN_DIR, filepath.Base(path))
+		if _, err = os.Stat(hostPath); err != nil {
+			return "", fmt.Errorf("%s is not installed on host: %w", filepath.Base(path), err)
+		}
+	}
+	output, err := exec.CommandContext(ctx, path, versionArgs...).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Sprintf("unknown version: %s", exitErr), nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", path, err)
+	}
+	return strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0]), nil
+}
+
+func checkFuseDevice(ctx context.Context) (string, error) {
+	fileInfo, err := os.Stat(FUSE_DEVICE)
+	if err != nil {
+		return "", err
+	} else if fileInfo.Mode()&os.ModeCharDevice == 0 {
+		return "", fmt.Errorf("%s is not a character device", FUSE_DEVICE)
+	}
+	return "", nil
+}
+
+// checkEndpoint 检查是否可以与服务地址建立 TCP 连接
+func checkEndpoint(ctx context.Context, endpoint *url.URL) (string, error) {
+	port := endpoint.Port()
+	if port == "" {
+		if endpoint.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	if endpoint.Hostname() == "" {
+		return "", errors.New("host is empty")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(endpoint.Hostname(), port))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.RemoteAddr().String(), nil
+}
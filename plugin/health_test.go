@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckerHandler(t *testing.T) {
+	checker := &healthChecker{checks: []healthCheck{
+		{name: "connector", scope: healthScopeLiveness, check: func(ctx context.Context) (string, error) {
+			return "protocol v3", nil
+		}},
+		{name: "fuse", scope: healthScopeReadiness, check: func(ctx context.Context) (string, error) {
+			return "", errors.New("/dev/fuse is not a character device")
+		}},
+		{name: "endpoint uc.qbox.me", scope: healthScopeController, check: func(ctx context.Context) (string, error) {
+			return "", errors.New("connection refused")
+		}},
+	}}
+
+	recorder := httptest.NewRecorder()
+	checker.Handler(healthScopeLiveness).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var report healthReport
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.Equal(t, HEALTH_STATUS_OK, report.Status)
+	assert.Len(t, report.Checks, 1)
+	assert.Equal(t, "protocol v3", report.Checks["connector"].Message)
+
+	recorder = httptest.NewRecorder()
+	checker.Handler(healthScopeReadiness).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	report = healthReport{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.Equal(t, HEALTH_STATUS_FAILED, report.Status)
+	assert.Equal(t, HEALTH_STATUS_OK, report.Checks["connector"].Status)
+	assert.Equal(t, HEALTH_STATUS_FAILED, report.Checks["fuse"].Status)
+	assert.Equal(t, "/dev/fuse is not a character device", report.Checks["fuse"].Message)
+	// 服务地址的连通性不影响节点的就绪状态
+	assert.NotContains(t, report.Checks, "endpoint uc.qbox.me")
+
+	report = *checker.Check(context.Background(), healthScopeController)
+	assert.Len(t, report.Checks, 3)
+	assert.Equal(t, HEALTH_STATUS_FAILED, report.Checks["endpoint uc.qbox.me"].Status)
+}
+
+func TestParseProbeEndpoints(t *testing.T) {
+	endpoints, err := parseProbeEndpoints("https://uc.qbox.me, http://10.0.0.1:8080,")
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 2)
+	assert.Equal(t, "uc.qbox.me", endpoints[0].Host)
+	assert.Equal(t, "10.0.0.1:8080", endpoints[1].Host)
+
+	_, err = parseProbeEndpoints("uc.qbox.me")
+	assert.Error(t, err)
+}
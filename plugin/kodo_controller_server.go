@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
@@ -17,14 +21,36 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// 后台复制对象时保存进度的最小间隔
+const COPY_PROGRESS_SAVE_INTERVAL = 10 * time.Second
+
+const (
+	CHECKPOINT_BUCKET_NAME        = "bucketname"
+	CHECKPOINT_BUCKET_CREATED     = "bucketcreated"
+	CHECKPOINT_IAM_USER_CREATED   = "iamusercreated"
+	CHECKPOINT_IAM_POLICY_CREATED = "iampolicycreated"
+	CHECKPOINT_IAM_POLICY_GRANTED = "iampolicygranted"
+	CHECKPOINT_CONTENT_COPIED     = "contentcopied"
+)
+
 type kodoControllerServer struct {
-	volumes     map[string]*csi.Volume
-	volumesLock sync.Mutex
-	client      kubernetes.Interface
+	volumesLock   sync.Mutex
+	store         *volumeStateStore
+	nodeStore     *nodeStateStore
+	snapshotsLock sync.Mutex
+	snapshotStore *snapshotStateStore
+	// 正在后台复制对象的快照
+	copyingSnapshots map[string]struct{}
+	// 正在后台从数据源复制对象的存储卷，以及复制失败的原因
+	populatingVolumes map[string]struct{}
+	populateErrors    map[string]error
+	// 创建 bucket 时添加的集群 ID 标签，回收器根据它识别当前集群创建的 bucket
+	clusterId string
+	client    kubernetes.Interface
 	*csicommon.DefaultControllerServer
 }
 
-func newKodoControllerServer(d *csicommon.CSIDriver) csi.ControllerServer {
+func newKodoControllerServer(d *csicommon.CSIDriver, clusterId string) csi.ControllerServer {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		log.Fatalf("newKodoControllerServer: failed to create config: %v", err)
@@ -33,38 +59,103 @@ func newKodoControllerServer(d *csicommon.CSIDriver) csi.ControllerServer {
 	if err != nil {
 		log.Fatalf("newKodoControllerServer: failed to create client: %v", err)
 	}
+	if clusterId, err = resolveClusterId(context.Background(), clientset, clusterId); err != nil {
+		log.Fatalf("newKodoControllerServer: %v", err)
+	}
 
 	c := &kodoControllerServer{
-		volumes:                 make(map[string]*csi.Volume),
+		store:                   newVolumeStateStore(clientset, PodNamespace, KodoDriverName),
+		nodeStore:               newNodeStateStore(clientset, PodNamespace, KodoDriverName),
+		snapshotStore:           newSnapshotStateStore(clientset, PodNamespace, KodoDriverName),
+		copyingSnapshots:        make(map[string]struct{}),
+		populatingVolumes:       make(map[string]struct{}),
+		populateErrors:          make(map[string]error),
+		clusterId:               clusterId,
 		client:                  clientset,
 		DefaultControllerServer: csicommon.NewDefaultControllerServer(d),
 	}
 	return c
 }
 
-func (cs *kodoControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+func (cs *kodoControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (resp *csi.CreateVolumeResponse, err error) {
 	pvName := req.GetName()
 	log.Infof("CreateVolume: starting creating Kodo bucket %s", pvName)
+	if err = validateVolumeCapabilities(cs.Driver, req.GetVolumeCapabilities()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "CreateVolume: %s", err)
+	}
 
 	cs.volumesLock.Lock()
 	defer cs.volumesLock.Unlock()
 
-	if volume, exists := cs.volumes[pvName]; exists {
+	state, err := cs.store.Get(ctx, pvName)
+	if err != nil {
+		return nil, fmt.Errorf("CreateVolume: get state of volume %s error: %w", pvName, err)
+	} else if state == nil {
+		state = newVolumeState(pvName)
+	} else if state.Phase == volumePhaseCreated {
 		log.Warnf("CreateVolume: bucket %s already exists", pvName)
+		volume := state.toVolume()
+		volume.ContentSource = req.GetVolumeContentSource()
 		return &csi.CreateVolumeResponse{Volume: volume}, nil
+	} else if _, populating := cs.populatingVolumes[pvName]; populating {
+		return nil, status.Errorf(codes.Aborted, "CreateVolume: volume %s is being populated", pvName)
+	} else {
+		log.Infof("CreateVolume: resume creating Kodo bucket %s", pvName)
 	}
 
 	parameter, err := parseKodoStorageClassParameter("CreateVolume", req.GetParameters(), req.GetSecrets())
 	if err != nil {
 		return nil, err
 	}
+	if parameter.subDirTemplate != "" {
+		// 创建存储卷前检查 subdirTemplate 中是否使用了未知的变量
+		if _, err = expandSubDirTemplate(parameter.subDirTemplate, map[string]string{
+			CONTEXT_POD_NAME:             "pod",
+			CONTEXT_POD_NAMESPACE:        "namespace",
+			CONTEXT_POD_UID:              "uid",
+			CONTEXT_SERVICE_ACCOUNT_NAME: "serviceaccount",
+		}); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "CreateVolume: %s", err)
+		}
+	}
 	client := qiniu.NewKodoClient(parameter.accessKey, parameter.secretKey, parameter.ucEndpoint, VERSION, COMMITID)
 
-	bucketName := pvName + "-" + randomBucketName(16)
+	// 创建因无法通过重试恢复的错误失败时，删除本次或之前的重试中已经创建的资源
+	// 其他错误保留已经创建的资源和状态，重试的 CreateVolume 将从 checkpoint 处继续
+	defer func() {
+		if isTerminalError(err) {
+			cs.rollbackCreateVolume(client, state)
+		}
+	}()
+
+	bucketName, ok := state.getCheckpoint(CHECKPOINT_BUCKET_NAME)
+	if !ok {
+		if bucketName, err = makeBucketName(parameter.bucketNameTemplate, bucketNameTemplateData{
+			PVName:    pvName,
+			PVCName:   req.GetParameters()[PARAMETER_PVC_NAME],
+			Namespace: req.GetParameters()[PARAMETER_PVC_NAMESPACE],
+		}); err != nil {
+			// 模板或 PVC 信息有误，重试也无法成功
+			return nil, status.Errorf(codes.InvalidArgument, "CreateVolume: make bucket name for %s error: %s", pvName, err)
+		}
+		if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_BUCKET_NAME, bucketName); err != nil {
+			return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+		}
+	}
 	bucket, err := client.FindBucketByName(ctx, bucketName, false)
+	_, bucketCreated := state.getCheckpoint(CHECKPOINT_BUCKET_CREATED)
 	if err != nil {
 		return nil, fmt.Errorf("CreateVolume: find bucket %s error: %w", bucketName, err)
+	} else if bucket != nil && !bucketCreated {
+		// 同名 bucket 不是由本存储卷创建的，可能是已有的或其他集群的 bucket，DeleteVolume 会清空并删除它，因此不能复用
+		return nil, status.Errorf(codes.AlreadyExists, "CreateVolume: bucket %s already exists and is not created by volume %s", bucketName, pvName)
 	} else if bucket == nil {
+		// 先记录再创建，否则创建成功后未能记录时，重试的 CreateVolume 无法确认 bucket 是由本存储卷创建的
+		if !bucketCreated {
+			if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_BUCKET_CREATED, bucketName); err != nil {
+				return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+			}
+		}
 		if err = client.CreateBucket(ctx, bucketName, parameter.region); err != nil {
 			return nil, fmt.Errorf("CreateVolume: create bucket %s error: %w", bucketName, err)
 		}
@@ -76,7 +167,31 @@ func (cs *kodoControllerServer) CreateVolume(ctx context.Context, req *csi.Creat
 		}
 	} else {
 		parameter.region = bucket.KodoRegionID
-		log.Infof("CreateVolume: Kodo bucket %s has been created, reuse it", bucketName)
+		log.Infof("CreateVolume: Kodo bucket %s has been created by volume %s, reuse it", bucketName, pvName)
+	}
+	// 创建 bucket 后没来得及添加标签时，重试的 CreateVolume 会再次添加
+	if err = client.SetBucketTags(ctx, bucket.Name, map[string]string{BUCKET_TAG_CLUSTER_ID: cs.clusterId}); err != nil {
+		return nil, fmt.Errorf("CreateVolume: tag bucket %s error: %w", bucket.Name, err)
+	}
+
+	capacityBytes := req.GetCapacityRange().GetRequiredBytes()
+	if capacityBytes > 0 {
+		if err = cs.setBucketQuota(ctx, client, bucket.Name, capacityBytes); err != nil {
+			return nil, fmt.Errorf("CreateVolume: %w", err)
+		}
+		// 使 Pod 中 df 看到的容量与 PVC 一致
+		if parameter.vfsDiskSpaceTotalSize == nil {
+			size := uint64(capacityBytes)
+			parameter.vfsDiskSpaceTotalSize = &size
+		}
+	}
+
+	if contentSource := req.GetVolumeContentSource(); contentSource != nil {
+		if _, ok = state.getCheckpoint(CHECKPOINT_CONTENT_COPIED); !ok {
+			if err = cs.populateVolume(ctx, client, bucket, subDirPrefix(parameter.subDir), state, capacityBytes, contentSource, req.GetSecrets()); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	s3Endpoint, err := client.GetS3Endpoint(ctx, parameter.region)
@@ -96,17 +211,34 @@ func (cs *kodoControllerServer) CreateVolume(ctx context.Context, req *csi.Creat
 	iamUserName := pvName
 	iamPolicyName := normalizePolicyName(pvName)
 	originalAccessKey, originalSecretKey := parameter.accessKey, parameter.secretKey
-	if err = client.CreateIAMUser(context.Background(), iamUserName, randomPassword(128)); err != nil {
-		return nil, fmt.Errorf("CreateVolume: create IAM user %s error: %w", iamUserName, err)
-	} else if parameter.accessKey, parameter.secretKey, err = client.GetIAMUserKeyPair(context.Background(), iamUserName); err != nil {
+	// 与 bucket 相同，IAM 用户和策略也是先记录再创建，重试的 CreateVolume 会再次创建，同名资源已经存在时视为创建成功
+	if _, ok = state.getCheckpoint(CHECKPOINT_IAM_USER_CREATED); !ok {
+		if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_IAM_USER_CREATED, iamUserName); err != nil {
+			return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+		}
+	}
+	if err = createIAMUser(context.Background(), client, iamUserName); err != nil {
+		return nil, fmt.Errorf("CreateVolume: %w", err)
+	}
+	if parameter.accessKey, parameter.secretKey, err = client.GetIAMUserKeyPair(context.Background(), iamUserName); err != nil {
 		return nil, fmt.Errorf("CreateVolume: create key pair for IAM user %s error: %w", iamUserName, err)
-	} else if err = client.CreateIAMPolicy(ctx, iamPolicyName, bucket.Name); err != nil {
-		return nil, fmt.Errorf("CreateVolume: create IAM policy %s error: %w", iamPolicyName, err)
-	} else if err = client.GrantIAMPolicyToUser(ctx, iamUserName, []string{iamPolicyName}); err != nil {
-		return nil, fmt.Errorf("CreateVolume: grant IAM policy %s to %s error: %w", iamPolicyName, iamUserName, err)
-	} else {
-		log.Infof("CreateVolume: Kodo bucket %s is granted", bucket.Name)
 	}
+	if _, ok = state.getCheckpoint(CHECKPOINT_IAM_POLICY_CREATED); !ok {
+		if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_IAM_POLICY_CREATED, iamPolicyName); err != nil {
+			return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+		}
+	}
+	if err = createIAMPolicy(ctx, client, iamPolicyName, bucket.Name); err != nil {
+		return nil, fmt.Errorf("CreateVolume: %w", err)
+	}
+	if _, ok = state.getCheckpoint(CHECKPOINT_IAM_POLICY_GRANTED); !ok {
+		if err = client.GrantIAMPolicyToUser(ctx, iamUserName, []string{iamPolicyName}); err != nil {
+			return nil, fmt.Errorf("CreateVolume: grant IAM policy %s to %s error: %w", iamPolicyName, iamUserName, err)
+		} else if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_IAM_POLICY_GRANTED, iamUserName); err != nil {
+			return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+		}
+	}
+	log.Infof("CreateVolume: Kodo bucket %s is granted", bucket.Name)
 
 	volumeContext := map[string]string{
 		FIELD_BUCKET_ID:           bucket.ID,
@@ -198,15 +330,228 @@ func (cs *kodoControllerServer) CreateVolume(ctx context.Context, req *csi.Creat
 	if parameter.debugFuse {
 		volumeContext[FIELD_DEBUG_FUSE] = formatBool(parameter.debugFuse)
 	}
-	volume := &csi.Volume{
-		CapacityBytes: int64(req.GetCapacityRange().GetRequiredBytes()),
-		VolumeId:      pvName,
-		VolumeContext: volumeContext,
+	if parameter.subDirTemplate != "" {
+		volumeContext[FIELD_SUB_DIR_TEMPLATE] = parameter.subDirTemplate
+	}
+	state.Phase = volumePhaseCreated
+	state.CapacityBytes = capacityBytes
+	state.VolumeContext = volumeContext
+	if err = cs.store.Save(ctx, state); err != nil {
+		return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
 	}
-	cs.volumes[pvName] = volume
+	volume := state.toVolume()
+	volume.ContentSource = req.GetVolumeContentSource()
 	return &csi.CreateVolumeResponse{Volume: volume}, nil
 }
 
+// populateVolume 在后台将数据源中的对象复制到新创建的 bucket 中
+// 复制完成之前返回 codes.Aborted，csi-provisioner 会重试 CreateVolume，直到复制完成后才继续创建存储卷
+// 复制进度保存在存储卷状态中，Provisioner 重启或切换 leader 后重试的 CreateVolume 从中断处继续复制
+func (cs *kodoControllerServer) populateVolume(ctx context.Context, client *qiniu.KodoClient, bucket *qiniu.Bucket, dstPrefix string,
+	state *volumeState, capacityBytes int64, contentSource *csi.VolumeContentSource, secrets map[string]string) error {
+	pvName := state.VolumeId
+	if err, failed := cs.populateErrors[pvName]; failed {
+		delete(cs.populateErrors, pvName)
+		return fmt.Errorf("populateVolume: failed to copy objects to volume %s: %w", pvName, err)
+	}
+
+	srcBucketName, srcPrefix, srcSizeBytes, err := cs.getContentSource(ctx, contentSource, secrets)
+	if err != nil {
+		return err
+	} else if capacityBytes > 0 && srcSizeBytes > capacityBytes {
+		return status.Errorf(codes.OutOfRange, "populateVolume: requested capacity %d of volume %s is less than size %d of the source",
+			capacityBytes, pvName, srcSizeBytes)
+	}
+	// 服务端复制要求两个 bucket 位于同一区域
+	if srcBucket, err := client.FindBucketByName(ctx, srcBucketName, false); err != nil {
+		return fmt.Errorf("populateVolume: find bucket %s error: %w", srcBucketName, err)
+	} else if srcBucket == nil {
+		return status.Errorf(codes.NotFound, "populateVolume: cannot find source bucket %s", srcBucketName)
+	} else if srcBucket.KodoRegionID != bucket.KodoRegionID {
+		return status.Errorf(codes.InvalidArgument, "populateVolume: source bucket %s is in region %s, but bucket %s is in region %s",
+			srcBucketName, srcBucket.KodoRegionID, bucket.Name, bucket.KodoRegionID)
+	}
+
+	var progress qiniu.CopyProgress
+	if state.CopyProgress != nil {
+		progress = *state.CopyProgress
+		log.Infof("populateVolume: resume copying objects from %s/%s to %s/%s after %s", srcBucketName, srcPrefix, bucket.Name, dstPrefix, progress.LastObjectName)
+	} else {
+		log.Infof("populateVolume: starting copying objects from %s/%s to %s/%s", srcBucketName, srcPrefix, bucket.Name, dstPrefix)
+	}
+	cs.populatingVolumes[pvName] = struct{}{}
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lastSavedAt := time.Now()
+		sizeBytes, err := client.ResumeCopyObjects(ctx, srcBucketName, srcPrefix, bucket.Name, dstPrefix, progress, func(progress qiniu.CopyProgress) {
+			if time.Since(lastSavedAt) < COPY_PROGRESS_SAVE_INTERVAL {
+				return
+			}
+			lastSavedAt = time.Now()
+			if !cs.savePopulateProgress(ctx, pvName, progress) {
+				cancel()
+			}
+		})
+
+		cs.volumesLock.Lock()
+		defer cs.volumesLock.Unlock()
+		delete(cs.populatingVolumes, pvName)
+
+		if err != nil {
+			log.Errorf("populateVolume: failed to copy objects from %s to %s: %s", srcBucketName, bucket.Name, err)
+			cs.populateErrors[pvName] = err
+			return
+		}
+		if state, err := cs.store.Get(ctx, pvName); err != nil {
+			cs.populateErrors[pvName] = err
+		} else if state == nil {
+			log.Warnf("populateVolume: volume %s has been rolled back", pvName)
+		} else {
+			state.CopyProgress = nil
+			if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_CONTENT_COPIED, srcBucketName); err != nil {
+				cs.populateErrors[pvName] = err
+			} else {
+				log.Infof("populateVolume: volume %s is populated from %s, size: %d", pvName, srcBucketName, sizeBytes)
+			}
+		}
+	}()
+	return status.Errorf(codes.Aborted, "populateVolume: volume %s is being populated", pvName)
+}
+
+// savePopulateProgress 将复制进度保存到存储卷状态中，存储卷已经被回滚时返回 false
+func (cs *kodoControllerServer) savePopulateProgress(ctx context.Context, pvName string, progress qiniu.CopyProgress) bool {
+	cs.volumesLock.Lock()
+	defer cs.volumesLock.Unlock()
+
+	state, err := cs.store.Get(ctx, pvName)
+	if err != nil {
+		log.Warnf("savePopulateProgress: %s", err)
+		return true
+	} else if state == nil {
+		log.Warnf("savePopulateProgress: volume %s has been rolled back, stop copying", pvName)
+		return false
+	}
+	state.CopyProgress = &progress
+	if err = cs.store.Save(ctx, state); err != nil {
+		log.Warnf("savePopulateProgress: %s", err)
+	}
+	return true
+}
+
+// getContentSource 获取数据源所在的 bucket、对象名称前缀和大小，数据源可以是快照或者另一个存储卷
+// 快照的大小为复制的对象总大小，存储卷的大小为动态创建时的容量，静态存储卷的大小未知，返回 0
+func (cs *kodoControllerServer) getContentSource(ctx context.Context, contentSource *csi.VolumeContentSource, secrets map[string]string) (string, string, int64, error) {
+	if snapshot := contentSource.GetSnapshot(); snapshot != nil {
+		state, err := cs.snapshotStore.Get(ctx, snapshot.GetSnapshotId())
+		if err != nil {
+			return "", "", 0, fmt.Errorf("getContentSource: %w", err)
+		} else if state == nil {
+			return "", "", 0, status.Errorf(codes.NotFound, "getContentSource: snapshot %s is not found", snapshot.GetSnapshotId())
+		} else if !state.ReadyToUse {
+			return "", "", 0, status.Errorf(codes.Unavailable, "getContentSource: snapshot %s is not ready to use", snapshot.GetSnapshotId())
+		}
+		return state.BucketName, state.Prefix, state.SizeBytes, nil
+	} else if volume := contentSource.GetVolume(); volume != nil {
+		volumeContext, err := cs.store.GetVolumeContext(ctx, TypePluginKodo, volume.GetVolumeId())
+		if err != nil {
+			return "", "", 0, err
+		}
+		parameter, err := parseKodoPvParameter("getContentSource", volumeContext, secrets)
+		if err != nil {
+			return "", "", 0, err
+		}
+		var sizeBytes int64
+		if state, err := cs.store.Get(ctx, volume.GetVolumeId()); err != nil {
+			return "", "", 0, fmt.Errorf("getContentSource: %w", err)
+		} else if state != nil {
+			sizeBytes = state.CapacityBytes
+		}
+		return parameter.bucketName, subDirPrefix(parameter.subDir), sizeBytes, nil
+	}
+	return "", "", 0, status.Error(codes.InvalidArgument, "getContentSource: unsupported volume content source")
+}
+
+// rollbackCreateVolume 按照与创建相反的顺序删除 CreateVolume 创建的 IAM 授权、IAM 策略、IAM 用户、复制的对象和 bucket
+// 只有记录了 CHECKPOINT_BUCKET_CREATED 的 bucket 才会被删除
+func (cs *kodoControllerServer) rollbackCreateVolume(client *qiniu.KodoClient, state *volumeState) {
+	iamUserName := state.VolumeId
+	iamPolicyName := normalizePolicyName(state.VolumeId)
+	bucketName, _ := state.getCheckpoint(CHECKPOINT_BUCKET_NAME)
+
+	cs.store.Rollback(state, []rollbackStep{
+		{checkpoint: CHECKPOINT_IAM_POLICY_GRANTED, undo: func(ctx context.Context) error {
+			return client.RevokeIAMPolicyFromUser(ctx, iamUserName, []string{iamPolicyName})
+		}},
+		{checkpoint: CHECKPOINT_IAM_POLICY_CREATED, undo: func(ctx context.Context) error {
+			// checkpoint 在创建 IAM 策略之前记录，策略可能并没有创建成功
+			if iamPolicies, err := client.GetIAMPolicies(ctx); err != nil {
+				return err
+			} else if !containsString(iamPolicies, iamPolicyName) {
+				return nil
+			}
+			return client.DeleteIAMPolicy(ctx, iamPolicyName)
+		}},
+		{checkpoint: CHECKPOINT_IAM_USER_CREATED, undo: func(ctx context.Context) error {
+			if iamUsers, err := client.GetIAMUsers(ctx); err != nil {
+				return err
+			} else if !containsString(iamUsers, iamUserName) {
+				return nil
+			}
+			return client.DeleteIAMUser(ctx, iamUserName)
+		}},
+		{checkpoint: CHECKPOINT_CONTENT_COPIED, undo: func(ctx context.Context) error {
+			// 被复用的 bucket 中的对象不会被删除
+			if _, created := state.getCheckpoint(CHECKPOINT_BUCKET_CREATED); created {
+				return client.CleanObjects(ctx, bucketName)
+			}
+			return nil
+		}},
+		{checkpoint: CHECKPOINT_BUCKET_CREATED, undo: func(ctx context.Context) error {
+			// checkpoint 在创建 bucket 之前记录，bucket 可能并没有创建成功
+			if bucket, err := client.FindBucketByName(ctx, bucketName, false); err != nil {
+				return err
+			} else if bucket == nil {
+				return nil
+			}
+			// bucket 中可能存在从数据源复制了一部分的对象
+			if err := client.CleanObjects(ctx, bucketName); err != nil {
+				return err
+			}
+			return client.DeleteBucket(ctx, bucketName)
+		}},
+	})
+}
+
+// createIAMUser 创建 IAM 用户，同名用户已经存在时视为创建成功
+// 用户名与 PV 名称相同，已经存在的同名用户是之前的 CreateVolume 创建后没来得及确认的
+func createIAMUser(ctx context.Context, client *qiniu.KodoClient, userName string) error {
+	err := client.CreateIAMUser(ctx, userName, randomPassword(128))
+	if err == nil {
+		log.Infof("CreateVolume: IAM user %s is created", userName)
+		return nil
+	}
+	if iamUsers, listErr := client.GetIAMUsers(ctx); listErr == nil && containsString(iamUsers, userName) {
+		log.Infof("CreateVolume: IAM user %s already exists, reuse it", userName)
+		return nil
+	}
+	return fmt.Errorf("createIAMUser: create IAM user %s error: %w", userName, err)
+}
+
+// createIAMPolicy 创建只允许访问 bucketName 的 IAM 策略，同名策略已经存在时视为创建成功
+func createIAMPolicy(ctx context.Context, client *qiniu.KodoClient, policyName, bucketName string) error {
+	err := client.CreateIAMPolicy(ctx, policyName, bucketName)
+	if err == nil {
+		log.Infof("CreateVolume: IAM policy %s is created", policyName)
+		return nil
+	}
+	if iamPolicies, listErr := client.GetIAMPolicies(ctx); listErr == nil && containsString(iamPolicies, policyName) {
+		log.Infof("CreateVolume: IAM policy %s already exists, reuse it", policyName)
+		return nil
+	}
+	return fmt.Errorf("createIAMPolicy: create IAM policy %s error: %w", policyName, err)
+}
+
 func (cs *kodoControllerServer) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
 	volumeId := req.GetVolumeId()
 
@@ -229,8 +574,6 @@ func (cs *kodoControllerServer) DeleteVolume(ctx context.Context, req *csi.Delet
 	cs.volumesLock.Lock()
 	defer cs.volumesLock.Unlock()
 
-	delete(cs.volumes, volumeId)
-
 	client := qiniu.NewKodoClient(parameter.originalAccessKey, parameter.originalSecretKey, parameter.ucEndpoint, VERSION, COMMITID)
 	iamUserName := volumeId
 	iamPolicyName := normalizePolicyName(volumeId)
@@ -255,14 +598,304 @@ func (cs *kodoControllerServer) DeleteVolume(ctx context.Context, req *csi.Delet
 		}
 	}
 
+	if err = cs.store.Delete(ctx, volumeId); err != nil {
+		return nil, fmt.Errorf("DeleteVolume: delete state of volume %s error: %w", volumeId, err)
+	}
 	return &csi.DeleteVolumeResponse{}, nil
 }
 
+// ControllerExpandVolume 提高 bucket 的存储量配额，Kodo 不支持缩容，请求的容量小于当前容量时直接返回当前容量
 func (cs *kodoControllerServer) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest,
 ) (*csi.ControllerExpandVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	volumeId := req.GetVolumeId()
+	capacityBytes := req.GetCapacityRange().GetRequiredBytes()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "ControllerExpandVolume: volume id is empty")
+	}
+	log.Infof("ControllerExpandVolume: starting expanding Kodo volume %s to %d", volumeId, capacityBytes)
+
+	cs.volumesLock.Lock()
+	defer cs.volumesLock.Unlock()
+
+	state, err := cs.store.Get(ctx, volumeId)
+	if err != nil {
+		return nil, fmt.Errorf("ControllerExpandVolume: get state of volume %s error: %w", volumeId, err)
+	} else if state != nil && state.Phase == volumePhaseCreated && state.CapacityBytes >= capacityBytes {
+		return &csi.ControllerExpandVolumeResponse{CapacityBytes: state.CapacityBytes}, nil
+	}
+
+	volumeContext, err := cs.store.GetVolumeContext(ctx, TypePluginKodo, volumeId)
+	if err != nil {
+		return nil, err
+	}
+	parameter, err := parseKodoPvParameter("ControllerExpandVolume", volumeContext, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	client, err := newKodoAdminClient("ControllerExpandVolume", volumeContext, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	if err = cs.setBucketQuota(ctx, client, parameter.bucketName, capacityBytes); err != nil {
+		return nil, fmt.Errorf("ControllerExpandVolume: %w", err)
+	}
+
+	// 静态存储卷没有状态，只调整配额
+	nodeExpansionRequired := false
+	if state != nil && state.Phase == volumePhaseCreated {
+		// 只有 vfsdiskspacetotalsize 来自于存储卷容量时才随之调整，StorageClass 中显式指定的值保持不变
+		// 已挂载的存储卷不会重新挂载，新的容量在下次挂载时生效
+		if state.VolumeContext[FIELD_VFS_DISK_SPACE_TOTAL_SIZE] == formatUint(uint64(state.CapacityBytes)) {
+			state.VolumeContext[FIELD_VFS_DISK_SPACE_TOTAL_SIZE] = formatUint(uint64(capacityBytes))
+			nodeExpansionRequired = true
+		}
+		state.CapacityBytes = capacityBytes
+		if err = cs.store.Save(ctx, state); err != nil {
+			return nil, fmt.Errorf("ControllerExpandVolume: save state of volume %s error: %w", volumeId, err)
+		}
+	}
+	log.Infof("ControllerExpandVolume: Kodo volume %s is expanded to %d", volumeId, capacityBytes)
+	return &csi.ControllerExpandVolumeResponse{CapacityBytes: capacityBytes, NodeExpansionRequired: nodeExpansionRequired}, nil
+}
+
+// setBucketQuota 将存储卷容量设置为 bucket 的存储量配额，私有云等不提供配额接口的环境下只记录警告
+func (cs *kodoControllerServer) setBucketQuota(ctx context.Context, client *qiniu.KodoClient, bucketName string, capacityBytes int64) error {
+	if err := client.SetBucketQuota(ctx, bucketName, capacityBytes); errors.Is(err, qiniu.ErrAPINotSupported) {
+		log.Warnf("setBucketQuota: quota of bucket %s is not supported: %s", bucketName, err)
+	} else if err != nil {
+		return fmt.Errorf("setBucketQuota: failed to set quota of bucket %s to %d: %w", bucketName, capacityBytes, err)
+	} else {
+		log.Infof("setBucketQuota: quota of bucket %s is set to %d", bucketName, capacityBytes)
+	}
+	return nil
+}
+
+func (cs *kodoControllerServer) ValidateVolumeCapabilities(ctx context.Context, req *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
+	return validateVolumeCapabilitiesOf(ctx, "ValidateVolumeCapabilities", TypePluginKodo, cs.store, cs.Driver, req)
+}
+
+func (cs *kodoControllerServer) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ControllerGetVolume: volume id is empty")
+	}
+	return getVolumeStatus(ctx, "ControllerGetVolume", TypePluginKodo, cs.store, cs.nodeStore, req.GetVolumeId(), cs.checkVolumeCondition)
 }
 
-func (cs *kodoControllerServer) ControllerGetVolume(context.Context, *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+func (cs *kodoControllerServer) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
+	return listVolumeStatuses(ctx, "ListVolumes", cs.store, cs.nodeStore, req, cs.checkVolumeCondition)
+}
+
+// checkVolumeCondition 检查存储卷的 bucket 是否仍然存在，无法检查时不认为存储卷异常
+func (cs *kodoControllerServer) checkVolumeCondition(ctx context.Context, volumeId string, volumeContext map[string]string) *csi.VolumeCondition {
+	parameter, err := parseKodoPvParameter("checkVolumeCondition", volumeContext, make(map[string]string))
+	if err != nil {
+		log.Warnf("checkVolumeCondition: failed to parse parameters of volume %s: %s", volumeId, err)
+		return &csi.VolumeCondition{Message: fmt.Sprintf("cannot check bucket of volume: %s", err)}
+	} else if parameter.bucketName == "" {
+		return &csi.VolumeCondition{Message: "cannot check bucket of volume: bucket name is unknown"}
+	}
+	client, err := newKodoAdminClient("checkVolumeCondition", volumeContext, make(map[string]string))
+	if err != nil {
+		return &csi.VolumeCondition{Message: fmt.Sprintf("cannot check bucket of volume: %s", err)}
+	}
+	if bucket, err := client.FindBucketByName(ctx, parameter.bucketName, false); err != nil {
+		log.Warnf("checkVolumeCondition: failed to find bucket %s of volume %s: %s", parameter.bucketName, volumeId, err)
+		return &csi.VolumeCondition{Message: fmt.Sprintf("cannot check bucket %s: %s", parameter.bucketName, err)}
+	} else if bucket == nil {
+		return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("bucket %s does not exist", parameter.bucketName)}
+	}
+	return &csi.VolumeCondition{Message: fmt.Sprintf("bucket %s exists", parameter.bucketName)}
+}
+
+// CreateSnapshot 在服务端将源存储卷 subdir 下的所有对象复制到快照 bucket 中
+// 复制在后台进行，完成之前返回的快照 ReadyToUse 为 false，csi-snapshotter 会重复调用直到复制完成
+// 快照不是某一时刻的一致性视图，复制期间写入的对象可能包含也可能不包含在快照中
+func (cs *kodoControllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
+	snapshotId := req.GetName()
+	sourceVolumeId := req.GetSourceVolumeId()
+	if snapshotId == "" {
+		return nil, status.Error(codes.InvalidArgument, "CreateSnapshot: snapshot name is empty")
+	} else if sourceVolumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "CreateSnapshot: source volume id is empty")
+	}
+	log.Infof("CreateSnapshot: starting creating snapshot %s of volume %s", snapshotId, sourceVolumeId)
+
+	cs.snapshotsLock.Lock()
+	defer cs.snapshotsLock.Unlock()
+
+	state, err := cs.snapshotStore.Get(ctx, snapshotId)
+	if err != nil {
+		return nil, fmt.Errorf("CreateSnapshot: get state of snapshot %s error: %w", snapshotId, err)
+	} else if state != nil {
+		if state.SourceVolumeId != sourceVolumeId {
+			return nil, status.Errorf(codes.AlreadyExists, "CreateSnapshot: snapshot %s already exists for volume %s", snapshotId, state.SourceVolumeId)
+		}
+	} else {
+		volumeContext, err := cs.store.GetVolumeContext(ctx, TypePluginKodo, sourceVolumeId)
+		if err != nil {
+			return nil, err
+		}
+		state = &snapshotState{
+			SnapshotId:          snapshotId,
+			SourceVolumeId:      sourceVolumeId,
+			SourceVolumeContext: volumeContext,
+			CreationTime:        time.Now(),
+		}
+		for key, value := range req.GetParameters() {
+			if strings.ToLower(key) == FIELD_SNAPSHOT_BUCKET {
+				state.BucketName = strings.TrimSpace(value)
+			}
+		}
+		if state.BucketName != "" {
+			state.Prefix = snapshotId + "/"
+		} else {
+			state.BucketName = normalizeBucketName(snapshotId)
+			state.DedicatedBucket = true
+		}
+		if err = cs.snapshotStore.Save(ctx, state); err != nil {
+			return nil, fmt.Errorf("CreateSnapshot: save state of snapshot %s error: %w", snapshotId, err)
+		}
+	}
+
+	if !state.ReadyToUse {
+		if _, copying := cs.copyingSnapshots[snapshotId]; !copying {
+			parameter, err := parseKodoPvParameter("CreateSnapshot", state.SourceVolumeContext, req.GetSecrets())
+			if err != nil {
+				return nil, err
+			}
+			client, err := newKodoAdminClient("CreateSnapshot", state.SourceVolumeContext, req.GetSecrets())
+			if err != nil {
+				return nil, err
+			}
+			cs.copyingSnapshots[snapshotId] = struct{}{}
+			go cs.copySnapshot(client, parameter, state)
+		}
+	}
+	return &csi.CreateSnapshotResponse{Snapshot: state.toSnapshot()}, nil
+}
+
+// copySnapshot 在后台复制快照的对象，完成后将快照标记为可用，失败时等待下一次 CreateSnapshot 重试
+// 复制进度保存在快照状态中，Provisioner 重启或切换 leader 后从中断处继续复制
+func (cs *kodoControllerServer) copySnapshot(client *qiniu.KodoClient, parameter *kodoPvParameter, state *snapshotState) {
+	ctx := context.Background()
+	var progress qiniu.CopyProgress
+	if state.CopyProgress != nil {
+		progress = *state.CopyProgress
+		log.Infof("copySnapshot: resume copying objects of snapshot %s after %s", state.SnapshotId, progress.LastObjectName)
+	}
+	sizeBytes, err := func() (int64, error) {
+		if state.DedicatedBucket {
+			if bucket, err := client.FindBucketByName(ctx, state.BucketName, false); err != nil {
+				return 0, fmt.Errorf("find bucket %s error: %w", state.BucketName, err)
+			} else if bucket == nil {
+				// 服务端复制要求两个 bucket 位于同一区域
+				if err = client.CreateBucket(ctx, state.BucketName, parameter.region); err != nil {
+					return 0, fmt.Errorf("create bucket %s error: %w", state.BucketName, err)
+				}
+				log.Infof("copySnapshot: Kodo bucket %s is created for snapshot %s", state.BucketName, state.SnapshotId)
+			}
+		}
+		lastSavedAt := time.Now()
+		return client.ResumeCopyObjects(ctx, parameter.bucketName, subDirPrefix(parameter.subDir), state.BucketName, state.Prefix, progress,
+			func(progress qiniu.CopyProgress) {
+				if time.Since(lastSavedAt) < COPY_PROGRESS_SAVE_INTERVAL {
+					return
+				}
+				lastSavedAt = time.Now()
+				cs.snapshotsLock.Lock()
+				defer cs.snapshotsLock.Unlock()
+				state.CopyProgress = &progress
+				if err := cs.snapshotStore.Save(ctx, state); err != nil {
+					log.Warnf("copySnapshot: %s", err)
+				}
+			})
+	}()
+
+	cs.snapshotsLock.Lock()
+	defer cs.snapshotsLock.Unlock()
+	delete(cs.copyingSnapshots, state.SnapshotId)
+
+	if err != nil {
+		log.Errorf("copySnapshot: failed to copy objects from %s to snapshot %s: %s", parameter.bucketName, state.SnapshotId, err)
+		return
+	}
+	state.SizeBytes = sizeBytes
+	state.ReadyToUse = true
+	state.CopyProgress = nil
+	if err = cs.snapshotStore.Save(ctx, state); err != nil {
+		log.Errorf("copySnapshot: %s", err)
+		return
+	}
+	log.Infof("copySnapshot: snapshot %s of volume %s is ready, size: %d", state.SnapshotId, state.SourceVolumeId, sizeBytes)
+}
+
+func (cs *kodoControllerServer) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
+	snapshotId := req.GetSnapshotId()
+	if snapshotId == "" {
+		return nil, status.Error(codes.InvalidArgument, "DeleteSnapshot: snapshot id is empty")
+	}
+	log.Infof("DeleteSnapshot: starting deleting snapshot %s", snapshotId)
+
+	cs.snapshotsLock.Lock()
+	defer cs.snapshotsLock.Unlock()
+
+	state, err := cs.snapshotStore.Get(ctx, snapshotId)
+	if err != nil {
+		return nil, fmt.Errorf("DeleteSnapshot: get state of snapshot %s error: %w", snapshotId, err)
+	} else if state == nil {
+		log.Warnf("DeleteSnapshot: snapshot %s does not exist", snapshotId)
+		return &csi.DeleteSnapshotResponse{}, nil
+	} else if _, copying := cs.copyingSnapshots[snapshotId]; copying {
+		return nil, status.Errorf(codes.Aborted, "DeleteSnapshot: snapshot %s is being created", snapshotId)
+	}
+
+	client, err := newKodoAdminClient("DeleteSnapshot", state.SourceVolumeContext, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	if bucket, err := client.FindBucketByName(ctx, state.BucketName, false); err != nil {
+		return nil, fmt.Errorf("DeleteSnapshot: find bucket %s error: %w", state.BucketName, err)
+	} else if bucket == nil {
+		log.Warnf("DeleteSnapshot: bucket %s of snapshot %s does not exist", state.BucketName, snapshotId)
+	} else if state.DedicatedBucket {
+		if err = client.CleanObjects(ctx, state.BucketName); err != nil {
+			return nil, fmt.Errorf("DeleteSnapshot: failed to clean all objects from %s: %w", state.BucketName, err)
+		} else if err = client.DeleteBucket(ctx, state.BucketName); err != nil {
+			return nil, fmt.Errorf("DeleteSnapshot: failed to delete bucket %s: %w", state.BucketName, err)
+		}
+		log.Infof("DeleteSnapshot: Kodo bucket %s is deleted", state.BucketName)
+	} else if err = client.DeleteObjectsByPrefix(ctx, state.BucketName, state.Prefix); err != nil {
+		return nil, fmt.Errorf("DeleteSnapshot: failed to delete objects with prefix %s from %s: %w", state.Prefix, state.BucketName, err)
+	}
+
+	if err = cs.snapshotStore.Delete(ctx, snapshotId); err != nil {
+		return nil, fmt.Errorf("DeleteSnapshot: delete state of snapshot %s error: %w", snapshotId, err)
+	}
+	return &csi.DeleteSnapshotResponse{}, nil
+}
+
+func (cs *kodoControllerServer) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
+	states, err := cs.snapshotStore.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListSnapshots: %w", err)
+	}
+
+	var entries []*csi.ListSnapshotsResponse_Entry
+	for _, state := range states {
+		if req.GetSnapshotId() != "" && req.GetSnapshotId() != state.SnapshotId {
+			continue
+		} else if req.GetSourceVolumeId() != "" && req.GetSourceVolumeId() != state.SourceVolumeId {
+			continue
+		}
+		entries = append(entries, &csi.ListSnapshotsResponse_Entry{Snapshot: state.toSnapshot()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Snapshot.SnapshotId < entries[j].Snapshot.SnapshotId
+	})
+
+	start, end, nextToken, err := paginate("ListSnapshots", req.GetStartingToken(), req.GetMaxEntries(), len(entries))
+	if err != nil {
+		return nil, err
+	}
+	return &csi.ListSnapshotsResponse{Entries: entries[start:end], NextToken: nextToken}, nil
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKodoControllerServer_ListSnapshots(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	cs := &kodoControllerServer{
+		snapshotStore: newSnapshotStateStore(clientset, "kube-system", KodoDriverName),
+		client:        clientset,
+	}
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, cs.snapshotStore.Save(ctx, &snapshotState{
+			SnapshotId:     fmt.Sprintf("snapshot-%d", i),
+			SourceVolumeId: fmt.Sprintf("kodo-%d", i%2),
+			ReadyToUse:     true,
+		}))
+	}
+
+	var snapshotIds []string
+	req := &csi.ListSnapshotsRequest{MaxEntries: 2}
+	for {
+		resp, err := cs.ListSnapshots(ctx, req)
+		assert.NoError(t, err)
+		for _, entry := range resp.GetEntries() {
+			snapshotIds = append(snapshotIds, entry.GetSnapshot().GetSnapshotId())
+		}
+		if resp.GetNextToken() == "" {
+			break
+		}
+		req.StartingToken = resp.GetNextToken()
+	}
+	assert.Equal(t, []string{"snapshot-0", "snapshot-1", "snapshot-2", "snapshot-3", "snapshot-4"}, snapshotIds)
+
+	resp, err := cs.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: "kodo-1"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetEntries(), 2)
+
+	resp, err = cs.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SnapshotId: "snapshot-3"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetEntries(), 1)
+
+	resp, err = cs.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SnapshotId: "snapshot-404"})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.GetEntries())
+
+	_, err = cs.ListSnapshots(ctx, &csi.ListSnapshotsRequest{StartingToken: "invalid"})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestKodoControllerServer_GetContentSource(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	cs := &kodoControllerServer{
+		store:         newVolumeStateStore(clientset, "kube-system", KodoDriverName),
+		snapshotStore: newSnapshotStateStore(clientset, "kube-system", KodoDriverName),
+		client:        clientset,
+	}
+	snapshotSource := func(snapshotId string) *csi.VolumeContentSource {
+		return &csi.VolumeContentSource{Type: &csi.VolumeContentSource_Snapshot{
+			Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapshotId},
+		}}
+	}
+
+	_, _, _, err := cs.getContentSource(ctx, snapshotSource("snapshot-1"), nil)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	state := &snapshotState{SnapshotId: "snapshot-1", SourceVolumeId: "kodo-1", BucketName: "kodo-snapshots", Prefix: "snapshot-1/"}
+	assert.NoError(t, cs.snapshotStore.Save(ctx, state))
+	_, _, _, err = cs.getContentSource(ctx, snapshotSource("snapshot-1"), nil)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	state.ReadyToUse = true
+	state.SizeBytes = 1 << 30
+	assert.NoError(t, cs.snapshotStore.Save(ctx, state))
+	bucketName, prefix, sizeBytes, err := cs.getContentSource(ctx, snapshotSource("snapshot-1"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "kodo-snapshots", bucketName)
+	assert.Equal(t, "snapshot-1/", prefix)
+	assert.Equal(t, int64(1<<30), sizeBytes)
+
+	_, _, _, err = cs.getContentSource(ctx, &csi.VolumeContentSource{Type: &csi.VolumeContentSource_Volume{
+		Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: "kodo-404"},
+	}}, nil)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, _, _, err = cs.getContentSource(ctx, &csi.VolumeContentSource{}, nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestKodoControllerServer_PopulateVolumeUndersized(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	cs := &kodoControllerServer{
+		store:             newVolumeStateStore(clientset, "kube-system", KodoDriverName),
+		snapshotStore:     newSnapshotStateStore(clientset, "kube-system", KodoDriverName),
+		populatingVolumes: make(map[string]struct{}),
+		populateErrors:    make(map[string]error),
+		client:            clientset,
+	}
+	assert.NoError(t, cs.snapshotStore.Save(ctx, &snapshotState{
+		SnapshotId: "snapshot-1", SourceVolumeId: "kodo-1", BucketName: "kodo-snapshots", Prefix: "snapshot-1/",
+		SizeBytes: 10 << 30, ReadyToUse: true,
+	}))
+	contentSource := &csi.VolumeContentSource{Type: &csi.VolumeContentSource_Snapshot{
+		Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: "snapshot-1"},
+	}}
+
+	// 请求的容量小于快照大小时，在复制对象之前失败
+	err := cs.populateVolume(ctx, nil, &qiniu.Bucket{Name: "kodo-2"}, "", newVolumeState("kodo-2"), 5<<30, contentSource, nil)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+	assert.True(t, isTerminalError(err))
+	assert.Empty(t, cs.populatingVolumes)
+}
+
+func TestKodoControllerServer_ControllerExpandVolumeNoShrink(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	cs := &kodoControllerServer{
+		store:  newVolumeStateStore(clientset, "kube-system", KodoDriverName),
+		client: clientset,
+	}
+	state := newVolumeState("kodo-1")
+	state.Phase = volumePhaseCreated
+	state.CapacityBytes = 10 << 30
+	assert.NoError(t, cs.store.Save(ctx, state))
+
+	resp, err := cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+		VolumeId:      "kodo-1",
+		CapacityRange: &csi.CapacityRange{RequiredBytes: 5 << 30},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10<<30), resp.GetCapacityBytes())
+
+	_, err = cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
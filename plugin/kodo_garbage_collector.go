@@ -0,0 +1,508 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	GC_LEASE_NAME           = "kodo-csi-garbage-collector"
+	GC_LEASE_DURATION       = 60 * time.Second
+	GC_LEASE_RENEW_DEADLINE = 30 * time.Second
+	GC_LEASE_RETRY_PERIOD   = 10 * time.Second
+	GC_COLLECT_TIMEOUT      = 30 * time.Minute
+)
+
+const (
+	EVENT_REASON_ORPHANED_RESOURCES              = "OrphanedResources"
+	EVENT_REASON_ORPHANED_RESOURCES_DELETED      = "OrphanedResourcesDeleted"
+	EVENT_REASON_ORPHANED_RESOURCES_DELETE_ERROR = "OrphanedResourcesDeleteError"
+)
+
+// BUCKET_TAG_CLUSTER_ID 是插件创建 bucket 时添加的标签，值为集群 ID，回收器只会删除带有当前集群标签的 bucket
+const BUCKET_TAG_CLUSTER_ID = "storage.qiniu.com/csi-cluster-id"
+
+// StorageClass 中引用 Provisioner Secret 的参数
+const (
+	PARAMETER_PROVISIONER_SECRET_NAME      = "csi.storage.k8s.io/provisioner-secret-name"
+	PARAMETER_PROVISIONER_SECRET_NAMESPACE = "csi.storage.k8s.io/provisioner-secret-namespace"
+)
+
+type kodoGarbageCollectorConfig struct {
+	// 两次回收之间的间隔，为 0 则不启动回收
+	interval time.Duration
+	// 资源被发现无主后，需要等待多久才会被删除
+	gracePeriod time.Duration
+	// 只报告无主资源，不删除
+	dryRun bool
+	// 与 csi-provisioner 的 --volume-name-prefix 保持一致
+	volumeNamePrefix string
+	// 当前集群的 ID，为空时使用 kube-system 命名空间的 UID
+	clusterId string
+}
+
+// kodoResourceClient 是回收器用到的 Kodo 接口
+type kodoResourceClient interface {
+	GetBuckets(ctx context.Context) ([]*qiniu.Bucket, error)
+	GetBucketTags(ctx context.Context, bucketName string) (map[string]string, error)
+	GetIAMUsers(ctx context.Context) ([]string, error)
+	GetIAMPolicies(ctx context.Context) ([]string, error)
+	RevokeIAMPolicyFromUser(ctx context.Context, userName string, policyNames []string) error
+	DeleteIAMPolicy(ctx context.Context, name string) error
+	DeleteIAMUser(ctx context.Context, userName string) error
+	CleanObjects(ctx context.Context, bucketName string) error
+	DeleteBucket(ctx context.Context, bucketName string) error
+}
+
+// kodoGarbageCollector 定期查找没有对应 PV 的 bucket、IAM 用户和 IAM 策略
+// 这些资源通常是 CreateVolume 或 DeleteVolume 失败后遗留下来的
+// 只有被当前集群的存储卷状态记录，或者 bucket 带有当前集群标签的资源才会被删除，其他集群或手动创建的同名资源不受影响
+// 回收策略为 Retain、回收策略未知或者仍在创建中的存储卷，其资源始终保留
+type kodoGarbageCollector struct {
+	config    kodoGarbageCollectorConfig
+	identity  string
+	clusterId string
+	client    kubernetes.Interface
+	store     *volumeStateStore
+	recorder  record.EventRecorder
+	matcher   *kodoResourceNameMatcher
+	newClient func(parameter *kodoStorageClassParameter) kodoResourceClient
+	// 无主资源第一次被发现的时间，key 为 accessKey@ucEndpoint/pvName
+	firstSeen map[string]time.Time
+}
+
+func newKodoGarbageCollector(identity string, config kodoGarbageCollectorConfig) (*kodoGarbageCollector, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("newKodoGarbageCollector: failed to create config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("newKodoGarbageCollector: failed to create client: %w", err)
+	}
+
+	clusterId, err := resolveClusterId(context.Background(), clientset, config.clusterId)
+	if err != nil {
+		return nil, fmt.Errorf("newKodoGarbageCollector: %w", err)
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: TypePluginKodo, Host: identity})
+
+	return &kodoGarbageCollector{
+		config:    config,
+		identity:  identity,
+		clusterId: clusterId,
+		client:    clientset,
+		store:     newVolumeStateStore(clientset, PodNamespace, KodoDriverName),
+		recorder:  recorder,
+		matcher:   newKodoResourceNameMatcher(config.volumeNamePrefix),
+		newClient: func(parameter *kodoStorageClassParameter) kodoResourceClient {
+			return qiniu.NewKodoClient(parameter.accessKey, parameter.secretKey, parameter.ucEndpoint, VERSION, COMMITID)
+		},
+		firstSeen: make(map[string]time.Time),
+	}, nil
+}
+
+// resolveClusterId 返回配置的集群 ID，未配置时使用 kube-system 命名空间的 UID，它在集群的生命周期内保持不变
+func resolveClusterId(ctx context.Context, client kubernetes.Interface, configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	namespace, err := client.CoreV1().Namespaces().Get(ctx, metav1.NamespaceSystem, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("resolveClusterId: get namespace %s error: %w", metav1.NamespaceSystem, err)
+	}
+	return string(namespace.UID), nil
+}
+
+// Run 只有 provisioner Deployment 中以 --controller 启动的插件会运行回收器，通过 Lease 选主保证同一时间只有一个回收器在工作
+func (gc *kodoGarbageCollector) Run() {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: GC_LEASE_NAME, Namespace: PodNamespace},
+		Client:     gc.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: gc.identity},
+	}
+	for {
+		leaderelection.RunOrDie(context.Background(), leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			ReleaseOnCancel: true,
+			LeaseDuration:   GC_LEASE_DURATION,
+			RenewDeadline:   GC_LEASE_RENEW_DEADLINE,
+			RetryPeriod:     GC_LEASE_RETRY_PERIOD,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: gc.runAsLeader,
+				OnStoppedLeading: func() {
+					log.Infof("kodoGarbageCollector: %s stopped leading", gc.identity)
+				},
+			},
+		})
+	}
+}
+
+func (gc *kodoGarbageCollector) runAsLeader(ctx context.Context) {
+	log.Infof("kodoGarbageCollector: %s started leading, interval: %s, grace period: %s, dry run: %t",
+		gc.identity, gc.config.interval, gc.config.gracePeriod, gc.config.dryRun)
+
+	// 刚成为 leader 时并不知道之前的 leader 何时发现了这些资源，重新开始计算等待时间
+	gc.firstSeen = make(map[string]time.Time)
+
+	ticker := time.NewTicker(gc.config.interval)
+	defer ticker.Stop()
+	for {
+		collectCtx, cancel := context.WithTimeout(ctx, GC_COLLECT_TIMEOUT)
+		gc.collect(collectCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type kodoAccount struct {
+	key          string
+	client       kodoResourceClient
+	storageClass *storagev1.StorageClass
+}
+
+// kodoVolumeResources 记录属于同一个 PV 的 Kodo 资源
+type kodoVolumeResources struct {
+	pvName    string
+	buckets   []string
+	iamUser   string
+	iamPolicy string
+	// 资源是否确定由当前集群创建，IAM 用户和策略无法添加标签，只能随存储卷状态或带有标签的 bucket 一起确认
+	owned bool
+}
+
+func (resources *kodoVolumeResources) String() string {
+	var descriptions []string
+	for _, bucketName := range resources.buckets {
+		descriptions = append(descriptions, "bucket "+bucketName)
+	}
+	if resources.iamUser != "" {
+		descriptions = append(descriptions, "IAM user "+resources.iamUser)
+	}
+	if resources.iamPolicy != "" {
+		descriptions = append(descriptions, "IAM policy "+resources.iamPolicy)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+func (gc *kodoGarbageCollector) collect(ctx context.Context) {
+	accounts, err := gc.listAccounts(ctx)
+	if err != nil {
+		log.Warnf("kodoGarbageCollector: %s", err)
+		return
+	}
+
+	// 先列出 Kodo 资源再列出 PV，避免将列出 PV 之后才创建的存储卷误判为无主资源
+	states, err := gc.store.List(ctx)
+	if err != nil {
+		log.Warnf("kodoGarbageCollector: %s", err)
+		return
+	}
+	candidates := make(map[*kodoAccount]map[string]*kodoVolumeResources, len(accounts))
+	for _, account := range accounts {
+		if resources, err := gc.listVolumeResources(ctx, account, states); err != nil {
+			log.Warnf("kodoGarbageCollector: list resources of storage class %s error: %s", account.storageClass.Name, err)
+		} else {
+			candidates[account] = resources
+		}
+	}
+	pvList, err := gc.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Warnf("kodoGarbageCollector: list persistent volumes error: %s", err)
+		return
+	}
+	pvNames := make(map[string]struct{}, len(pvList.Items))
+	for _, pv := range pvList.Items {
+		pvNames[pv.Name] = struct{}{}
+	}
+	gc.syncReclaimPolicies(ctx, states, pvList.Items)
+
+	now := time.Now()
+	firstSeen := make(map[string]time.Time)
+	for account, resourcesByPV := range candidates {
+		for pvName, resources := range resourcesByPV {
+			if _, exists := pvNames[pvName]; exists {
+				continue
+			} else if !resources.owned {
+				log.Debugf("kodoGarbageCollector: skip resources of volume %s not owned by cluster %s: %s", pvName, gc.clusterId, resources)
+				continue
+			}
+			key := account.key + "/" + pvName
+			seenAt, ok := gc.firstSeen[key]
+			if !ok {
+				seenAt = now
+			}
+			firstSeen[key] = seenAt
+			if !gc.handleOrphan(ctx, account, resources, now.Sub(seenAt)) {
+				delete(firstSeen, key)
+			}
+		}
+	}
+	gc.firstSeen = firstSeen
+}
+
+// syncReclaimPolicies 将 PV 当前的回收策略记录到存储卷状态中，PV 被删除后回收器只能依据状态中的记录判断是否回收
+func (gc *kodoGarbageCollector) syncReclaimPolicies(ctx context.Context, states []*volumeState, pvs []corev1.PersistentVolume) {
+	policies := make(map[string]corev1.PersistentVolumeReclaimPolicy, len(pvs))
+	for _, pv := range pvs {
+		policies[pv.Name] = pv.Spec.PersistentVolumeReclaimPolicy
+	}
+	for _, state := range states {
+		if policy, ok := policies[state.VolumeId]; ok && policy != state.ReclaimPolicy {
+			state.ReclaimPolicy = policy
+			if err := gc.store.Save(ctx, state); err != nil {
+				log.Warnf("kodoGarbageCollector: %s", err)
+			}
+		}
+	}
+}
+
+// isCollectable 判断存储卷状态记录的资源在 PV 被删除后是否可以回收
+// 回收策略为 Retain 的 PV 被手动删除时不会调用 DeleteVolume，资源需要保留；仍在创建中的存储卷可能还没有创建 PV
+func isCollectable(state *volumeState) bool {
+	return state.Phase == volumePhaseCreated && state.ReclaimPolicy == corev1.PersistentVolumeReclaimDelete
+}
+
+// listAccounts 从所有 Kodo StorageClass 引用的 Secret 中获取七牛账号，相同的账号只返回一次
+func (gc *kodoGarbageCollector) listAccounts(ctx context.Context) ([]*kodoAccount, error) {
+	storageClassList, err := gc.client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("kodoGarbageCollector.listAccounts: list storage classes error: %w", err)
+	}
+
+	var accounts []*kodoAccount
+	keys := make(map[string]struct{})
+	for i := range storageClassList.Items {
+		storageClass := &storageClassList.Items[i]
+		if storageClass.Provisioner != TypePluginKodo {
+			continue
+		}
+		secrets := make(map[string]string)
+		secretName := storageClass.Parameters[PARAMETER_PROVISIONER_SECRET_NAME]
+		secretNamespace := storageClass.Parameters[PARAMETER_PROVISIONER_SECRET_NAMESPACE]
+		if strings.Contains(secretName, "${") || strings.Contains(secretNamespace, "${") {
+			log.Warnf("kodoGarbageCollector.listAccounts: skip storage class %s with templated provisioner secret", storageClass.Name)
+			continue
+		} else if secretName != "" {
+			secret, err := gc.client.CoreV1().Secrets(secretNamespace).Get(ctx, secretName, metav1.GetOptions{})
+			if err != nil {
+				log.Warnf("kodoGarbageCollector.listAccounts: get secret %s/%s of storage class %s error: %s",
+					secretNamespace, secretName, storageClass.Name, err)
+				continue
+			}
+			for key, value := range secret.Data {
+				secrets[key] = string(value)
+			}
+		}
+		parameter, err := parseKodoStorageClassParameter("kodoGarbageCollector.listAccounts", storageClass.Parameters, secrets)
+		if err != nil {
+			log.Warnf("kodoGarbageCollector.listAccounts: skip storage class %s: %s", storageClass.Name, err)
+			continue
+		}
+		key := parameter.accessKey + "@" + parameter.ucEndpoint.String()
+		if _, exists := keys[key]; exists {
+			continue
+		}
+		keys[key] = struct{}{}
+		accounts = append(accounts, &kodoAccount{
+			key:          key,
+			client:       gc.newClient(parameter),
+			storageClass: storageClass,
+		})
+	}
+	return accounts, nil
+}
+
+// listVolumeResources 列出账号下所有符合 PV 命名规则，或者被存储卷状态记录为由插件创建的资源，按 PV 名称分组
+// 被当前集群可回收的存储卷状态记录，或者没有存储卷状态但 bucket 带有当前集群标签的资源组被标记为 owned
+func (gc *kodoGarbageCollector) listVolumeResources(ctx context.Context, account *kodoAccount, states []*volumeState) (map[string]*kodoVolumeResources, error) {
+	// 使用 bucketnametemplate 创建的 bucket 不符合命名规则，只能通过存储卷状态找到
+	createdBuckets := make(map[string]string, len(states))
+	statePVNames := make(map[string]bool, len(states))
+	for _, state := range states {
+		statePVNames[state.VolumeId] = isCollectable(state)
+		if bucketName, ok := state.getCheckpoint(CHECKPOINT_BUCKET_CREATED); ok {
+			createdBuckets[bucketName] = state.VolumeId
+		}
+	}
+
+	resourcesByPV := make(map[string]*kodoVolumeResources)
+	getResources := func(pvName string) *kodoVolumeResources {
+		resources, ok := resourcesByPV[pvName]
+		if !ok {
+			resources = &kodoVolumeResources{pvName: pvName}
+			resourcesByPV[pvName] = resources
+		}
+		return resources
+	}
+
+	buckets, err := account.client.GetBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		if pvName, ok := createdBuckets[bucket.Name]; ok {
+			resources := getResources(pvName)
+			resources.buckets = append(resources.buckets, bucket.Name)
+		} else if pvName, ok = gc.matcher.matchBucket(bucket.Name); ok {
+			resources := getResources(pvName)
+			resources.buckets = append(resources.buckets, bucket.Name)
+			if _, hasState := statePVNames[pvName]; hasState {
+				continue
+			}
+			tags, err := account.client.GetBucketTags(ctx, bucket.Name)
+			if err != nil {
+				log.Warnf("kodoGarbageCollector: get tags of bucket %s error: %s", bucket.Name, err)
+			} else if tags[BUCKET_TAG_CLUSTER_ID] == gc.clusterId {
+				resources.owned = true
+			}
+		}
+	}
+
+	iamUsers, err := account.client.GetIAMUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, iamUser := range iamUsers {
+		if pvName, ok := gc.matcher.matchIAMUser(iamUser); ok {
+			getResources(pvName).iamUser = iamUser
+		}
+	}
+
+	iamPolicies, err := account.client.GetIAMPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, iamPolicy := range iamPolicies {
+		if pvName, ok := gc.matcher.matchIAMPolicy(iamPolicy); ok {
+			getResources(pvName).iamPolicy = iamPolicy
+		}
+	}
+	for pvName, resources := range resourcesByPV {
+		if collectable, hasState := statePVNames[pvName]; hasState {
+			resources.owned = collectable
+		}
+	}
+	return resourcesByPV, nil
+}
+
+// handleOrphan 报告或删除无主资源，返回资源是否仍然存在
+func (gc *kodoGarbageCollector) handleOrphan(ctx context.Context, account *kodoAccount, resources *kodoVolumeResources, age time.Duration) bool {
+	if gc.config.dryRun {
+		log.Infof("kodoGarbageCollector: [dry run] found orphaned resources of volume %s: %s", resources.pvName, resources)
+		gc.recorder.Eventf(account.storageClass, corev1.EventTypeWarning, EVENT_REASON_ORPHANED_RESOURCES,
+			"[dry run] Found orphaned resources of volume %s: %s", resources.pvName, resources)
+		return true
+	} else if age < gc.config.gracePeriod {
+		log.Infof("kodoGarbageCollector: found orphaned resources of volume %s: %s, will be deleted after %s",
+			resources.pvName, resources, gc.config.gracePeriod-age)
+		gc.recorder.Eventf(account.storageClass, corev1.EventTypeWarning, EVENT_REASON_ORPHANED_RESOURCES,
+			"Found orphaned resources of volume %s: %s, will be deleted after %s", resources.pvName, resources, gc.config.gracePeriod-age)
+		return true
+	}
+
+	if err := gc.deleteResources(ctx, account.client, resources); err != nil {
+		log.Warnf("kodoGarbageCollector: %s", err)
+		gc.recorder.Eventf(account.storageClass, corev1.EventTypeWarning, EVENT_REASON_ORPHANED_RESOURCES_DELETE_ERROR,
+			"Failed to delete orphaned resources of volume %s: %s", resources.pvName, err)
+		return true
+	}
+	log.Infof("kodoGarbageCollector: orphaned resources of volume %s are deleted: %s", resources.pvName, resources)
+	gc.recorder.Eventf(account.storageClass, corev1.EventTypeNormal, EVENT_REASON_ORPHANED_RESOURCES_DELETED,
+		"Deleted orphaned resources of volume %s: %s", resources.pvName, resources)
+	return false
+}
+
+// deleteResources 按照 DeleteVolume 相同的顺序删除资源，最后删除存储卷状态
+func (gc *kodoGarbageCollector) deleteResources(ctx context.Context, client kodoResourceClient, resources *kodoVolumeResources) error {
+	if resources.iamUser != "" && resources.iamPolicy != "" {
+		// 策略可能尚未授权给用户，撤销失败不影响后续的删除
+		if err := client.RevokeIAMPolicyFromUser(ctx, resources.iamUser, []string{resources.iamPolicy}); err != nil {
+			log.Warnf("kodoGarbageCollector.deleteResources: revoke IAM policy %s from %s error: %s", resources.iamPolicy, resources.iamUser, err)
+		}
+	}
+	if resources.iamPolicy != "" {
+		if err := client.DeleteIAMPolicy(ctx, resources.iamPolicy); err != nil {
+			return fmt.Errorf("kodoGarbageCollector.deleteResources: delete IAM policy %s error: %w", resources.iamPolicy, err)
+		}
+	}
+	if resources.iamUser != "" {
+		if err := client.DeleteIAMUser(ctx, resources.iamUser); err != nil {
+			return fmt.Errorf("kodoGarbageCollector.deleteResources: delete IAM user %s error: %w", resources.iamUser, err)
+		}
+	}
+	for _, bucketName := range resources.buckets {
+		if err := client.CleanObjects(ctx, bucketName); err != nil {
+			return fmt.Errorf("kodoGarbageCollector.deleteResources: clean objects from %s error: %w", bucketName, err)
+		} else if err = client.DeleteBucket(ctx, bucketName); err != nil {
+			return fmt.Errorf("kodoGarbageCollector.deleteResources: delete bucket %s error: %w", bucketName, err)
+		}
+	}
+	return gc.store.Delete(ctx, resources.pvName)
+}
+
+const uuidPattern = `([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})`
+
+// kodoResourceNameMatcher 根据 PV 的命名规则识别资源对应的 PV 名称
+// csi-provisioner 创建的 PV 名称为 <prefix>-<PVC UID>，IAM 用户与 PV 同名，IAM 策略为去掉短划线的 PV 名称
+// bucket 与 PV 同名，或者为早期版本使用的 <PV 名称>-<16 位随机字符>
+type kodoResourceNameMatcher struct {
+	prefix                                          string
+	bucketPattern, iamUserPattern, iamPolicyPattern *regexp.Regexp
+}
+
+func newKodoResourceNameMatcher(prefix string) *kodoResourceNameMatcher {
+	quotedPrefix := regexp.QuoteMeta(prefix)
+	return &kodoResourceNameMatcher{
+		prefix:           prefix,
+		bucketPattern:    regexp.MustCompile(`^` + regexp.QuoteMeta(strings.ToLower(prefix)) + `-` + uuidPattern + `(-[a-z0-9]{16})?$`),
+		iamUserPattern:   regexp.MustCompile(`^` + quotedPrefix + `-` + uuidPattern + `$`),
+		iamPolicyPattern: regexp.MustCompile(`^` + regexp.QuoteMeta(normalizePolicyName(prefix)) + `([0-9a-f]{32})$`),
+	}
+}
+
+func (matcher *kodoResourceNameMatcher) matchBucket(bucketName string) (string, bool) {
+	if matches := matcher.bucketPattern.FindStringSubmatch(bucketName); matches != nil {
+		return matcher.prefix + "-" + matches[1], true
+	}
+	return "", false
+}
+
+func (matcher *kodoResourceNameMatcher) matchIAMUser(userName string) (string, bool) {
+	if matches := matcher.iamUserPattern.FindStringSubmatch(userName); matches != nil {
+		return matcher.prefix + "-" + matches[1], true
+	}
+	return "", false
+}
+
+func (matcher *kodoResourceNameMatcher) matchIAMPolicy(policyName string) (string, bool) {
+	if matches := matcher.iamPolicyPattern.FindStringSubmatch(policyName); matches != nil {
+		uid := matches[1]
+		return fmt.Sprintf("%s-%s-%s-%s-%s-%s", matcher.prefix, uid[0:8], uid[8:12], uid[12:16], uid[16:20], uid[20:32]), true
+	}
+	return "", false
+}
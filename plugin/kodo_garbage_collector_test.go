@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestKodoResourceNameMatcher(t *testing.T) {
+	matcher := newKodoResourceNameMatcher("kodo")
+	const pvName = "kodo-0b3f5a5c-64e1-4a9f-9c1e-2d2a7f1c9e10"
+
+	name, ok := matcher.matchBucket(pvName)
+	assert.True(t, ok)
+	assert.Equal(t, pvName, name)
+
+	// 早期版本创建的 bucket 带有随机后缀
+	name, ok = matcher.matchBucket(pvName + "-abcdefghij012345")
+	assert.True(t, ok)
+	assert.Equal(t, pvName, name)
+
+	name, ok = matcher.matchIAMUser(pvName)
+	assert.True(t, ok)
+	assert.Equal(t, pvName, name)
+
+	name, ok = matcher.matchIAMPolicy(normalizePolicyName(pvName))
+	assert.True(t, ok)
+	assert.Equal(t, pvName, name)
+
+	for _, name := range []string{
+		"kodo-csi-pv",
+		"my-bucket",
+		"kodofs-0b3f5a5c-64e1-4a9f-9c1e-2d2a7f1c9e10",
+		pvName + "-abc",
+	} {
+		_, ok = matcher.matchBucket(name)
+		assert.False(t, ok, name)
+		_, ok = matcher.matchIAMUser(name)
+		assert.False(t, ok, name)
+		_, ok = matcher.matchIAMPolicy(normalizePolicyName(name))
+		assert.False(t, ok, name)
+	}
+}
+
+func TestKodoVolumeResourcesString(t *testing.T) {
+	resources := &kodoVolumeResources{
+		pvName:    "kodo-test",
+		buckets:   []string{"kodo-test"},
+		iamUser:   "kodo-test",
+		iamPolicy: "kodotest",
+	}
+	assert.Equal(t, "bucket kodo-test, IAM user kodo-test, IAM policy kodotest", resources.String())
+	assert.Equal(t, "IAM policy kodotest", (&kodoVolumeResources{pvName: "kodo-test", iamPolicy: "kodotest"}).String())
+}
+
+// fakeKodoResourceClient 在内存中模拟一个七牛账号下的 bucket 和 IAM 资源
+type fakeKodoResourceClient struct {
+	buckets     map[string]map[string]string
+	iamUsers    []string
+	iamPolicies []string
+	deleted     []string
+}
+
+func (client *fakeKodoResourceClient) GetBuckets(ctx context.Context) ([]*qiniu.Bucket, error) {
+	var buckets []*qiniu.Bucket
+	for name := range client.buckets {
+		buckets = append(buckets, &qiniu.Bucket{Name: name})
+	}
+	return buckets, nil
+}
+
+func (client *fakeKodoResourceClient) GetBucketTags(ctx context.Context, bucketName string) (map[string]string, error) {
+	return client.buckets[bucketName], nil
+}
+
+func (client *fakeKodoResourceClient) GetIAMUsers(ctx context.Context) ([]string, error) {
+	return client.iamUsers, nil
+}
+
+func (client *fakeKodoResourceClient) GetIAMPolicies(ctx context.Context) ([]string, error) {
+	return client.iamPolicies, nil
+}
+
+func (client *fakeKodoResourceClient) RevokeIAMPolicyFromUser(ctx context.Context, userName string, policyNames []string) error {
+	return nil
+}
+
+func (client *fakeKodoResourceClient) DeleteIAMPolicy(ctx context.Context, name string) error {
+	client.deleted = append(client.deleted, "IAM policy "+name)
+	return nil
+}
+
+func (client *fakeKodoResourceClient) DeleteIAMUser(ctx context.Context, userName string) error {
+	client.deleted = append(client.deleted, "IAM user "+userName)
+	return nil
+}
+
+func (client *fakeKodoResourceClient) CleanObjects(ctx context.Context, bucketName string) error {
+	return nil
+}
+
+func (client *fakeKodoResourceClient) DeleteBucket(ctx context.Context, bucketName string) error {
+	client.deleted = append(client.deleted, "bucket "+bucketName)
+	delete(client.buckets, bucketName)
+	return nil
+}
+
+func newTestKodoGarbageCollector(kodoClient *fakeKodoResourceClient, config kodoGarbageCollectorConfig, objects ...runtime.Object) *kodoGarbageCollector {
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "kodo"},
+		Provisioner: TypePluginKodo,
+		Parameters: map[string]string{
+			FIELD_ACCESS_KEY:  "ak",
+			FIELD_SECRET_KEY:  "sk",
+			FIELD_UC_ENDPOINT: "https://uc.qbox.me",
+		},
+	}
+	clientset := fake.NewSimpleClientset(append(objects, storageClass)...)
+	return &kodoGarbageCollector{
+		config:    config,
+		identity:  "node-1",
+		clusterId: "cluster-1",
+		client:    clientset,
+		store:     newVolumeStateStore(clientset, "kube-system", KodoDriverName),
+		recorder:  record.NewFakeRecorder(100),
+		matcher:   newKodoResourceNameMatcher(config.volumeNamePrefix),
+		newClient: func(*kodoStorageClassParameter) kodoResourceClient {
+			return kodoClient
+		},
+		firstSeen: make(map[string]time.Time),
+	}
+}
+
+func TestKodoGarbageCollector_Collect(t *testing.T) {
+	ctx := context.Background()
+	const (
+		existingPV = "kodo-00000000-0000-0000-0000-000000000001"
+		taggedPV   = "kodo-00000000-0000-0000-0000-000000000002"
+		foreignPV  = "kodo-00000000-0000-0000-0000-000000000003"
+		untaggedPV = "kodo-00000000-0000-0000-0000-000000000004"
+		statePV    = "kodo-00000000-0000-0000-0000-000000000005"
+		retainPV   = "kodo-00000000-0000-0000-0000-000000000006"
+		creatingPV = "kodo-00000000-0000-0000-0000-000000000007"
+		unknownPV  = "kodo-00000000-0000-0000-0000-000000000008"
+	)
+	kodoClient := &fakeKodoResourceClient{
+		buckets: map[string]map[string]string{
+			existingPV: {BUCKET_TAG_CLUSTER_ID: "cluster-1"},
+			taggedPV:   {BUCKET_TAG_CLUSTER_ID: "cluster-1"},
+			foreignPV:  {BUCKET_TAG_CLUSTER_ID: "cluster-2"},
+			untaggedPV: {},
+			retainPV:   {BUCKET_TAG_CLUSTER_ID: "cluster-1"},
+			creatingPV: {BUCKET_TAG_CLUSTER_ID: "cluster-1"},
+			unknownPV:  {BUCKET_TAG_CLUSTER_ID: "cluster-1"},
+			// 使用 bucketnametemplate 创建、尚未添加标签的 bucket，只被存储卷状态记录
+			"custom-bucket": {},
+		},
+		iamUsers:    []string{taggedPV, foreignPV, retainPV, creatingPV},
+		iamPolicies: []string{normalizePolicyName(taggedPV), normalizePolicyName(retainPV)},
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: existingPV},
+		Spec:       corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain},
+	}
+	gc := newTestKodoGarbageCollector(kodoClient, kodoGarbageCollectorConfig{volumeNamePrefix: "kodo"}, pv)
+	saveState := func(pvName string, phase volumePhase, policy corev1.PersistentVolumeReclaimPolicy) *volumeState {
+		state := newVolumeState(pvName)
+		state.Phase = phase
+		state.ReclaimPolicy = policy
+		assert.NoError(t, gc.store.Save(ctx, state))
+		return state
+	}
+	state := saveState(statePV, volumePhaseCreated, corev1.PersistentVolumeReclaimDelete)
+	assert.NoError(t, gc.store.SaveCheckpoint(ctx, state, CHECKPOINT_BUCKET_CREATED, "custom-bucket"))
+	saveState(existingPV, volumePhaseCreated, corev1.PersistentVolumeReclaimDelete)
+	// 回收策略为 Retain、仍在创建中或回收策略未知的存储卷，即使 bucket 带有当前集群的标签也不会被回收
+	saveState(retainPV, volumePhaseCreated, corev1.PersistentVolumeReclaimRetain)
+	saveState(creatingPV, volumePhaseCreating, corev1.PersistentVolumeReclaimDelete)
+	saveState(unknownPV, volumePhaseCreated, "")
+
+	gc.collect(ctx)
+	assert.ElementsMatch(t, []string{
+		"IAM policy " + normalizePolicyName(taggedPV),
+		"IAM user " + taggedPV,
+		"bucket " + taggedPV,
+		"bucket custom-bucket",
+	}, kodoClient.deleted)
+	assert.Contains(t, kodoClient.buckets, existingPV)
+	assert.Contains(t, kodoClient.buckets, foreignPV)
+	assert.Contains(t, kodoClient.buckets, untaggedPV)
+	assert.Contains(t, kodoClient.buckets, retainPV)
+	assert.Contains(t, kodoClient.buckets, creatingPV)
+	assert.Contains(t, kodoClient.buckets, unknownPV)
+
+	saved, err := gc.store.Get(ctx, statePV)
+	assert.NoError(t, err)
+	assert.Nil(t, saved)
+	for _, pvName := range []string{retainPV, creatingPV, unknownPV} {
+		saved, err = gc.store.Get(ctx, pvName)
+		assert.NoError(t, err)
+		assert.NotNil(t, saved, pvName)
+	}
+
+	// 回收策略从 PV 同步到存储卷状态
+	saved, err = gc.store.Get(ctx, existingPV)
+	assert.NoError(t, err)
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, saved.ReclaimPolicy)
+}
+
+func TestKodoGarbageCollector_HandleOrphan(t *testing.T) {
+	ctx := context.Background()
+	const pvName = "kodo-00000000-0000-0000-0000-000000000001"
+	newResources := func() *kodoVolumeResources {
+		return &kodoVolumeResources{pvName: pvName, buckets: []string{pvName}, owned: true}
+	}
+
+	// dry run 只报告不删除
+	kodoClient := &fakeKodoResourceClient{buckets: map[string]map[string]string{pvName: {}}}
+	gc := newTestKodoGarbageCollector(kodoClient, kodoGarbageCollectorConfig{dryRun: true, volumeNamePrefix: "kodo"})
+	account := &kodoAccount{key: "ak@uc", client: kodoClient, storageClass: &storagev1.StorageClass{}}
+	assert.True(t, gc.handleOrphan(ctx, account, newResources(), time.Hour))
+	assert.Empty(t, kodoClient.deleted)
+
+	// 宽限期内不删除
+	gc = newTestKodoGarbageCollector(kodoClient, kodoGarbageCollectorConfig{gracePeriod: time.Hour, volumeNamePrefix: "kodo"})
+	assert.True(t, gc.handleOrphan(ctx, account, newResources(), time.Minute))
+	assert.Empty(t, kodoClient.deleted)
+
+	assert.False(t, gc.handleOrphan(ctx, account, newResources(), 2*time.Hour))
+	assert.Equal(t, []string{"bucket " + pvName}, kodoClient.deleted)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	MOUNT_OPTION_UID         = "uid"
+	MOUNT_OPTION_GID         = "gid"
+	MOUNT_OPTION_UMASK       = "umask"
+	MOUNT_OPTION_DIR_PERMS   = "dir-perms"
+	MOUNT_OPTION_FILE_PERMS  = "file-perms"
+	MOUNT_OPTION_ALLOW_OTHER = "allow-other"
+
+	// 设置了 fsGroup 但没有设置 umask 时使用，使同组的用户可以写入
+	FS_GROUP_UMASK = "002"
+)
+
+// kodoMountOptions PV 的 mountOptions 和 Pod 的 fsGroup 对应的 rclone 挂载参数
+type kodoMountOptions struct {
+	uid, gid                   *uint32
+	umask, dirPerms, filePerms string
+	allowOther                 bool
+}
+
+// parseKodoMountOptions 解析 VolumeCapability 中的挂载选项，只允许 uid、gid、umask、dir-perms、file-perms 和 allow-other
+// 选项可以写作 uid=1000、--uid=1000 或 allow_other，一项中可以包含多个以逗号分隔的选项
+// volume_mount_group 即 Pod 的 fsGroup，没有在挂载选项中指定 gid 时作为 gid 使用，并允许其他用户访问挂载点
+func parseKodoMountOptions(functionName string, capability *csi.VolumeCapability) (*kodoMountOptions, error) {
+	var options kodoMountOptions
+	for _, flag := range capability.GetMount().GetMountFlags() {
+		for _, option := range strings.Split(flag, ",") {
+			option = strings.TrimSpace(option)
+			if option == "" {
+				continue
+			}
+			key, value, hasValue := strings.Cut(strings.TrimPrefix(option, "--"), "=")
+			key = strings.ReplaceAll(strings.ToLower(key), "_", "-")
+			value = strings.TrimSpace(value)
+			if (key == MOUNT_OPTION_ALLOW_OTHER) == hasValue {
+				return nil, status.Errorf(codes.InvalidArgument, "%s: invalid mount option %s", functionName, option)
+			}
+			switch key {
+			case MOUNT_OPTION_UID, MOUNT_OPTION_GID:
+				id, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "%s: invalid mount option %s: %s", functionName, option, err)
+				}
+				id32 := uint32(id)
+				if key == MOUNT_OPTION_UID {
+					options.uid = &id32
+				} else {
+					options.gid = &id32
+				}
+			case MOUNT_OPTION_UMASK, MOUNT_OPTION_DIR_PERMS, MOUNT_OPTION_FILE_PERMS:
+				if _, err := strconv.ParseUint(value, 8, 32); err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "%s: invalid mount option %s: %s", functionName, option, err)
+				}
+				if key == MOUNT_OPTION_UMASK {
+					options.umask = value
+				} else if key == MOUNT_OPTION_DIR_PERMS {
+					options.dirPerms = value
+				} else {
+					options.filePerms = value
+				}
+			case MOUNT_OPTION_ALLOW_OTHER:
+				options.allowOther = true
+			default:
+				return nil, status.Errorf(codes.InvalidArgument, "%s: unsupported mount option %s", functionName, option)
+			}
+		}
+	}
+
+	if group := capability.GetMount().GetVolumeMountGroup(); group != "" {
+		gid, err := strconv.ParseUint(group, 10, 32)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s: invalid volume mount group %s: %s", functionName, group, err)
+		}
+		if options.gid == nil {
+			gid32 := uint32(gid)
+			options.gid = &gid32
+		}
+		if options.umask == "" {
+			options.umask = FS_GROUP_UMASK
+		}
+		// FUSE 挂载点默认只允许挂载者访问，Pod 中的非 root 用户需要 allow-other
+		options.allowOther = true
+	}
+	return &options, nil
+}
+
+// checkStagedMountGroup 检查 NodeStageVolume 挂载 stagingPath 时使用的 gid 是否与 Pod 的 fsGroup 一致
+// 同一节点上的 Pod 共享 stagingPath 上的挂载，gid 只在第一次挂载时设置，fsGroup 不同的 Pod 无法写入，返回 FailedPrecondition
+func checkStagedMountGroup(functionName, stagingPath string, capability *csi.VolumeCapability) error {
+	if capability.GetMount().GetVolumeMountGroup() == "" {
+		return nil
+	}
+	options, err := parseKodoMountOptions(functionName, capability)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(stagingPath)
+	if err != nil {
+		return fmt.Errorf("%s: stat staging path %s error: %w", functionName, stagingPath, err)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Gid != *options.gid {
+		return status.Errorf(codes.FailedPrecondition, "%s: volume is staged on %s with gid %d, which differs from gid %d required by the pod",
+			functionName, stagingPath, stat.Gid, *options.gid)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseKodoMountOptions(t *testing.T) {
+	capability := func(group string, flags ...string) *csi.VolumeCapability {
+		return &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{MountFlags: flags, VolumeMountGroup: group},
+		}}
+	}
+
+	options, err := parseKodoMountOptions("NodePublishVolume", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &kodoMountOptions{}, options)
+
+	options, err = parseKodoMountOptions("NodePublishVolume", capability("", "uid=1000,gid=2000", "--umask=022", "dir_perms=0775", "file-perms=0664", "allow_other"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1000), *options.uid)
+	assert.Equal(t, uint32(2000), *options.gid)
+	assert.Equal(t, "022", options.umask)
+	assert.Equal(t, "0775", options.dirPerms)
+	assert.Equal(t, "0664", options.filePerms)
+	assert.True(t, options.allowOther)
+
+	// fsGroup 作为 gid，并且允许其他用户访问
+	options, err = parseKodoMountOptions("NodePublishVolume", capability("3000"))
+	assert.NoError(t, err)
+	assert.Nil(t, options.uid)
+	assert.Equal(t, uint32(3000), *options.gid)
+	assert.Equal(t, FS_GROUP_UMASK, options.umask)
+	assert.True(t, options.allowOther)
+
+	// 挂载选项中的 gid 优先
+	options, err = parseKodoMountOptions("NodePublishVolume", capability("3000", "gid=2000", "umask=027"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2000), *options.gid)
+	assert.Equal(t, "027", options.umask)
+
+	for _, c := range []*csi.VolumeCapability{
+		capability("", "uid=root"),
+		capability("", "umask=999"),
+		capability("", "allow-other=true"),
+		capability("", "uid"),
+		capability("", "exec"),
+		capability("users"),
+	} {
+		_, err = parseKodoMountOptions("NodePublishVolume", c)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), c.String())
+	}
+}
+
+func TestCheckStagedMountGroup(t *testing.T) {
+	stagingPath := t.TempDir()
+	capability := func(group string, flags ...string) *csi.VolumeCapability {
+		return &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{MountFlags: flags, VolumeMountGroup: group},
+		}}
+	}
+	gid := os.Getgid()
+
+	// Pod 没有设置 fsGroup
+	assert.NoError(t, checkStagedMountGroup("NodePublishVolume", stagingPath, capability("")))
+	assert.NoError(t, checkStagedMountGroup("NodePublishVolume", stagingPath, capability(strconv.Itoa(gid))))
+
+	err := checkStagedMountGroup("NodePublishVolume", stagingPath, capability(strconv.Itoa(gid+1)))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// 挂载选项中指定的 gid 优先于 fsGroup
+	assert.NoError(t, checkStagedMountGroup("NodePublishVolume", stagingPath, capability(strconv.Itoa(gid+1), "gid="+strconv.Itoa(gid))))
+}
@@ -4,23 +4,70 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
+	"github.com/qiniu/kubernetes-csi-driver/bindmount"
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	k8smount "k8s.io/utils/mount"
 )
 
+// bucket 的统计数据更新并不及时，缓存一段时间以避免 kubelet 频繁查询
+const BUCKET_USAGE_CACHE_TTL = 10 * time.Minute
+
+// 卸载时没有确认缓存中的文件已经上传，缓存保留在节点上
+const EVENT_REASON_UNFLUSHED_CACHE_KEPT = "UnflushedCacheKept"
+
+type cachedBucketUsage struct {
+	usage     *qiniu.BucketUsage
+	updatedAt time.Time
+}
+
 type kodoNodeServer struct {
+	nodeID     string
 	k8smounter k8smount.Interface
+	store      *volumeStateStore
+	nodeStore  *nodeStateStore
+	usageLock  sync.Mutex
+	usageCache map[string]*cachedBucketUsage
+	recorder   record.EventRecorder
 	*csicommon.DefaultNodeServer
 }
 
-func newKodoNodeServer(d *csicommon.CSIDriver) csi.NodeServer {
+func newKodoNodeServer(d *csicommon.CSIDriver, nodeID string) csi.NodeServer {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		log.Fatalf("newKodoNodeServer: failed to create config: %v", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatalf("newKodoNodeServer: failed to create client: %v", err)
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+
 	return &kodoNodeServer{
+		nodeID:            nodeID,
 		k8smounter:        k8smount.New(""),
+		store:             newVolumeStateStore(clientset, PodNamespace, KodoDriverName),
+		nodeStore:         newNodeStateStore(clientset, PodNamespace, KodoDriverName),
+		usageCache:        make(map[string]*cachedBucketUsage),
+		recorder:          broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: TypePluginKodo, Host: nodeID}),
 		DefaultNodeServer: csicommon.NewDefaultNodeServer(d),
 	}
 }
@@ -32,26 +79,116 @@ func (server *kodoNodeServer) NodePublishVolume(ctx context.Context, req *csi.No
 	}
 	log.Infof("NodePublishVolume: starting mount kodo volume %s to path: %s", req.GetVolumeId(), mountPath)
 
-	parameter, err := parseKodoPvParameter("NodePublishVolume", req.GetVolumeContext(), req.GetSecrets())
+	// Pod 中设置了 readOnly 或者访问模式只允许读取时，以只读方式挂载
+	readOnly := req.GetReadonly() || isReadOnlyAccessMode(req.GetVolumeCapability())
+	// 每个 Pod 使用 subdir 下由 subdirTemplate 展开得到的子目录
+	podSubDir, err := getPodSubDir("NodePublishVolume", req.GetVolumeContext())
 	if err != nil {
 		return nil, err
 	}
+	stagingPath := req.GetStagingTargetPath()
+	if staged, err := isStaged(stagingPath, FuseTypeKodo); err != nil {
+		return nil, wrapStatusError(err, "NodePublishVolume")
+	} else if staged {
+		if err = checkStagedMountGroup("NodePublishVolume", stagingPath, req.GetVolumeCapability()); err != nil {
+			return nil, err
+		}
+		sourcePath := stagingPath
+		if podSubDir != "" {
+			// 通过 rclone 创建子目录，第一次挂载时子目录还不存在
+			sourcePath = filepath.Join(stagingPath, podSubDir)
+			if err = os.MkdirAll(sourcePath, 0755); err != nil {
+				return nil, fmt.Errorf("NodePublishVolume: create subdir %s of kodo volume %s error: %w", podSubDir, req.GetVolumeId(), err)
+			}
+		}
+		if err = bindMount(server.k8smounter, "NodePublishVolume", sourcePath, mountPath, FuseTypeKodo, readOnly); err != nil {
+			return nil, err
+		}
+		log.Infof("NodePublishVolume: kodo volume %s staged on %s is bind mounted on %s", req.GetVolumeId(), sourcePath, mountPath)
+	} else {
+		// 没有在 NodeStageVolume 中挂载的存储卷，直接挂载到目标路径
+		var parameter *kodoPvParameter
+		if isEphemeralVolume(req.GetVolumeContext()) {
+			// Pod 中声明的临时存储卷没有 PV，也没有存储卷状态，kubelet 不会调用 NodeStageVolume
+			log.Infof("NodePublishVolume: kodo volume %s is an ephemeral volume", req.GetVolumeId())
+			parameter, err = parseKodoEphemeralParameter("NodePublishVolume", req.GetVolumeContext(), req.GetSecrets())
+		} else {
+			parameter, err = server.loadKodoPvParameter(ctx, "NodePublishVolume", req.GetVolumeId(), req.GetVolumeContext(), req.GetSecrets())
+		}
+		if err != nil {
+			return nil, err
+		}
+		if readOnly {
+			parameter.readOnly = true
+		}
+		if podSubDir != "" {
+			parameter.subDir = path.Join(parameter.subDir, podSubDir)
+		}
+		mountOptions, err := parseKodoMountOptions("NodePublishVolume", req.GetVolumeCapability())
+		if err != nil {
+			return nil, err
+		}
+		if mounted, err := prepareMountPoint("NodePublishVolume", mountPath, FuseTypeKodo); err != nil {
+			return nil, err
+		} else if mounted {
+			log.Infof("NodePublishVolume: kodo volume %s is already mounted on %s", req.GetVolumeId(), mountPath)
+		} else if err = server.mountKodoVolume(req.GetVolumeId(), mountPath, parameter, mountOptions); err != nil {
+			return nil, wrapStatusError(err, "NodePublishVolume: failed to mount kodo volume %s to %s", req.GetVolumeId(), mountPath)
+		} else {
+			log.Infof("NodePublishVolume: kodo volume %s is mounted on %s", req.GetVolumeId(), mountPath)
+		}
+	}
+	if err := server.nodeStore.AddPublishedVolume(ctx, server.nodeID, req.GetVolumeId(), mountPath); err != nil {
+		log.Warnf("NodePublishVolume: failed to record kodo volume %s is published: %s", req.GetVolumeId(), err)
+	}
+	return &csi.NodePublishVolumeResponse{}, nil
+}
 
-	if err = ensureDirectoryCreated(mountPath); err != nil {
-		return nil, fmt.Errorf("NodePublishVolume: create mount path %s error: %w", mountPath, err)
+// getPodSubDir 展开存储卷参数中的 subdirTemplate，没有设置时返回空字符串
+func getPodSubDir(functionName string, volumeContext map[string]string) (string, error) {
+	for key, value := range volumeContext {
+		if strings.ToLower(key) != FIELD_SUB_DIR_TEMPLATE || strings.TrimSpace(value) == "" {
+			continue
+		}
+		subDir, err := expandSubDirTemplate(strings.TrimSpace(value), volumeContext)
+		if err != nil {
+			return "", status.Errorf(codes.InvalidArgument, "%s: %s", functionName, err)
+		}
+		return subDir, nil
 	}
-	if err = mountKodo(req.GetVolumeId(), mountPath, parameter.subDir, parameter.accessKey, parameter.secretKey,
+	return "", nil
+}
+
+// loadKodoPvParameter 解析 PV 的参数
+// PV 的参数创建后无法修改，扩容后的 vfsdiskspacetotalsize 只记录在存储卷状态中
+func (server *kodoNodeServer) loadKodoPvParameter(ctx context.Context, functionName, volumeId string, volumeContext, secrets map[string]string) (*kodoPvParameter, error) {
+	parameter, err := parseKodoPvParameter(functionName, volumeContext, secrets)
+	if err != nil {
+		return nil, err
+	}
+	if state, err := server.store.Get(ctx, volumeId); err != nil {
+		log.Warnf("%s: failed to get state of volume %s: %s", functionName, volumeId, err)
+	} else if state != nil && state.Phase == volumePhaseCreated {
+		if value, ok := state.VolumeContext[FIELD_VFS_DISK_SPACE_TOTAL_SIZE]; ok {
+			if size, err := parseUint(value); err != nil {
+				log.Warnf("%s: invalid %s of volume %s: %s", functionName, FIELD_VFS_DISK_SPACE_TOTAL_SIZE, volumeId, err)
+			} else {
+				parameter.vfsDiskSpaceTotalSize = &size
+			}
+		}
+	}
+	return parameter, nil
+}
+
+func (server *kodoNodeServer) mountKodoVolume(volumeId, mountPath string, parameter *kodoPvParameter, mountOptions *kodoMountOptions) error {
+	return mountKodo(volumeId, mountPath, parameter.subDir, parameter.accessKey, parameter.secretKey,
 		parameter.bucketID, parameter.s3Region, parameter.s3Endpoint.String(), parameter.storageClass, parameter.s3ForcePathStyle,
 		parameter.vfsCacheMode, parameter.dirCacheDuration, parameter.bufferSize,
 		parameter.vfsCacheMaxAge, parameter.vfsCachePollInterval, parameter.vfsWriteBack, parameter.vfsCacheMaxSize,
 		parameter.vfsReadAhead, parameter.vfsFastFingerprint, parameter.vfsReadChunkSize, parameter.vfsReadChunkSizeLimit,
 		parameter.noCheckSum, parameter.noModTime, parameter.noSeek, parameter.readOnly,
 		parameter.vfsReadWait, parameter.vfsWriteWait, parameter.transfers, parameter.vfsDiskSpaceTotalSize, parameter.writeBackCache,
-		parameter.uploadCutoff, parameter.uploadChunkSize, parameter.uploadConcurrency, parameter.debugHttp, parameter.debugFuse); err != nil {
-		return nil, fmt.Errorf("NodePublishVolume: failed to to mount kodo to %s: %w", mountPath, err)
-	}
-	log.Infof("NodePublishVolume: kodo volume %s is mounted on %s", req.GetVolumeId(), mountPath)
-	return &csi.NodePublishVolumeResponse{}, nil
+		parameter.uploadCutoff, parameter.uploadChunkSize, parameter.uploadConcurrency, parameter.debugHttp, parameter.debugFuse, mountOptions)
 }
 
 func (server *kodoNodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
@@ -60,32 +197,202 @@ func (server *kodoNodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.
 		return nil, errors.New("NodeUnpublishVolume: mountPath is empty")
 	}
 	log.Infof("NodeUnpublishVolume: starting umount kodo volume from path: %s", mountPath)
-	mounted, err := isKodoMounted(mountPath)
-	if err != nil {
-		log.Warnf("NodeUnpublishVolume: failed to detect mount point: %s", err)
-	} else if !mounted {
-		log.Warnf("NodeUnpublishVolume: mountPath is not mounted by kodo")
-	} else if err = umount(mountPath); err != nil {
-		return nil, fmt.Errorf("NodeUnpublishVolume: failed to unmount kodo: %w", err)
-	} else {
-		log.Infof("NodeUnpublishVolume: umounted kodo volume from path: %s", mountPath)
+	// 绑定挂载的目标路径只需卸载绑定，FUSE 挂载在 NodeUnstageVolume 中卸载
+	if binds, err := bindmount.FindBindMounts(mountPath, FuseTypeKodo); err != nil {
+		return nil, fmt.Errorf("NodeUnpublishVolume: %w", err)
+	} else if len(binds) > 0 {
+		if err = unbindMount(server.k8smounter, "NodeUnpublishVolume", mountPath); err != nil {
+			return nil, err
+		}
+	} else if flushed, err := unmountKodo(req.GetVolumeId(), mountPath); err != nil {
+		return nil, wrapStatusError(err, "NodeUnpublishVolume")
+	} else if !flushed {
+		server.recordUnflushedCache(req.GetVolumeId())
 	}
-	if err = cleanAfterKodoUmount(req.VolumeId, mountPath); err != nil {
-		log.Warnf("NodeUnpublishVolume: failed to clean kodo volume cache and log files: %s", err)
-	} else {
-		log.Infof("NodeUnpublishVolume: kodo volume cache and log files are cleaned")
+	log.Infof("NodeUnpublishVolume: umounted kodo volume from path: %s", mountPath)
+	if err := server.nodeStore.RemovePublishedVolume(ctx, server.nodeID, req.GetVolumeId(), mountPath); err != nil {
+		log.Warnf("NodeUnpublishVolume: failed to record kodo volume %s is unpublished: %s", req.GetVolumeId(), err)
 	}
+
+	server.usageLock.Lock()
+	delete(server.usageCache, req.GetVolumeId())
+	server.usageLock.Unlock()
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
+// NodeStageVolume 在每个节点上只挂载一次存储卷，NodePublishVolume 将其绑定挂载到每个 Pod 的目标路径
 func (server *kodoNodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	volumeId := req.GetVolumeId()
+	stagingPath := req.GetStagingTargetPath()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeStageVolume: volume id is empty")
+	} else if stagingPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeStageVolume: staging path is empty")
+	}
+	log.Infof("NodeStageVolume: starting mount kodo volume %s to staging path: %s", volumeId, stagingPath)
+
+	parameter, err := server.loadKodoPvParameter(ctx, "NodeStageVolume", volumeId, req.GetVolumeContext(), req.GetSecrets())
+	if err != nil {
+		if len(req.GetSecrets()) == 0 {
+			// 之前创建的静态存储卷只设置了 nodePublishSecretRef，由 NodePublishVolume 直接挂载到目标路径
+			log.Warnf("NodeStageVolume: kodo volume %s has no nodeStageSecretRef and is not staged, it will be mounted on each target path: %s", volumeId, err)
+			return &csi.NodeStageVolumeResponse{}, nil
+		}
+		return nil, err
+	}
+	if isReadOnlyAccessMode(req.GetVolumeCapability()) {
+		parameter.readOnly = true
+	}
+	mountOptions, err := parseKodoMountOptions("NodeStageVolume", req.GetVolumeCapability())
+	if err != nil {
+		return nil, err
+	}
+	if mounted, err := prepareMountPoint("NodeStageVolume", stagingPath, FuseTypeKodo); err != nil {
+		return nil, err
+	} else if mounted {
+		log.Infof("NodeStageVolume: kodo volume %s is already mounted on %s", volumeId, stagingPath)
+	} else if err = server.mountKodoVolume(volumeId, stagingPath, parameter, mountOptions); err != nil {
+		return nil, wrapStatusError(err, "NodeStageVolume: failed to mount kodo volume %s to %s", volumeId, stagingPath)
+	} else {
+		log.Infof("NodeStageVolume: kodo volume %s is mounted on %s", volumeId, stagingPath)
+	}
 	return &csi.NodeStageVolumeResponse{}, nil
 }
 
+// NodeUnstageVolume 等待缓存中的文件上传后卸载 NodeStageVolume 挂载的存储卷
 func (server *kodoNodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
+	volumeId := req.GetVolumeId()
+	stagingPath := req.GetStagingTargetPath()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeUnstageVolume: volume id is empty")
+	} else if stagingPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeUnstageVolume: staging path is empty")
+	}
+	log.Infof("NodeUnstageVolume: starting umount kodo volume %s from staging path: %s", volumeId, stagingPath)
+	if flushed, err := unmountKodo(volumeId, stagingPath); err != nil {
+		return nil, wrapStatusError(err, "NodeUnstageVolume")
+	} else if !flushed {
+		server.recordUnflushedCache(volumeId)
+	}
+	log.Infof("NodeUnstageVolume: umounted kodo volume %s from staging path: %s", volumeId, stagingPath)
 	return &csi.NodeUnstageVolumeResponse{}, nil
 }
 
+// recordUnflushedCache 在存储卷上记录卸载时缓存中可能有尚未上传的文件，不等待上传时不记录
+func (server *kodoNodeServer) recordUnflushedCache(volumeId string) {
+	if *unmountFlushTimeout <= 0 {
+		return
+	}
+	server.recorder.Eventf(&corev1.ObjectReference{Kind: "PersistentVolume", Name: volumeId}, corev1.EventTypeWarning, EVENT_REASON_UNFLUSHED_CACHE_KEPT,
+		"Volume %s is unmounted from node %s without confirming that cached files were uploaded, the cache is kept on the node and will be uploaded by the next mount of the volume on it",
+		volumeId, server.nodeID)
+}
+
+func (server *kodoNodeServer) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
+	return &csi.NodeGetCapabilitiesResponse{
+		Capabilities: []*csi.NodeServiceCapability{
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_VOLUME_MOUNT_GROUP),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_EXPAND_VOLUME),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_GET_VOLUME_STATS),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_VOLUME_CONDITION),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER),
+		},
+	}, nil
+}
+
+// NodeExpandVolume 不会重新挂载正在使用的存储卷，重启 rclone 会使 Pod 中打开的文件失效并丢失缓存中尚未上传的数据
+// 扩容后的 vfsdiskspacetotalsize 记录在存储卷状态中，下次挂载时生效
+// 挂载点上的容量尚未更新时返回 FailedPrecondition，kubelet 会保留 FileSystemResizePending 并在下次挂载后重试
 func (server *kodoNodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	volumeId := req.GetVolumeId()
+	volumePath := req.GetVolumePath()
+	capacityBytes := req.GetCapacityRange().GetRequiredBytes()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeExpandVolume: volume id is empty")
+	} else if volumePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeExpandVolume: volume path is empty")
+	}
+	log.Infof("NodeExpandVolume: starting expanding kodo volume %s on %s to %d", volumeId, volumePath, capacityBytes)
+
+	state, err := server.store.Get(ctx, volumeId)
+	if err != nil {
+		return nil, fmt.Errorf("NodeExpandVolume: get state of volume %s error: %w", volumeId, err)
+	} else if state == nil || state.Phase != volumePhaseCreated {
+		log.Infof("NodeExpandVolume: kodo volume %s is not dynamically provisioned, nothing to do", volumeId)
+		return &csi.NodeExpandVolumeResponse{CapacityBytes: capacityBytes}, nil
+	}
+	if capacityBytes <= 0 {
+		capacityBytes = state.CapacityBytes
+	}
+	totalBytes, err := getMountedCapacity(volumePath)
+	if err != nil {
+		return nil, fmt.Errorf("NodeExpandVolume: %w", err)
+	} else if totalBytes < capacityBytes {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"NodeExpandVolume: kodo volume %s is mounted on %s with capacity %d, the new capacity %d takes effect after it is mounted again",
+			volumeId, volumePath, totalBytes, capacityBytes)
+	}
+	log.Infof("NodeExpandVolume: kodo volume %s is mounted on %s with capacity %d", volumeId, volumePath, totalBytes)
+	return &csi.NodeExpandVolumeResponse{CapacityBytes: capacityBytes}, nil
+}
+
+// NodeGetVolumeStats 通过 statfs 获取挂载点的使用情况
+// rclone 无法得知 bucket 的实际用量，对于插件创建的 bucket，使用 Kodo 统计接口返回的存储量和文件数作为已用量
+func (server *kodoNodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
+	volumeId := req.GetVolumeId()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeGetVolumeStats: volume id is empty")
+	} else if req.GetVolumePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeGetVolumeStats: volume path is empty")
+	}
+	resp, err := getVolumeStats("NodeGetVolumeStats", req.GetVolumePath(), FuseTypeKodo)
+	if err != nil || len(resp.GetUsage()) == 0 {
+		return resp, err
+	}
+
+	if usage := server.getBucketUsage(ctx, volumeId); usage != nil {
+		for _, volumeUsage := range resp.GetUsage() {
+			switch volumeUsage.Unit {
+			case csi.VolumeUsage_BYTES:
+				volumeUsage.Used = usage.SizeBytes
+			case csi.VolumeUsage_INODES:
+				volumeUsage.Used = usage.ObjectCount
+			}
+			if volumeUsage.Available = volumeUsage.Total - volumeUsage.Used; volumeUsage.Available < 0 {
+				volumeUsage.Available = 0
+			}
+		}
+	}
+	return resp, nil
+}
+
+// getBucketUsage 获取插件为存储卷创建的 bucket 的用量，获取失败或 bucket 不是由插件创建时返回 nil
+// 只在读写缓存时持有锁，不同存储卷的远程请求不会互相阻塞
+func (server *kodoNodeServer) getBucketUsage(ctx context.Context, volumeId string) *qiniu.BucketUsage {
+	server.usageLock.Lock()
+	cached, ok := server.usageCache[volumeId]
+	server.usageLock.Unlock()
+	if ok && time.Since(cached.updatedAt) < BUCKET_USAGE_CACHE_TTL {
+		return cached.usage
+	}
+
+	var usage *qiniu.BucketUsage
+	// 静态存储卷和使用已有 bucket 的存储卷，bucket 可能被多个存储卷共享，不使用 bucket 的用量
+	if state, err := server.store.Get(ctx, volumeId); err != nil {
+		log.Warnf("getBucketUsage: failed to get state of volume %s: %s", volumeId, err)
+	} else if state != nil && state.Phase == volumePhaseCreated {
+		if bucketName, ok := state.getCheckpoint(CHECKPOINT_BUCKET_CREATED); ok {
+			if client, err := newKodoAdminClient("getBucketUsage", state.VolumeContext, make(map[string]string)); err != nil {
+				log.Warnf("getBucketUsage: %s", err)
+			} else if usage, err = client.GetBucketUsage(ctx, bucketName); err != nil {
+				log.Warnf("getBucketUsage: failed to get usage of bucket %s: %s", bucketName, err)
+			}
+		}
+	}
+	// 获取失败时同样缓存，避免每次都请求统计接口
+	server.usageLock.Lock()
+	server.usageCache[volumeId] = &cachedBucketUsage{usage: usage, updatedAt: time.Now()}
+	server.usageLock.Unlock()
+	return usage
 }
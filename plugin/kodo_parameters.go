@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -47,8 +49,31 @@ const (
 	FIELD_DEBUG_FUSE                = "debugfuse"
 	FIELD_ORIGINAL_ACCESS_KEY       = "originalaccesskey"
 	FIELD_ORIGINAL_SECRET_KEY       = "originalsecretkey"
+	FIELD_BUCKET_NAME_TEMPLATE      = "bucketnametemplate"
+	FIELD_SUB_DIR_TEMPLATE          = "subdirtemplate"
+	FIELD_SNAPSHOT_BUCKET           = "snapshotbucket"
 )
 
+// csi-provisioner 开启 --extra-create-metadata 后传入的参数
+const (
+	PARAMETER_PVC_NAME      = "csi.storage.k8s.io/pvc/name"
+	PARAMETER_PVC_NAMESPACE = "csi.storage.k8s.io/pvc/namespace"
+)
+
+// CSIDriver 开启 podInfoOnMount 后 kubelet 在 NodePublishVolume 中传入的参数
+const (
+	CONTEXT_EPHEMERAL            = "csi.storage.k8s.io/ephemeral"
+	CONTEXT_POD_NAME             = "csi.storage.k8s.io/pod.name"
+	CONTEXT_POD_NAMESPACE        = "csi.storage.k8s.io/pod.namespace"
+	CONTEXT_POD_UID              = "csi.storage.k8s.io/pod.uid"
+	CONTEXT_SERVICE_ACCOUNT_NAME = "csi.storage.k8s.io/serviceAccount.name"
+)
+
+// isEphemeralVolume 判断是否为 Pod 中直接声明的 CSI 临时存储卷
+func isEphemeralVolume(volumeContext map[string]string) bool {
+	return volumeContext[CONTEXT_EPHEMERAL] == "true"
+}
+
 type VfsCacheMode string
 
 const (
@@ -70,6 +95,21 @@ type kodoPvParameter struct {
 	s3Region                             string
 }
 
+// parseKodoEphemeralParameter 解析 CSI 临时存储卷的参数
+// 参数由 Pod 的创建者提供，AccessKey 和 SecretKey 只能来自 nodePublishSecretRef 引用的 Secret，不能写在 Pod 中
+func parseKodoEphemeralParameter(functionName string, ctx, secrets map[string]string) (*kodoPvParameter, error) {
+	for key := range ctx {
+		switch strings.ToLower(key) {
+		case FIELD_ACCESS_KEY, FIELD_SECRET_KEY, FIELD_ORIGINAL_ACCESS_KEY, FIELD_ORIGINAL_SECRET_KEY:
+			return nil, status.Errorf(codes.InvalidArgument, "%s: %s of ephemeral volume must be set in the secret referenced by nodePublishSecretRef", functionName, key)
+		}
+	}
+	if len(secrets) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: nodePublishSecretRef of ephemeral volume is empty", functionName)
+	}
+	return parseKodoPvParameter(functionName, ctx, secrets)
+}
+
 func parseKodoPvParameter(functionName string, ctx, secrets map[string]string) (param *kodoPvParameter, err error) {
 	var p kodoPvParameter
 
@@ -176,7 +216,9 @@ type kodoStorageClassParameter struct {
 	accessKey, secretKey, region                       string
 	ucEndpoint                                         *url.URL
 	storageClass                                       string
+	bucketNameTemplate                                 string
 	subDir                                             string
+	subDirTemplate                                     string
 	s3ForcePathStyle                                   *bool
 	dirCacheDuration                                   *time.Duration
 	bufferSize                                         *uint64
@@ -215,6 +257,10 @@ func parseKodoStorageClassParameter(functionName string, ctx, secrets map[string
 			p.storageClass = strings.TrimSpace(value)
 		case FIELD_SUB_DIR:
 			p.subDir = strings.TrimSpace(value)
+		case FIELD_SUB_DIR_TEMPLATE:
+			p.subDirTemplate = strings.TrimSpace(value)
+		case FIELD_BUCKET_NAME_TEMPLATE:
+			p.bucketNameTemplate = strings.TrimSpace(value)
 		case FIELD_S3_FORCE_PATH_STYLE:
 			if b, ok := parseBool(value); !ok {
 				err = fmt.Errorf("%s: unrecognized %s: %s", functionName, FIELD_S3_FORCE_PATH_STYLE, value)
@@ -466,6 +512,20 @@ func parseKodoStorageClassParameter(functionName string, ctx, secrets map[string
 	return
 }
 
+// newKodoAdminClient 创建用于管理存储卷的 KodoClient
+// 动态创建的存储卷参数中的 accessKey 属于只能访问单个 bucket 的 IAM 用户，因此优先使用原始的 accessKey
+func newKodoAdminClient(functionName string, volumeContext, secrets map[string]string) (*qiniu.KodoClient, error) {
+	parameter, err := parseKodoStorageClassParameter(functionName, volumeContext, secrets)
+	if err != nil {
+		return nil, err
+	}
+	accessKey, secretKey := parameter.accessKey, parameter.secretKey
+	if volumeContext[FIELD_ORIGINAL_ACCESS_KEY] != "" && volumeContext[FIELD_ORIGINAL_SECRET_KEY] != "" {
+		accessKey, secretKey = volumeContext[FIELD_ORIGINAL_ACCESS_KEY], volumeContext[FIELD_ORIGINAL_SECRET_KEY]
+	}
+	return qiniu.NewKodoClient(accessKey, secretKey, parameter.ucEndpoint, VERSION, COMMITID), nil
+}
+
 func toLower(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
 }
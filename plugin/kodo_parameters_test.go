@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseKodoEphemeralParameter(t *testing.T) {
+	assert.True(t, isEphemeralVolume(map[string]string{CONTEXT_EPHEMERAL: "true"}))
+	assert.False(t, isEphemeralVolume(map[string]string{CONTEXT_EPHEMERAL: "false"}))
+	assert.False(t, isEphemeralVolume(nil))
+
+	secrets := map[string]string{FIELD_ACCESS_KEY: "ak", FIELD_SECRET_KEY: "sk", FIELD_UC_ENDPOINT: "https://uc.qbox.me"}
+
+	// 凭证不能写在 Pod 中
+	_, err := parseKodoEphemeralParameter("NodePublishVolume", map[string]string{
+		CONTEXT_EPHEMERAL: "true",
+		FIELD_BUCKET_NAME: "bucket",
+		"SecretKey":       "sk",
+	}, secrets)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = parseKodoEphemeralParameter("NodePublishVolume", map[string]string{
+		CONTEXT_EPHEMERAL: "true",
+		FIELD_BUCKET_NAME: "bucket",
+	}, nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
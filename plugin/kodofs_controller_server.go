@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,9 +21,17 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+const (
+	CHECKPOINT_VOLUME_CREATED       = "volumecreated"
+	CHECKPOINT_GATEWAY_ID           = "gatewayid"
+	CHECKPOINT_ACCESS_POINT_CREATED = "accesspointcreated"
+	CHECKPOINT_ACCESS_POINT_ID      = "accesspointid"
+)
+
 type kodofsControllerServer struct {
-	volumes     map[string]*csi.Volume
 	volumesLock sync.Mutex
+	store       *volumeStateStore
+	nodeStore   *nodeStateStore
 	client      kubernetes.Interface
 	*csicommon.DefaultControllerServer
 }
@@ -38,23 +47,34 @@ func newKodoFSControllerServer(d *csicommon.CSIDriver) csi.ControllerServer {
 	}
 
 	c := &kodofsControllerServer{
-		volumes:                 make(map[string]*csi.Volume),
+		store:                   newVolumeStateStore(clientset, PodNamespace, KodoFSDriverName),
+		nodeStore:               newNodeStateStore(clientset, PodNamespace, KodoFSDriverName),
 		client:                  clientset,
 		DefaultControllerServer: csicommon.NewDefaultControllerServer(d),
 	}
 	return c
 }
 
-func (cs *kodofsControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+func (cs *kodofsControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (resp *csi.CreateVolumeResponse, err error) {
 	pvName := req.GetName()
 	log.Infof("CreateVolume: starting creating KodoFS volume %s", pvName)
+	if err = validateVolumeCapabilities(cs.Driver, req.GetVolumeCapabilities()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "CreateVolume: %s", err)
+	}
 
 	cs.volumesLock.Lock()
 	defer cs.volumesLock.Unlock()
 
-	if volume, exists := cs.volumes[pvName]; exists {
+	state, err := cs.store.Get(ctx, pvName)
+	if err != nil {
+		return nil, fmt.Errorf("CreateVolume: get state of volume %s error: %w", pvName, err)
+	} else if state == nil {
+		state = newVolumeState(pvName)
+	} else if state.Phase == volumePhaseCreated {
 		log.Warnf("CreateVolume: volume %s already exists", pvName)
-		return &csi.CreateVolumeResponse{Volume: volume}, nil
+		return &csi.CreateVolumeResponse{Volume: state.toVolume()}, nil
+	} else {
+		log.Infof("CreateVolume: resume creating KodoFS volume %s", pvName)
 	}
 
 	parameter, err := parseKodoFSStorageClassParameter("CreateVolume", req.GetParameters(), req.GetSecrets(), false)
@@ -62,13 +82,43 @@ func (cs *kodofsControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 		return nil, err
 	}
 	client := qiniu.NewKodoFSClient(parameter.accessKey, parameter.secretKey, parameter.masterServerAddress, VERSION, COMMITID)
-	gatewayId, err := client.CreateVolume(ctx, pvName, pvName, parameter.region, parameter.fsType, parameter.blockSize)
-	if err != nil {
-		return nil, fmt.Errorf("CreateVolume: create mount %s error: %w", pvName, err)
+
+	// 创建因无法通过重试恢复的错误失败时，删除本次或之前的重试中已经创建的资源
+	defer func() {
+		if isTerminalError(err) {
+			cs.rollbackCreateVolume(client, state)
+		}
+	}()
+
+	// 先记录再创建，否则创建成功后未能记录时，重试的 CreateVolume 无法确认存储卷是由本次创建的
+	gatewayId, ok := state.getCheckpoint(CHECKPOINT_GATEWAY_ID)
+	if !ok {
+		if _, ok = state.getCheckpoint(CHECKPOINT_VOLUME_CREATED); !ok {
+			if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_VOLUME_CREATED, pvName); err != nil {
+				return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+			}
+		}
+		if gatewayId, err = createKodoFSVolume(ctx, client, pvName, parameter); err != nil {
+			return nil, fmt.Errorf("CreateVolume: %w", err)
+		} else if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_GATEWAY_ID, gatewayId); err != nil {
+			return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+		}
+	} else {
+		log.Infof("CreateVolume: KodoFS volume %s has been created, reuse it", pvName)
 	}
-	accessPointId, err := client.CreateAccessPoint(ctx, pvName, pvName)
-	if err != nil {
-		return nil, fmt.Errorf("CreateVolume: create access point %s error: %w", pvName, err)
+	accessPointId, ok := state.getCheckpoint(CHECKPOINT_ACCESS_POINT_ID)
+	if !ok {
+		if _, ok = state.getCheckpoint(CHECKPOINT_ACCESS_POINT_CREATED); ok {
+			// 访问点没有名称，无法找到之前的 CreateVolume 可能已经创建的访问点，删除存储卷时需要手动清理
+			log.Warnf("CreateVolume: access point of KodoFS volume %s may have been created without being recorded, create a new one", pvName)
+		} else if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_ACCESS_POINT_CREATED, pvName); err != nil {
+			return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+		}
+		if accessPointId, err = client.CreateAccessPoint(ctx, pvName, pvName); err != nil {
+			return nil, fmt.Errorf("CreateVolume: create access point %s error: %w", pvName, err)
+		} else if err = cs.store.SaveCheckpoint(ctx, state, CHECKPOINT_ACCESS_POINT_ID, accessPointId); err != nil {
+			return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
+		}
 	}
 	accessToken, err := client.GetAccessToken(ctx, accessPointId)
 	if err != nil {
@@ -76,6 +126,13 @@ func (cs *kodofsControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 	}
 	log.Infof("CreateVolume: KodoFS volume %s is created", pvName)
 
+	capacityBytes := req.GetCapacityRange().GetRequiredBytes()
+	if capacityBytes > 0 {
+		if err = cs.setVolumeQuota(ctx, client, pvName, capacityBytes); err != nil {
+			return nil, fmt.Errorf("CreateVolume: %w", err)
+		}
+	}
+
 	volumeContext := map[string]string{
 		FIELD_GATEWAY_ID:            gatewayId,
 		FIELD_ACCESS_POINT_ID:       accessPointId,
@@ -88,13 +145,55 @@ func (cs *kodofsControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 		FIELD_FS_TYPE:               strconv.FormatUint(uint64(parameter.fsType), 10),
 		FIELD_BLOCK_SIZE:            strconv.FormatUint(uint64(parameter.blockSize), 10),
 	}
-	volume := &csi.Volume{
-		CapacityBytes: req.GetCapacityRange().GetRequiredBytes(),
-		VolumeId:      pvName,
-		VolumeContext: volumeContext,
+	state.Phase = volumePhaseCreated
+	state.CapacityBytes = capacityBytes
+	state.VolumeContext = volumeContext
+	if err = cs.store.Save(ctx, state); err != nil {
+		return nil, fmt.Errorf("CreateVolume: save state of volume %s error: %w", pvName, err)
 	}
-	cs.volumes[pvName] = volume
-	return &csi.CreateVolumeResponse{Volume: volume}, nil
+	return &csi.CreateVolumeResponse{Volume: state.toVolume()}, nil
+}
+
+// rollbackCreateVolume 按照与创建相反的顺序删除 CreateVolume 创建的访问点和 KodoFS 存储卷
+func (cs *kodofsControllerServer) rollbackCreateVolume(client *qiniu.KodoFSClient, state *volumeState) {
+	accessPointId, _ := state.getCheckpoint(CHECKPOINT_ACCESS_POINT_ID)
+
+	cs.store.Rollback(state, []rollbackStep{
+		{checkpoint: CHECKPOINT_ACCESS_POINT_ID, undo: func(ctx context.Context) error {
+			return client.RemoveAccessPoint(ctx, accessPointId)
+		}},
+		{checkpoint: CHECKPOINT_GATEWAY_ID, undo: func(ctx context.Context) error {
+			return removeKodoFSVolumeIfExists(ctx, client, state.VolumeId)
+		}},
+		{checkpoint: CHECKPOINT_VOLUME_CREATED, undo: func(ctx context.Context) error {
+			// checkpoint 在创建存储卷之前记录，存储卷可能并没有创建成功
+			return removeKodoFSVolumeIfExists(ctx, client, state.VolumeId)
+		}},
+	})
+}
+
+// createKodoFSVolume 创建 KodoFS 存储卷并返回 gateway id，同名存储卷已经存在时视为创建成功
+// 存储卷名称与 PV 名称相同，已经存在的同名存储卷是之前的 CreateVolume 创建后没来得及记录的
+func createKodoFSVolume(ctx context.Context, client *qiniu.KodoFSClient, volumeName string, parameter *kodofsStorageClassParameter) (string, error) {
+	gatewayId, err := client.CreateVolume(ctx, volumeName, volumeName, parameter.region, parameter.fsType, parameter.blockSize)
+	if err == nil {
+		return gatewayId, nil
+	}
+	if exists, existsErr := client.IsVolumeExists(ctx, volumeName); existsErr != nil || !exists {
+		return "", fmt.Errorf("createKodoFSVolume: create volume %s error: %w", volumeName, err)
+	}
+	if gatewayId, err = client.GetVolumeGatewayId(ctx, volumeName); err != nil {
+		return "", fmt.Errorf("createKodoFSVolume: get gateway id of existing volume %s error: %w", volumeName, err)
+	}
+	log.Infof("CreateVolume: KodoFS volume %s already exists, reuse it", volumeName)
+	return gatewayId, nil
+}
+
+func removeKodoFSVolumeIfExists(ctx context.Context, client *qiniu.KodoFSClient, volumeName string) error {
+	if exists, err := client.IsVolumeExists(ctx, volumeName); err != nil || !exists {
+		return err
+	}
+	return client.RemoveVolume(ctx, volumeName)
 }
 
 func (cs *kodofsControllerServer) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
@@ -120,8 +219,6 @@ func (cs *kodofsControllerServer) DeleteVolume(ctx context.Context, req *csi.Del
 	cs.volumesLock.Lock()
 	defer cs.volumesLock.Unlock()
 
-	delete(cs.volumes, volumeId)
-
 	client := qiniu.NewKodoFSClient(parameter.accessKey, parameter.secretKey, parameter.masterServerAddress, VERSION, COMMITID)
 
 	if persistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimDelete {
@@ -131,6 +228,9 @@ func (cs *kodofsControllerServer) DeleteVolume(ctx context.Context, req *csi.Del
 			// 已经不存在了，那么直接认为删除成功
 			if !exists {
 				log.Infof("DeleteVolume: volume %s is not exists, delete successful", volumeId)
+				if err = cs.store.Delete(ctx, volumeId); err != nil {
+					return nil, fmt.Errorf("DeleteVolume: delete state of volume %s error: %w", volumeId, err)
+				}
 				return &csi.DeleteVolumeResponse{}, nil
 			}
 		}
@@ -192,17 +292,104 @@ func (cs *kodofsControllerServer) DeleteVolume(ctx context.Context, req *csi.Del
 					return nil, fmt.Errorf("DeleteVolume: rename volume %s => %s error: %w", volumeId, newVolumeId, err)
 				}
 				log.Infof("DeleteVolume: KodoFS volume %s is deleted, archive to %s", volumeId, newVolumeId)
+				break
 			}
 		}
 	}
+	if err = cs.store.Delete(ctx, volumeId); err != nil {
+		return nil, fmt.Errorf("DeleteVolume: delete state of volume %s error: %w", volumeId, err)
+	}
 	return &csi.DeleteVolumeResponse{}, nil
 }
 
-func (cs *kodofsControllerServer) ControllerExpandVolume(context.Context, *csi.ControllerExpandVolumeRequest,
+// ControllerExpandVolume 提高 KodoFS 存储卷在 master 上的配额，请求的容量小于当前容量时直接返回当前容量
+func (cs *kodofsControllerServer) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest,
 ) (*csi.ControllerExpandVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	volumeId := req.GetVolumeId()
+	capacityBytes := req.GetCapacityRange().GetRequiredBytes()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "ControllerExpandVolume: volume id is empty")
+	}
+	log.Infof("ControllerExpandVolume: starting expanding KodoFS volume %s to %d", volumeId, capacityBytes)
+
+	cs.volumesLock.Lock()
+	defer cs.volumesLock.Unlock()
+
+	state, err := cs.store.Get(ctx, volumeId)
+	if err != nil {
+		return nil, fmt.Errorf("ControllerExpandVolume: get state of volume %s error: %w", volumeId, err)
+	} else if state != nil && state.Phase == volumePhaseCreated && state.CapacityBytes >= capacityBytes {
+		return &csi.ControllerExpandVolumeResponse{CapacityBytes: state.CapacityBytes}, nil
+	}
+
+	volumeContext, err := cs.store.GetVolumeContext(ctx, TypePluginKodoFS, volumeId)
+	if err != nil {
+		return nil, err
+	}
+	parameter, err := parseKodoFSPvParameter("ControllerExpandVolume", volumeContext, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	} else if parameter.accessKey == "" || parameter.secretKey == "" || parameter.masterServerAddress == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "ControllerExpandVolume: %s, %s or %s of volume %s is empty",
+			FIELD_ACCESS_KEY, FIELD_SECRET_KEY, FIELD_MASTER_SERVER_ADDRESS, volumeId)
+	}
+	client := qiniu.NewKodoFSClient(parameter.accessKey, parameter.secretKey, parameter.masterServerAddress, VERSION, COMMITID)
+	if err = cs.setVolumeQuota(ctx, client, volumeId, capacityBytes); err != nil {
+		return nil, fmt.Errorf("ControllerExpandVolume: %w", err)
+	}
+
+	// 静态存储卷没有状态，只调整配额
+	if state != nil && state.Phase == volumePhaseCreated {
+		state.CapacityBytes = capacityBytes
+		if err = cs.store.Save(ctx, state); err != nil {
+			return nil, fmt.Errorf("ControllerExpandVolume: save state of volume %s error: %w", volumeId, err)
+		}
+	}
+	log.Infof("ControllerExpandVolume: KodoFS volume %s is expanded to %d", volumeId, capacityBytes)
+	return &csi.ControllerExpandVolumeResponse{CapacityBytes: capacityBytes}, nil
 }
 
-func (cs *kodofsControllerServer) ControllerGetVolume(context.Context, *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+// setVolumeQuota 将存储卷容量设置为 KodoFS 存储卷的配额，不支持配额的 master 只记录警告
+func (cs *kodofsControllerServer) setVolumeQuota(ctx context.Context, client *qiniu.KodoFSClient, volumeName string, capacityBytes int64) error {
+	if err := client.SetVolumeQuota(ctx, volumeName, capacityBytes); errors.Is(err, qiniu.ErrAPINotSupported) {
+		log.Warnf("setVolumeQuota: quota of KodoFS volume %s is not supported: %s", volumeName, err)
+	} else if err != nil {
+		return fmt.Errorf("setVolumeQuota: failed to set quota of KodoFS volume %s to %d: %w", volumeName, capacityBytes, err)
+	} else {
+		log.Infof("setVolumeQuota: quota of KodoFS volume %s is set to %d", volumeName, capacityBytes)
+	}
+	return nil
+}
+
+func (cs *kodofsControllerServer) ValidateVolumeCapabilities(ctx context.Context, req *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
+	return validateVolumeCapabilitiesOf(ctx, "ValidateVolumeCapabilities", TypePluginKodoFS, cs.store, cs.Driver, req)
+}
+
+func (cs *kodofsControllerServer) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ControllerGetVolume: volume id is empty")
+	}
+	return getVolumeStatus(ctx, "ControllerGetVolume", TypePluginKodoFS, cs.store, cs.nodeStore, req.GetVolumeId(), cs.checkVolumeCondition)
+}
+
+func (cs *kodofsControllerServer) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
+	return listVolumeStatuses(ctx, "ListVolumes", cs.store, cs.nodeStore, req, cs.checkVolumeCondition)
+}
+
+// checkVolumeCondition 检查 KodoFS 存储卷是否仍然存在，无法检查时不认为存储卷异常
+func (cs *kodofsControllerServer) checkVolumeCondition(ctx context.Context, volumeId string, volumeContext map[string]string) *csi.VolumeCondition {
+	parameter, err := parseKodoFSStorageClassParameter("checkVolumeCondition", volumeContext, make(map[string]string), true)
+	if err != nil {
+		return &csi.VolumeCondition{Message: fmt.Sprintf("cannot check KodoFS volume: %s", err)}
+	} else if parameter.accessKey == "" || parameter.secretKey == "" || parameter.masterServerAddress == nil {
+		return &csi.VolumeCondition{Message: "cannot check KodoFS volume: credentials or master server address is unknown"}
+	}
+	client := qiniu.NewKodoFSClient(parameter.accessKey, parameter.secretKey, parameter.masterServerAddress, VERSION, COMMITID)
+	if exists, err := client.IsVolumeExists(ctx, volumeId); err != nil {
+		log.Warnf("checkVolumeCondition: failed to check if KodoFS volume %s exists: %s", volumeId, err)
+		return &csi.VolumeCondition{Message: fmt.Sprintf("cannot check KodoFS volume: %s", err)}
+	} else if !exists {
+		return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("KodoFS volume %s does not exist", volumeId)}
+	}
+	return &csi.VolumeCondition{Message: fmt.Sprintf("KodoFS volume %s exists", volumeId)}
 }
@@ -7,20 +7,36 @@ import (
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
+	"github.com/qiniu/kubernetes-csi-driver/bindmount"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	k8smount "k8s.io/utils/mount"
 )
 
 type kodofsNodeServer struct {
+	nodeID     string
 	k8smounter k8smount.Interface
+	nodeStore  *nodeStateStore
 	*csicommon.DefaultNodeServer
 }
 
-func newKodoFSNodeServer(d *csicommon.CSIDriver) csi.NodeServer {
+func newKodoFSNodeServer(d *csicommon.CSIDriver, nodeID string) csi.NodeServer {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		log.Fatalf("newKodoFSNodeServer: failed to create config: %v", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatalf("newKodoFSNodeServer: failed to create client: %v", err)
+	}
+
 	return &kodofsNodeServer{
+		nodeID:            nodeID,
 		k8smounter:        k8smount.New(""),
+		nodeStore:         newNodeStateStore(clientset, PodNamespace, KodoFSDriverName),
 		DefaultNodeServer: csicommon.NewDefaultNodeServer(d),
 	}
 }
@@ -32,17 +48,35 @@ func (server *kodofsNodeServer) NodePublishVolume(ctx context.Context, req *csi.
 	}
 	log.Infof("NodePublishVolume: starting mount kodofs volume %s to path: %s", req.GetVolumeId(), mountPath)
 
-	parameter, err := parseKodoFSPvParameter("NodePublishVolume", req.GetVolumeContext(), req.GetSecrets())
-	if err != nil {
-		return nil, err
-	}
-	if err = ensureDirectoryCreated(mountPath); err != nil {
-		return nil, fmt.Errorf("NodePublishVolume: create mount path %s error: %w", mountPath, err)
+	// Pod 中设置了 readOnly 或者访问模式只允许读取时，以只读方式挂载
+	readOnly := req.GetReadonly() || isReadOnlyAccessMode(req.GetVolumeCapability())
+	stagingPath := req.GetStagingTargetPath()
+	if staged, err := isStaged(stagingPath, FuseTypeKodoFS); err != nil {
+		return nil, wrapStatusError(err, "NodePublishVolume")
+	} else if staged {
+		if err = bindMount(server.k8smounter, "NodePublishVolume", stagingPath, mountPath, FuseTypeKodoFS, readOnly); err != nil {
+			return nil, err
+		}
+		log.Infof("NodePublishVolume: kodofs volume %s staged on %s is bind mounted on %s", req.GetVolumeId(), stagingPath, mountPath)
+	} else {
+		// 没有在 NodeStageVolume 中挂载的存储卷，直接挂载到目标路径
+		parameter, err := parseKodoFSPvParameter("NodePublishVolume", req.GetVolumeContext(), req.GetSecrets())
+		if err != nil {
+			return nil, err
+		}
+		if mounted, err := prepareMountPoint("NodePublishVolume", mountPath, FuseTypeKodoFS); err != nil {
+			return nil, err
+		} else if mounted {
+			log.Infof("NodePublishVolume: kodofs volume %s is already mounted on %s", req.GetVolumeId(), mountPath)
+		} else if err = mountKodoFS(req.VolumeId, parameter.gatewayID, mountPath, parameter.mountServerAddress, parameter.accessToken, "/", readOnly); err != nil {
+			return nil, wrapStatusError(err, "NodePublishVolume: failed to mount kodofs volume %s to %s", req.GetVolumeId(), mountPath)
+		} else {
+			log.Infof("NodePublishVolume: kodofs volume %s is mounted on %s", req.GetVolumeId(), mountPath)
+		}
 	}
-	if err = mountKodoFS(req.VolumeId, parameter.gatewayID, mountPath, parameter.mountServerAddress, parameter.accessToken, "/"); err != nil {
-		return nil, fmt.Errorf("NodePublishVolume: failed to to mount kodofs to %s: %w", mountPath, err)
+	if err := server.nodeStore.AddPublishedVolume(ctx, server.nodeID, req.GetVolumeId(), mountPath); err != nil {
+		log.Warnf("NodePublishVolume: failed to record kodofs volume %s is published: %s", req.GetVolumeId(), err)
 	}
-	log.Infof("NodePublishVolume: kodofs volume %s is mounted on %s", req.GetVolumeId(), mountPath)
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
@@ -52,27 +86,99 @@ func (server *kodofsNodeServer) NodeUnpublishVolume(ctx context.Context, req *cs
 		return nil, errors.New("NodeUnpublishVolume: mountPath is empty")
 	}
 	log.Infof("NodeUnpublishVolume: starting umount kodofs volume from path: %s", mountPath)
-	mounted, err := isKodoFSMounted(mountPath)
-	if err != nil {
-		log.Warnf("NodeUnpublishVolume: failed to detect mount point: %s", err)
-	} else if !mounted {
-		log.Warnf("NodeUnpublishVolume: mountPath is not mounted by kodofs")
-	} else if err = umount(mountPath); err != nil {
-		return nil, fmt.Errorf("NodeUnpublishVolume: failed to unmount kodofs: %w", err)
-	} else {
-		log.Infof("NodeUnpublishVolume: umounted kodofs volume from path: %s", mountPath)
+	// 绑定挂载的目标路径只需卸载绑定，FUSE 挂载在 NodeUnstageVolume 中卸载
+	if binds, err := bindmount.FindBindMounts(mountPath, FuseTypeKodoFS); err != nil {
+		return nil, fmt.Errorf("NodeUnpublishVolume: %w", err)
+	} else if len(binds) > 0 {
+		if err = unbindMount(server.k8smounter, "NodeUnpublishVolume", mountPath); err != nil {
+			return nil, err
+		}
+	} else if err = unmountKodoFS(req.GetVolumeId(), mountPath); err != nil {
+		return nil, wrapStatusError(err, "NodeUnpublishVolume")
+	}
+	log.Infof("NodeUnpublishVolume: umounted kodofs volume from path: %s", mountPath)
+	if err := server.nodeStore.RemovePublishedVolume(ctx, server.nodeID, req.GetVolumeId(), mountPath); err != nil {
+		log.Warnf("NodeUnpublishVolume: failed to record kodofs volume %s is unpublished: %s", req.GetVolumeId(), err)
 	}
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
+// NodeStageVolume 在每个节点上只挂载一次存储卷，NodePublishVolume 将其绑定挂载到每个 Pod 的目标路径
 func (server *kodofsNodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	volumeId := req.GetVolumeId()
+	stagingPath := req.GetStagingTargetPath()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeStageVolume: volume id is empty")
+	} else if stagingPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeStageVolume: staging path is empty")
+	}
+	log.Infof("NodeStageVolume: starting mount kodofs volume %s to staging path: %s", volumeId, stagingPath)
+
+	parameter, err := parseKodoFSPvParameter("NodeStageVolume", req.GetVolumeContext(), req.GetSecrets())
+	if err != nil {
+		if len(req.GetSecrets()) == 0 {
+			// 之前创建的静态存储卷只设置了 nodePublishSecretRef，由 NodePublishVolume 直接挂载到目标路径
+			log.Warnf("NodeStageVolume: kodofs volume %s has no nodeStageSecretRef and is not staged, it will be mounted on each target path: %s", volumeId, err)
+			return &csi.NodeStageVolumeResponse{}, nil
+		}
+		return nil, err
+	}
+	if mounted, err := prepareMountPoint("NodeStageVolume", stagingPath, FuseTypeKodoFS); err != nil {
+		return nil, err
+	} else if mounted {
+		log.Infof("NodeStageVolume: kodofs volume %s is already mounted on %s", volumeId, stagingPath)
+	} else if err = mountKodoFS(volumeId, parameter.gatewayID, stagingPath, parameter.mountServerAddress, parameter.accessToken, "/", isReadOnlyAccessMode(req.GetVolumeCapability())); err != nil {
+		return nil, wrapStatusError(err, "NodeStageVolume: failed to mount kodofs volume %s to %s", volumeId, stagingPath)
+	} else {
+		log.Infof("NodeStageVolume: kodofs volume %s is mounted on %s", volumeId, stagingPath)
+	}
 	return &csi.NodeStageVolumeResponse{}, nil
 }
 
+// NodeUnstageVolume 卸载 NodeStageVolume 挂载的存储卷
 func (server *kodofsNodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
+	volumeId := req.GetVolumeId()
+	stagingPath := req.GetStagingTargetPath()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeUnstageVolume: volume id is empty")
+	} else if stagingPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeUnstageVolume: staging path is empty")
+	}
+	log.Infof("NodeUnstageVolume: starting umount kodofs volume %s from staging path: %s", volumeId, stagingPath)
+	if err := unmountKodoFS(volumeId, stagingPath); err != nil {
+		return nil, wrapStatusError(err, "NodeUnstageVolume")
+	}
+	log.Infof("NodeUnstageVolume: umounted kodofs volume %s from staging path: %s", volumeId, stagingPath)
 	return &csi.NodeUnstageVolumeResponse{}, nil
 }
 
+func (server *kodofsNodeServer) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
+	return &csi.NodeGetCapabilitiesResponse{
+		Capabilities: []*csi.NodeServiceCapability{
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_EXPAND_VOLUME),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_GET_VOLUME_STATS),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_VOLUME_CONDITION),
+			newNodeServiceCapability(csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER),
+		},
+	}, nil
+}
+
+// NodeExpandVolume KodoFS 的配额由 master 控制，已挂载的存储卷无需任何操作
 func (server *kodofsNodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeExpandVolume: volume id is empty")
+	} else if req.GetVolumePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeExpandVolume: volume path is empty")
+	}
+	return &csi.NodeExpandVolumeResponse{CapacityBytes: req.GetCapacityRange().GetRequiredBytes()}, nil
+}
+
+func (server *kodofsNodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeGetVolumeStats: volume id is empty")
+	} else if req.GetVolumePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeGetVolumeStats: volume path is empty")
+	}
+	return getVolumeStats("NodeGetVolumeStats", req.GetVolumePath(), FuseTypeKodoFS)
 }
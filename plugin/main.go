@@ -22,6 +22,8 @@ const (
 
 var (
 	KubeletRootDir = "/var/lib/kubelet"
+	// 用于存放存储卷状态 ConfigMap 的命名空间
+	PodNamespace = "kube-system"
 )
 
 var (
@@ -37,6 +39,21 @@ var (
 	nodeID     = flag.String("nodeid", "", "Node id")
 	driverName = flag.String("driver", "", "Driver Name")
 	healthPort = flag.Int("health-port", 11260, "Health Port")
+
+	connectorSocket = flag.String("connector-socket", SocketPath, "Unix socket of the connector on the host, must be the same as socketPath of the connector config file")
+
+	probeEndpoints = flag.String("probe-endpoints", "", "Comma separated UC or KodoFS master endpoints whose reachability is checked by the CSI Probe called by controller sidecars, not by /readyz")
+
+	unmountFlushTimeout = flag.Duration("unmount-flush-timeout", time.Minute, "How long to wait for rclone to upload cached files before unmounting a Kodo volume, 0 to unmount without waiting")
+
+	controller = flag.Bool("controller", false, "Run as the controller instance in the provisioner Deployment, only the controller instance collects orphaned Kodo resources")
+
+	gcInterval         = flag.Duration("gc-interval", time.Hour, "Interval of collecting orphaned Kodo resources, 0 to disable")
+	gcGracePeriod      = flag.Duration("gc-grace-period", 24*time.Hour, "How long orphaned Kodo resources are kept before being deleted")
+	gcDryRun           = flag.Bool("gc-dry-run", true, "Only report orphaned Kodo resources without deleting them")
+	gcVolumeNamePrefix = flag.String("gc-volume-name-prefix", "kodo", "Volume name prefix of csi-provisioner, used to recognize Kodo resources created by the driver")
+
+	clusterID = flag.String("cluster-id", "", "Cluster id tagged on Kodo buckets created by the driver, only tagged buckets are collected as orphaned resources, defaults to the UID of the kube-system namespace")
 )
 
 func init() {
@@ -44,6 +61,10 @@ func init() {
 	if rootDir != "" {
 		KubeletRootDir = rootDir
 	}
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace != "" {
+		PodNamespace = namespace
+	}
 }
 
 func main() {
@@ -99,15 +120,37 @@ func main() {
 	log.Infof("CSI Driver Name: %s, nodeID: %s, endPoints: %s", *driverName, *nodeID, *endpoint)
 	log.Infof("CSI Driver Version: %s, CommitID: %s, Build time: %s", VERSION, COMMITID, BUILDTIME)
 
+	endpoints, err := parseProbeEndpoints(*probeEndpoints)
+	if err != nil {
+		log.Errorf("Invalid -probe-endpoints: %s", err)
+		os.Exit(1)
+	}
+	checker := newHealthChecker(*driverName, endpoints)
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 
 	var driver Runnable = nil
 	switch *driverName {
 	case KodoDriverName:
-		driver = newKodoDriver(*nodeID, *endpoint, VERSION)
+		gcConfig := kodoGarbageCollectorConfig{
+			interval:         *gcInterval,
+			gracePeriod:      *gcGracePeriod,
+			dryRun:           *gcDryRun,
+			volumeNamePrefix: *gcVolumeNamePrefix,
+			clusterId:        *clusterID,
+		}
+		if *controller && gcConfig.interval > 0 {
+			collector, err := newKodoGarbageCollector(*nodeID, gcConfig)
+			if err != nil {
+				log.Errorf("Failed to create garbage collector: %s", err)
+				os.Exit(1)
+			}
+			go collector.Run()
+		}
+		driver = newKodoDriver(*nodeID, *endpoint, VERSION, gcConfig, checker)
 	case KodoFSDriverName:
-		driver = newKodoFSDriver(*nodeID, *endpoint, VERSION)
+		driver = newKodoFSDriver(*nodeID, *endpoint, VERSION, checker)
 	default:
 		log.Errorf("-driver must be either kodo or kodofs")
 		os.Exit(1)
@@ -126,7 +169,11 @@ func main() {
 	log.Info("CSI is running status.")
 	log.Infof("CSI will listen on port %d.", servicePort)
 	server := &http.Server{Addr: fmt.Sprintf(":%d", servicePort)}
-	http.HandleFunc("/health", healthHandler)
+	// /health 为 /healthz 的别名，兼容旧的部署文件
+	http.Handle("/health", checker.Handler(healthScopeLiveness))
+	http.Handle("/healthz", checker.Handler(healthScopeLiveness))
+	http.Handle("/readyz", checker.Handler(healthScopeReadiness))
+	http.Handle("/metrics", newMetricsHandler(*driverName))
 	if err = server.ListenAndServe(); err != nil {
 		log.Fatalf("Service port listen and serve err: %s", err.Error())
 	}
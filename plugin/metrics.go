@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	METRICS_NAMESPACE = protocol.MetricsNamespace
+	// 每次采集时请求 connector 的超时时间
+	CONNECTOR_SCRAPE_TIMEOUT = 10 * time.Second
+	// 每次采集时同时获取 rclone 传输统计的挂载点数量
+	CONNECTOR_SCRAPE_CONCURRENCY = 8
+)
+
+var (
+	csiRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "csi_requests_total",
+		Help:      "Number of CSI requests handled by the plugin.",
+	}, []string{"method", "code"})
+	csiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "csi_request_duration_seconds",
+		Help:      "Latency of CSI requests handled by the plugin.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"method"})
+	connectorRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "connector_requests_total",
+		Help:      "Number of requests sent to the connector by method, protocol version and result.",
+	}, []string{"method", "protocol", "code"})
+
+	activeMountsDesc = prometheus.NewDesc(METRICS_NAMESPACE+"_active_mounts",
+		"Number of mount points of the driver recorded by the connector on this node.", []string{"status"}, nil)
+	rcloneTransferredBytesDesc = prometheus.NewDesc(METRICS_NAMESPACE+"_rclone_transferred_bytes_total",
+		"Bytes transferred by the rclone processes of a volume.", []string{"volume_id"}, nil)
+	rcloneTransfersDesc = prometheus.NewDesc(METRICS_NAMESPACE+"_rclone_transfers_total",
+		"Files transferred by the rclone processes of a volume.", []string{"volume_id"}, nil)
+	rcloneErrorsDesc = prometheus.NewDesc(METRICS_NAMESPACE+"_rclone_errors_total",
+		"Errors of the rclone processes of a volume.", []string{"volume_id"}, nil)
+	rclonePendingUploadsDesc = prometheus.NewDesc(METRICS_NAMESPACE+"_rclone_pending_uploads",
+		"Files in the VFS caches of a volume which are being uploaded or waiting to be uploaded.", []string{"volume_id"}, nil)
+)
+
+// newMetricsHandler 返回 /metrics 的处理函数，插件自身的指标都带有 driver 标签
+func newMetricsHandler(driverName string) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	mountType := connectorpb.MountType_MOUNT_TYPE_KODO
+	if driverName == KodoFSDriverName {
+		mountType = connectorpb.MountType_MOUNT_TYPE_KODOFS
+	}
+	registerer := prometheus.WrapRegistererWith(prometheus.Labels{"driver": driverName}, registry)
+	registerer.MustRegister(csiRequestsTotal, csiRequestDuration, connectorRequestsTotal, &connectorCollector{mountType: mountType})
+	registerer.MustRegister(qiniu.Collectors()...)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func observeCSIRequest(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	method := path.Base(info.FullMethod)
+	csiRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	csiRequestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	return resp, err
+}
+
+// connectorCollector 在每次采集时通过 connector 获取节点上的挂载点，以及 Kodo 挂载点上 rclone 的传输统计
+// connector 不可用时（例如在 controller 中）不返回这些指标
+// 挂载路径中包含 Pod UID，所以 rclone 的指标只以存储卷为标签，同一个存储卷的多个挂载点求和
+type connectorCollector struct {
+	mountType connectorpb.MountType
+}
+
+func (collector *connectorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeMountsDesc
+	ch <- rcloneTransferredBytesDesc
+	ch <- rcloneTransfersDesc
+	ch <- rcloneErrorsDesc
+	ch <- rclonePendingUploadsDesc
+}
+
+func (collector *connectorCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONNECTOR_SCRAPE_TIMEOUT)
+	defer cancel()
+
+	conn, err := connector.getConn()
+	if err != nil {
+		log.Debugf("connectorCollector: %s", err)
+		return
+	}
+	client := connectorpb.NewConnectorClient(conn)
+	resp, err := client.ListMounts(ctx, &connectorpb.ListMountsRequest{})
+	if err != nil {
+		log.Debugf("connectorCollector: failed to list mounts: %s", err)
+		return
+	}
+
+	counts := make(map[connectorpb.MountStatus]int)
+	var rcloneMounts []*connectorpb.MountInfo
+	for _, mount := range resp.GetMounts() {
+		if mount.GetType() != collector.mountType {
+			continue
+		}
+		counts[mount.GetStatus()]++
+		if mount.GetType() == connectorpb.MountType_MOUNT_TYPE_KODO && mount.GetStatus() == connectorpb.MountStatus_MOUNT_STATUS_HEALTHY {
+			rcloneMounts = append(rcloneMounts, mount)
+		}
+	}
+	for value, name := range connectorpb.MountStatus_name {
+		mountStatus := strings.ToLower(strings.TrimPrefix(name, "MOUNT_STATUS_"))
+		ch <- prometheus.MustNewConstMetric(activeMountsDesc, prometheus.GaugeValue, float64(counts[connectorpb.MountStatus(value)]), mountStatus)
+	}
+
+	for volumeId, stats := range collectRcloneStats(ctx, client, rcloneMounts) {
+		ch <- prometheus.MustNewConstMetric(rcloneTransferredBytesDesc, prometheus.CounterValue, stats.bytes, volumeId)
+		ch <- prometheus.MustNewConstMetric(rcloneTransfersDesc, prometheus.CounterValue, stats.transfers, volumeId)
+		ch <- prometheus.MustNewConstMetric(rcloneErrorsDesc, prometheus.CounterValue, stats.errors, volumeId)
+		if stats.hasPendingUploads {
+			ch <- prometheus.MustNewConstMetric(rclonePendingUploadsDesc, prometheus.GaugeValue, stats.pendingUploads, volumeId)
+		}
+	}
+}
+
+// rcloneStats 一个存储卷所有挂载点上 rclone 传输统计的总和
+type rcloneStats struct {
+	bytes, transfers, errors float64
+	pendingUploads           float64
+	hasPendingUploads        bool
+}
+
+// collectRcloneStats 并发获取每个挂载点上 rclone 的传输统计，并按照存储卷求和，获取失败的挂载点被忽略
+func collectRcloneStats(ctx context.Context, client connectorpb.ConnectorClient, mounts []*connectorpb.MountInfo) map[string]*rcloneStats {
+	var (
+		lock        sync.Mutex
+		wg          sync.WaitGroup
+		concurrency = make(chan struct{}, CONNECTOR_SCRAPE_CONCURRENCY)
+		volumeStats = make(map[string]*rcloneStats)
+	)
+	for _, mount := range mounts {
+		wg.Add(1)
+		concurrency <- struct{}{}
+		go func(mount *connectorpb.MountInfo) {
+			defer wg.Done()
+			defer func() { <-concurrency }()
+			stats, err := getRcloneStats(ctx, client, mount.GetMountPath())
+			if err != nil {
+				log.Debugf("connectorCollector: %s", err)
+				return
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			sum, ok := volumeStats[mount.GetVolumeId()]
+			if !ok {
+				sum = &rcloneStats{}
+				volumeStats[mount.GetVolumeId()] = sum
+			}
+			sum.bytes += stats.bytes
+			sum.transfers += stats.transfers
+			sum.errors += stats.errors
+			if stats.hasPendingUploads {
+				sum.pendingUploads += stats.pendingUploads
+				sum.hasPendingUploads = true
+			}
+		}(mount)
+	}
+	wg.Wait()
+	return volumeStats
+}
+
+// getRcloneStats 通过 connector 调用挂载点上 rclone 的 core/stats 和 vfs/stats
+func getRcloneStats(ctx context.Context, client connectorpb.ConnectorClient, mountPath string) (*rcloneStats, error) {
+	var coreStats struct {
+		Bytes     float64 `json:"bytes"`
+		Transfers float64 `json:"transfers"`
+		Errors    float64 `json:"errors"`
+	}
+	if err := callRcloneRc(ctx, client, mountPath, "core/stats", &coreStats); err != nil {
+		return nil, err
+	}
+	stats := &rcloneStats{bytes: coreStats.Bytes, transfers: coreStats.Transfers, errors: coreStats.Errors}
+
+	var vfsStats struct {
+		DiskCache *struct {
+			UploadsInProgress float64 `json:"uploadsInProgress"`
+			UploadsQueued     float64 `json:"uploadsQueued"`
+		} `json:"diskCache"`
+	}
+	if err := callRcloneRc(ctx, client, mountPath, "vfs/stats", &vfsStats); err != nil {
+		log.Debugf("connectorCollector: %s", err)
+	} else if vfsStats.DiskCache != nil {
+		stats.pendingUploads = vfsStats.DiskCache.UploadsInProgress + vfsStats.DiskCache.UploadsQueued
+		stats.hasPendingUploads = true
+	}
+	return stats, nil
+}
+
+// callRcloneRc 通过 connector 调用挂载点上 rclone 的远程控制命令
+func callRcloneRc(ctx context.Context, client connectorpb.ConnectorClient, mountPath, method string, out interface{}) error {
+	resp, err := client.Rc(ctx, &connectorpb.RcRequest{MountPath: mountPath, Method: method})
+	if err != nil {
+		return wrapStatusError(err, "failed to call %s on %s", method, mountPath)
+	}
+	if err = json.Unmarshal([]byte(resp.GetResult()), out); err != nil {
+		return fmt.Errorf("failed to unmarshal result of %s on %s: %w", method, mountPath, err)
+	}
+	return nil
+}
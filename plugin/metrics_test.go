@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestObserveCSIRequest(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodePublishVolume"}
+	before := testutil.ToFloat64(csiRequestsTotal.WithLabelValues("NodePublishVolume", codes.NotFound.String()))
+
+	_, err := observeCSIRequest(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "bucket not found")
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, before+1, testutil.ToFloat64(csiRequestsTotal.WithLabelValues("NodePublishVolume", codes.NotFound.String())))
+	assert.Equal(t, 1, testutil.CollectAndCount(csiRequestDuration, "qiniu_csi_csi_request_duration_seconds"))
+}
+
+// fakeRcClient 按照挂载路径返回 rclone 远程控制命令的结果，只实现了 Rc
+type fakeRcClient struct {
+	connectorpb.ConnectorClient
+	results map[string]string
+}
+
+func (client *fakeRcClient) Rc(ctx context.Context, req *connectorpb.RcRequest, opts ...grpc.CallOption) (*connectorpb.RcResponse, error) {
+	result, ok := client.results[req.GetMountPath()+" "+req.GetMethod()]
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "remote control of %s is not enabled", req.GetMountPath())
+	}
+	return &connectorpb.RcResponse{Result: result}, nil
+}
+
+func TestCollectRcloneStats(t *testing.T) {
+	client := &fakeRcClient{results: map[string]string{
+		"/pod-1/mount core/stats": `{"bytes":100,"transfers":2,"errors":1}`,
+		"/pod-1/mount vfs/stats":  `{"diskCache":{"uploadsInProgress":1,"uploadsQueued":2}}`,
+		"/pod-2/mount core/stats": `{"bytes":50,"transfers":1,"errors":0}`,
+		"/pod-3/mount core/stats": `{"bytes":10,"transfers":1,"errors":0}`,
+	}}
+	mounts := []*connectorpb.MountInfo{
+		{VolumeId: "kodo-1", MountPath: "/pod-1/mount"},
+		{VolumeId: "kodo-1", MountPath: "/pod-2/mount"},
+		{VolumeId: "kodo-2", MountPath: "/pod-3/mount"},
+		{VolumeId: "kodo-3", MountPath: "/pod-4/mount"},
+	}
+
+	// 同一个存储卷的多个挂载点求和，获取失败的挂载点被忽略
+	stats := collectRcloneStats(context.Background(), client, mounts)
+	assert.Len(t, stats, 2)
+	assert.Equal(t, &rcloneStats{bytes: 150, transfers: 3, errors: 1, pendingUploads: 3, hasPendingUploads: true}, stats["kodo-1"])
+	assert.Equal(t, &rcloneStats{bytes: 10, transfers: 1}, stats["kodo-2"])
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeState 记录一个节点上已经发布的存储卷，key 为存储卷 ID，value 为挂载路径
+// 由于不需要 ControllerPublishVolume，只能由节点插件自己记录存储卷被发布到了哪些节点上
+type nodeState struct {
+	NodeId           string              `json:"node_id"`
+	PublishedVolumes map[string][]string `json:"published_volumes,omitempty"`
+}
+
+// nodeStateStore 为每个节点保存一个 ConfigMap，每个 ConfigMap 只会被对应节点上的插件修改
+type nodeStateStore struct {
+	lock sync.Mutex
+	*volumeStateStore
+}
+
+func newNodeStateStore(client kubernetes.Interface, namespace, driverName string) *nodeStateStore {
+	return &nodeStateStore{volumeStateStore: newVolumeStateStore(client, namespace, driverName+"-node")}
+}
+
+// AddPublishedVolume 记录存储卷被发布到了节点的 targetPath 上
+func (store *nodeStateStore) AddPublishedVolume(ctx context.Context, nodeId, volumeId, targetPath string) error {
+	return store.update(ctx, nodeId, func(state *nodeState) {
+		for _, path := range state.PublishedVolumes[volumeId] {
+			if path == targetPath {
+				return
+			}
+		}
+		state.PublishedVolumes[volumeId] = append(state.PublishedVolumes[volumeId], targetPath)
+	})
+}
+
+// RemovePublishedVolume 移除存储卷在节点 targetPath 上的发布记录
+func (store *nodeStateStore) RemovePublishedVolume(ctx context.Context, nodeId, volumeId, targetPath string) error {
+	return store.update(ctx, nodeId, func(state *nodeState) {
+		var paths []string
+		for _, path := range state.PublishedVolumes[volumeId] {
+			if path != targetPath {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) > 0 {
+			state.PublishedVolumes[volumeId] = paths
+		} else {
+			delete(state.PublishedVolumes, volumeId)
+		}
+	})
+}
+
+// ListPublishedNodes 返回每个存储卷被发布到的节点 ID
+func (store *nodeStateStore) ListPublishedNodes(ctx context.Context) (map[string][]string, error) {
+	configMaps, err := store.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	publishedNodes := make(map[string][]string)
+	for _, configMap := range configMaps {
+		var state nodeState
+		if err = parseConfigMapData(configMap, &state); err != nil {
+			return nil, err
+		}
+		for volumeId := range state.PublishedVolumes {
+			publishedNodes[volumeId] = append(publishedNodes[volumeId], state.NodeId)
+		}
+	}
+	for _, nodeIds := range publishedNodes {
+		sort.Strings(nodeIds)
+	}
+	return publishedNodes, nil
+}
+
+func (store *nodeStateStore) update(ctx context.Context, nodeId string, f func(state *nodeState)) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	var state nodeState
+	if _, err := store.get(ctx, nodeId, &state); err != nil {
+		return fmt.Errorf("nodeStateStore.update: get state of node %s error: %w", nodeId, err)
+	}
+	state.NodeId = nodeId
+	if state.PublishedVolumes == nil {
+		state.PublishedVolumes = make(map[string][]string)
+	}
+	f(&state)
+	if err := store.save(ctx, nodeId, &state); err != nil {
+		return fmt.Errorf("nodeStateStore.update: save state of node %s error: %w", nodeId, err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodeStateStore_PublishedVolumes(t *testing.T) {
+	ctx := context.Background()
+	store := newNodeStateStore(fake.NewSimpleClientset(), "kube-system", KodoDriverName)
+
+	assert.NoError(t, store.AddPublishedVolume(ctx, "node-1", "kodo-1", "/target/pod-1"))
+	assert.NoError(t, store.AddPublishedVolume(ctx, "node-1", "kodo-1", "/target/pod-2"))
+	// 重复发布是幂等的
+	assert.NoError(t, store.AddPublishedVolume(ctx, "node-1", "kodo-1", "/target/pod-2"))
+	assert.NoError(t, store.AddPublishedVolume(ctx, "node-2", "kodo-1", "/target/pod-3"))
+	assert.NoError(t, store.AddPublishedVolume(ctx, "node-2", "kodo-2", "/target/pod-3"))
+
+	publishedNodes, err := store.ListPublishedNodes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node-1", "node-2"}, publishedNodes["kodo-1"])
+	assert.Equal(t, []string{"node-2"}, publishedNodes["kodo-2"])
+
+	// 节点上仍然有其他挂载路径时，存储卷仍然被发布在该节点上
+	assert.NoError(t, store.RemovePublishedVolume(ctx, "node-1", "kodo-1", "/target/pod-1"))
+	assert.NoError(t, store.RemovePublishedVolume(ctx, "node-2", "kodo-1", "/target/pod-3"))
+	publishedNodes, err = store.ListPublishedNodes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node-1"}, publishedNodes["kodo-1"])
+
+	assert.NoError(t, store.RemovePublishedVolume(ctx, "node-1", "kodo-1", "/target/pod-2"))
+	publishedNodes, err = store.ListPublishedNodes(ctx)
+	assert.NoError(t, err)
+	assert.NotContains(t, publishedNodes, "kodo-1")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/client-go/kubernetes"
+)
+
+// snapshotState 记录快照所在的位置和复制进度
+type snapshotState struct {
+	SnapshotId     string `json:"snapshot_id"`
+	SourceVolumeId string `json:"source_volume_id"`
+	// 创建快照时源存储卷的参数，源存储卷被删除后仍然可以用来访问快照
+	SourceVolumeContext map[string]string `json:"source_volume_context,omitempty"`
+	BucketName          string            `json:"bucket_name"`
+	Prefix              string            `json:"prefix,omitempty"`
+	// 快照独占的 bucket 由插件创建，删除快照时将一并删除
+	DedicatedBucket bool      `json:"dedicated_bucket,omitempty"`
+	SizeBytes       int64     `json:"size_bytes,omitempty"`
+	CreationTime    time.Time `json:"creation_time"`
+	ReadyToUse      bool      `json:"ready_to_use"`
+	// 复制对象的进度，Provisioner 重启或切换 leader 后从中断处继续复制
+	CopyProgress *qiniu.CopyProgress `json:"copy_progress,omitempty"`
+}
+
+func (state *snapshotState) toSnapshot() *csi.Snapshot {
+	return &csi.Snapshot{
+		SizeBytes:      state.SizeBytes,
+		SnapshotId:     state.SnapshotId,
+		SourceVolumeId: state.SourceVolumeId,
+		CreationTime:   timestamppb.New(state.CreationTime),
+		ReadyToUse:     state.ReadyToUse,
+	}
+}
+
+// snapshotStateStore 与 volumeStateStore 一样将快照状态保存在 ConfigMap 中，凭证保存在 Secret 中，但使用单独的标签
+type snapshotStateStore struct {
+	*volumeStateStore
+}
+
+func newSnapshotStateStore(client kubernetes.Interface, namespace, driverName string) *snapshotStateStore {
+	return &snapshotStateStore{newVolumeStateStore(client, namespace, driverName+"-snapshot")}
+}
+
+// Get 获取快照的状态，如果不存在则返回 nil
+func (store *snapshotStateStore) Get(ctx context.Context, snapshotId string) (*snapshotState, error) {
+	var state snapshotState
+	if found, err := store.get(ctx, snapshotId, &state); err != nil {
+		return nil, fmt.Errorf("snapshotStateStore.Get: get state of snapshot %s error: %w", snapshotId, err)
+	} else if !found {
+		return nil, nil
+	}
+	credentials, err := store.getCredentials(ctx, snapshotId)
+	if err != nil {
+		return nil, fmt.Errorf("snapshotStateStore.Get: get credentials of snapshot %s error: %w", snapshotId, err)
+	}
+	state.SourceVolumeContext = mergeCredentials(state.SourceVolumeContext, credentials)
+	return &state, nil
+}
+
+// Save 创建或更新快照的状态，源存储卷参数中的凭证先保存到 Secret 中
+func (store *snapshotStateStore) Save(ctx context.Context, state *snapshotState) error {
+	saved := *state
+	var credentials map[string]string
+	saved.SourceVolumeContext, credentials = splitCredentials(state.SourceVolumeContext)
+	if err := store.saveCredentials(ctx, state.SnapshotId, credentials); err != nil {
+		return fmt.Errorf("snapshotStateStore.Save: save credentials of snapshot %s error: %w", state.SnapshotId, err)
+	}
+	if err := store.save(ctx, state.SnapshotId, &saved); err != nil {
+		return fmt.Errorf("snapshotStateStore.Save: save state of snapshot %s error: %w", state.SnapshotId, err)
+	}
+	return nil
+}
+
+// List 列出当前驱动的所有快照状态
+func (store *snapshotStateStore) List(ctx context.Context) ([]*snapshotState, error) {
+	configMaps, err := store.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := store.listCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*snapshotState, 0, len(configMaps))
+	for _, configMap := range configMaps {
+		var state snapshotState
+		if err = parseConfigMapData(configMap, &state); err != nil {
+			return nil, err
+		}
+		state.SourceVolumeContext = mergeCredentials(state.SourceVolumeContext, credentials[state.SnapshotId])
+		states = append(states, &state)
+	}
+	return states, nil
+}
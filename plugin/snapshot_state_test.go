@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSnapshotStateStore(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	volumeStore := newVolumeStateStore(clientset, "kube-system", KodoDriverName)
+	snapshotStore := newSnapshotStateStore(clientset, "kube-system", KodoDriverName)
+
+	state, err := snapshotStore.Get(ctx, "snapshot-1")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	creationTime := time.Unix(1700000000, 0)
+	assert.NoError(t, volumeStore.Save(ctx, newVolumeState("kodo-1")))
+	assert.NoError(t, snapshotStore.Save(ctx, &snapshotState{
+		SnapshotId:     "snapshot-1",
+		SourceVolumeId: "kodo-1",
+		SourceVolumeContext: map[string]string{
+			FIELD_BUCKET_NAME:         "kodo-1",
+			FIELD_ORIGINAL_ACCESS_KEY: "original-ak",
+		},
+		BucketName:      "snapshot-1",
+		DedicatedBucket: true,
+		CreationTime:    creationTime,
+	}))
+
+	state, err = snapshotStore.Get(ctx, "snapshot-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "kodo-1", state.SourceVolumeId)
+	assert.Equal(t, "original-ak", state.SourceVolumeContext[FIELD_ORIGINAL_ACCESS_KEY])
+	assert.True(t, state.DedicatedBucket)
+	assert.False(t, state.ReadyToUse)
+	snapshot := state.toSnapshot()
+	assert.Equal(t, "snapshot-1", snapshot.GetSnapshotId())
+	assert.Equal(t, creationTime.Unix(), snapshot.GetCreationTime().GetSeconds())
+	assert.Nil(t, state.CopyProgress)
+
+	// 复制进度随状态一起保存，切换 leader 后可以从中断处继续
+	state.CopyProgress = &qiniu.CopyProgress{LastObjectName: "dir/file-0100", CopiedBytes: 1 << 20}
+	assert.NoError(t, snapshotStore.Save(ctx, state))
+	state, err = snapshotStore.Get(ctx, "snapshot-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &qiniu.CopyProgress{LastObjectName: "dir/file-0100", CopiedBytes: 1 << 20}, state.CopyProgress)
+
+	// 快照状态与存储卷状态互不影响
+	snapshots, err := snapshotStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	volumes, err := volumeStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, volumes, 1)
+
+	assert.NoError(t, snapshotStore.Delete(ctx, "snapshot-1"))
+	state, err = snapshotStore.Get(ctx, "snapshot-1")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
@@ -3,23 +3,30 @@ package main
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"math/rand"
-	"net"
-	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
 	"github.com/moby/sys/mountinfo"
 	"github.com/qiniu/kubernetes-csi-driver/protocol"
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const LOG_DIR_PATH = "/var/log/qiniu/storage/csi-plugin/"
@@ -63,13 +70,6 @@ func setLogAttribute(driverName string) {
 	}
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	time := time.Now()
-	message := "Liveness probe is OK, time:" + time.String()
-	w.Write([]byte(message))
-}
-
 func ensureCommandExists(name string) error {
 	_, err := exec.LookPath(name)
 	if err != nil {
@@ -94,10 +94,6 @@ func ensureDirectoryCreated(path string) error {
 	return nil
 }
 
-const (
-	SocketPath = "/var/lib/qiniu/storage/csi-plugin/connector.sock"
-)
-
 func redirectToLog(logPrefix string, reader io.Reader) {
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
@@ -156,9 +152,9 @@ func mountKodoFSLocally(ctx context.Context, volumeId, gatewayID, mountPath stri
 
 	go func(ctx context.Context, input io.Writer, output <-chan string) {
 		for text := range output {
-			if strings.Contains(text, "please enter the master address(separate multiple addresses with commas):") {
+			if strings.Contains(text, protocol.KodoFSMasterAddressPrompt) {
 				io.WriteString(input, mountServerAddress.String()+"\n")
-			} else if strings.Contains(text, "please enter the AccessToken:") {
+			} else if strings.Contains(text, protocol.KodoFSAccessTokenPrompt) {
 				io.WriteString(input, accessToken+"\n")
 			} else {
 				log.Infof(protocol.KodoFSCmd+" mount stdout: %s", text)
@@ -169,95 +165,29 @@ func mountKodoFSLocally(ctx context.Context, volumeId, gatewayID, mountPath stri
 	return execCmd.Run()
 }
 
-func mountKodoFS(volumeId, gatewayID, mountPath string, mountServerAddress *url.URL, accessToken, subDir string) error {
-	conn, err := net.Dial("unix", SocketPath)
-	if err != nil {
-		return fmt.Errorf("failed to dial unix socket %s: %w", SocketPath, err)
-	}
-	defer conn.Close()
-
+func mountKodoFS(volumeId, gatewayID, mountPath string, mountServerAddress *url.URL, accessToken, subDir string, readOnly bool) error {
 	if subDir == "" {
 		subDir = "/"
 	} else if !strings.HasPrefix(subDir, "/") {
 		subDir = filepath.Join("/", subDir)
 	}
 
-	encoder := json.NewEncoder(conn)
-	decoder := json.NewDecoder(conn)
-
-	writeCmdToConn := func(encoder *json.Encoder, cmd protocol.Cmd) error {
-		buf, err := json.Marshal(cmd)
-		if err != nil {
-			return fmt.Errorf("failed to marshal json payload: %w", err)
-		}
-		switch cmd.(type) {
-		case *protocol.InitKodoFSMountCmd:
-			if err = encoder.Encode(makeRequest(protocol.InitKodoFsMountCmdName, buf)); err != nil {
-				return fmt.Errorf("failed to write command to unix socket %s: %w", SocketPath, err)
-			}
-		case *protocol.RequestDataCmd:
-			if err = encoder.Encode(makeRequest(protocol.RequestDataCmdName, buf)); err != nil {
-				return fmt.Errorf("failed to write command to unix socket %s: %w", SocketPath, err)
-			}
-		}
-		return nil
-	}
-
-	if err = writeCmdToConn(encoder, &protocol.InitKodoFSMountCmd{
+	cmd := &protocol.InitKodoFSMountCmd{
 		VolumeId:        volumeId,
 		GatewayID:       gatewayID,
 		MountPath:       mountPath,
 		SubDir:          subDir,
 		MayRunOnSystemd: true,
-	}); err != nil {
-		return err
-	}
-
-	for decoder.More() {
-		var request protocol.Request
-		if err = decoder.Decode(&request); err != nil {
-			return fmt.Errorf("failed to decode json request: %w", err)
-		}
-		if request.Version != protocol.Version {
-			return fmt.Errorf("unrecognized protocol version: %s", request.Version)
-		}
-		switch request.Cmd {
-		case protocol.ResponseDataCmdName:
-			var cmd protocol.ResponseDataCmd
-			if err = json.Unmarshal([]byte(request.Payload), &cmd); err != nil {
-				return fmt.Errorf("failed to marshal json payload: %w", err)
-			}
-			if cmd.IsError {
-				log.Warnf("kodofs mount stderr prompt: %s", cmd.Data)
-			} else if strings.Contains(cmd.Data, "please enter the master address(separate multiple addresses with commas):") {
-				if err = writeCmdToConn(encoder, &protocol.RequestDataCmd{
-					Data: mountServerAddress.String() + "\n",
-				}); err != nil {
-					return fmt.Errorf("failed to enter the master address: %w", err)
-				}
-			} else if strings.Contains(cmd.Data, "please enter the AccessToken:") {
-				if err = writeCmdToConn(encoder, &protocol.RequestDataCmd{
-					Data: accessToken + "\n",
-				}); err != nil {
-					return fmt.Errorf("failed to enter the AccessToken: %w", err)
-				}
-			} else {
-				log.Infof("kodofs mount stdout prompt: %s", cmd.Data)
-			}
-		case protocol.TerminateCmdName:
-			var cmd protocol.TerminateCmd
-			if err = json.Unmarshal([]byte(request.Payload), &cmd); err != nil {
-				return fmt.Errorf("failed to marshal json payload: %w", err)
-			}
-			if cmd.Code == 0 {
-				return nil
-			} else {
-				return fmt.Errorf("unexpected command returns code: %d", cmd.Code)
-			}
-		}
-	}
-
-	return nil
+		MasterAddress:   mountServerAddress.String(),
+		AccessToken:     accessToken,
+		ReadOnly:        readOnly,
+	}
+	return mountByConnector("kodofs", &connectorpb.MountRequest{
+		Mount: &connectorpb.MountRequest_Kodofs{Kodofs: cmd.ToProto()},
+	}, protocol.InitKodoFsMountCmdName, cmd, map[string]string{
+		protocol.KodoFSMasterAddressPrompt: mountServerAddress.String() + "\n",
+		protocol.KodoFSAccessTokenPrompt:   accessToken + "\n",
+	})
 }
 
 func mountKodo(volumeId, mountPath, subDir, accessKey, secretKey, bucketId string,
@@ -267,32 +197,9 @@ func mountKodo(volumeId, mountPath, subDir, accessKey, secretKey, bucketId strin
 	vfsFastFingerPrint bool, vfsReadChunkSize, vfsReadChunkSizeLimit *uint64,
 	noCheckSum, noModTime, noSeek, readOnly bool, vfsReadWait, vfsWriteWait *time.Duration,
 	transfers, vfsDiskSpaceTotalSize *uint64, writeBackCache bool,
-	uploadCutoff, uploadChunkSize, uploadConcurrency *uint64, debugHttp, debugFuse bool) error {
-
-	conn, err := net.Dial("unix", SocketPath)
-	if err != nil {
-		return fmt.Errorf("failed to dial unix socket %s: %w", SocketPath, err)
-	}
-	defer conn.Close()
+	uploadCutoff, uploadChunkSize, uploadConcurrency *uint64, debugHttp, debugFuse bool, mountOptions *kodoMountOptions) error {
 
-	encoder := json.NewEncoder(conn)
-	decoder := json.NewDecoder(conn)
-
-	writeCmdToConn := func(encoder *json.Encoder, cmd protocol.Cmd) error {
-		buf, err := json.Marshal(cmd)
-		if err != nil {
-			return fmt.Errorf("failed to marshal json payload: %w", err)
-		}
-		switch cmd.(type) {
-		case *protocol.InitKodoMountCmd:
-			if err = encoder.Encode(makeRequest(protocol.InitKodoMountCmdName, buf)); err != nil {
-				return fmt.Errorf("failed to write command to unix socket %s: %w", SocketPath, err)
-			}
-		}
-		return nil
-	}
-
-	cmd := protocol.InitKodoMountCmd{
+	cmd := &protocol.InitKodoMountCmd{
 		VolumeId:           volumeId,
 		MountPath:          mountPath,
 		SubDir:             subDir,
@@ -363,44 +270,18 @@ func mountKodo(volumeId, mountPath, subDir, accessKey, secretKey, bucketId strin
 	if uploadConcurrency != nil {
 		cmd.UploadConcurrency = uploadConcurrency
 	}
-
-	if err = writeCmdToConn(encoder, &cmd); err != nil {
-		return err
-	}
-
-	for decoder.More() {
-		var request protocol.Request
-		if err = decoder.Decode(&request); err != nil {
-			return fmt.Errorf("failed to decode json request: %w", err)
-		}
-		if request.Version != protocol.Version {
-			return fmt.Errorf("unrecognized protocol version: %s", request.Version)
-		}
-		switch request.Cmd {
-		case protocol.ResponseDataCmdName:
-			var cmd protocol.ResponseDataCmd
-			if err = json.Unmarshal([]byte(request.Payload), &cmd); err != nil {
-				return fmt.Errorf("failed to marshal json payload: %w", err)
-			}
-			if cmd.IsError {
-				log.Warnf("kodo mount stderr prompt: %s", cmd.Data)
-			} else {
-				log.Infof("kodo mount stdout prompt: %s", cmd.Data)
-			}
-		case protocol.TerminateCmdName:
-			var cmd protocol.TerminateCmd
-			if err = json.Unmarshal([]byte(request.Payload), &cmd); err != nil {
-				return fmt.Errorf("failed to marshal json payload: %w", err)
-			}
-			if cmd.Code == 0 {
-				return nil
-			} else {
-				return fmt.Errorf("unexpected command returns code: %d", cmd.Code)
-			}
-		}
+	if mountOptions != nil {
+		cmd.Uid = mountOptions.uid
+		cmd.Gid = mountOptions.gid
+		cmd.Umask = mountOptions.umask
+		cmd.DirPerms = mountOptions.dirPerms
+		cmd.FilePerms = mountOptions.filePerms
+		cmd.AllowOther = mountOptions.allowOther
 	}
 
-	return nil
+	return mountByConnector("kodo", &connectorpb.MountRequest{
+		Mount: &connectorpb.MountRequest_Kodo{Kodo: cmd.ToProto()},
+	}, protocol.InitKodoMountCmdName, cmd, nil)
 }
 
 func umount(mountPath string) error {
@@ -408,41 +289,99 @@ func umount(mountPath string) error {
 	return err
 }
 
-func cleanAfterKodoUmount(volumeId, mountPath string) error {
-	conn, err := net.Dial("unix", SocketPath)
-	if err != nil {
-		return fmt.Errorf("failed to dial unix socket %s: %w", SocketPath, err)
+// lazyUmount 懒卸载挂载点，用于 FUSE 进程已经退出的挂载点，无法访问的挂载点不能被立即卸载
+func lazyUmount(mountPath string) error {
+	if output, err := exec.Command("umount", "-l", mountPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
 	}
-	defer conn.Close()
+	return nil
+}
+
+// unmountKodo 等待缓存中的文件上传后卸载 Kodo 存储卷，并清理缓存和日志，返回缓存中的文件是否已经确认上传
+func unmountKodo(volumeId, mountPath string) (bool, error) {
+	return unmountByConnector("kodo", volumeId, mountPath, FuseTypeKodo, *unmountFlushTimeout, protocol.KodoUmountCmdName, &protocol.KodoUmountCmd{
+		VolumeId:  volumeId,
+		MountPath: mountPath,
+	})
+}
 
-	encoder := json.NewEncoder(conn)
+// unmountKodoFS 卸载 KodoFS 存储卷，connector 不再恢复这个挂载点
+func unmountKodoFS(volumeId, mountPath string) error {
+	_, err := unmountByConnector("kodofs", volumeId, mountPath, FuseTypeKodoFS, 0, protocol.KodoFSUmountCmdName, &protocol.KodoFSUmountCmd{
+		VolumeId:  volumeId,
+		MountPath: mountPath,
+	})
+	return err
+}
 
-	writeCmdToConn := func(encoder *json.Encoder, cmd protocol.Cmd) error {
-		buf, err := json.Marshal(cmd)
-		if err != nil {
-			return fmt.Errorf("failed to marshal json payload: %w", err)
+func newNodeServiceCapability(capability csi.NodeServiceCapability_RPC_Type) *csi.NodeServiceCapability {
+	return &csi.NodeServiceCapability{
+		Type: &csi.NodeServiceCapability_Rpc{
+			Rpc: &csi.NodeServiceCapability_RPC{Type: capability},
+		},
+	}
+}
+
+// SUPPORTED_ACCESS_MODES 驱动支持的访问模式，ReadOnlyMany 对应 MULTI_NODE_READER_ONLY，ReadWriteOnce 对应 SINGLE_NODE_WRITER
+var SUPPORTED_ACCESS_MODES = []csi.VolumeCapability_AccessMode_Mode{
+	csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+	csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+	csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+	csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
+}
+
+// validateVolumeCapabilities 检查请求的访问模式是否被驱动支持，只支持文件系统类型的存储卷
+func validateVolumeCapabilities(driver *csicommon.CSIDriver, capabilities []*csi.VolumeCapability) error {
+	for _, capability := range capabilities {
+		if capability.GetBlock() != nil {
+			return errors.New("block volume is not supported")
 		}
-		switch cmd.(type) {
-		case *protocol.KodoUmountCmd:
-			if err = encoder.Encode(makeRequest(protocol.KodoUmountCmdName, buf)); err != nil {
-				return fmt.Errorf("failed to write command to unix socket %s: %w", SocketPath, err)
+		mode := capability.GetAccessMode().GetMode()
+		supported := false
+		for _, accessMode := range driver.GetVolumeCapabilityAccessModes() {
+			if accessMode.GetMode() == mode {
+				supported = true
+				break
 			}
 		}
-		return nil
+		if !supported {
+			return fmt.Errorf("access mode %s is not supported", mode)
+		}
 	}
+	return nil
+}
 
-	cmd := protocol.KodoUmountCmd{
-		VolumeId:  volumeId,
-		MountPath: mountPath,
-	}
-	return writeCmdToConn(encoder, &cmd)
+// validateVolumeCapabilitiesOf 确认存储卷存在，并且驱动支持请求的所有访问模式
+// 不支持时返回的响应中 Confirmed 为空，Message 说明原因
+func validateVolumeCapabilitiesOf(ctx context.Context, functionName, pluginType string, store *volumeStateStore, driver *csicommon.CSIDriver,
+	req *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
+	if req.GetVolumeId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: volume id is empty", functionName)
+	} else if len(req.GetVolumeCapabilities()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: volume capabilities are empty", functionName)
+	}
+	if _, err := store.GetVolumeContext(ctx, pluginType, req.GetVolumeId()); err != nil {
+		return nil, wrapStatusError(err, "%s", functionName)
+	}
+	if err := validateVolumeCapabilities(driver, req.GetVolumeCapabilities()); err != nil {
+		return &csi.ValidateVolumeCapabilitiesResponse{Message: err.Error()}, nil
+	}
+	return &csi.ValidateVolumeCapabilitiesResponse{
+		Confirmed: &csi.ValidateVolumeCapabilitiesResponse_Confirmed{
+			VolumeContext:      req.GetVolumeContext(),
+			VolumeCapabilities: req.GetVolumeCapabilities(),
+			Parameters:         req.GetParameters(),
+		},
+	}, nil
 }
 
-func makeRequest(cmdName string, buf []byte) *protocol.Request {
-	return &protocol.Request{
-		Version: protocol.Version,
-		Cmd:     cmdName,
-		Payload: json.RawMessage(buf),
+// isReadOnlyAccessMode 判断访问模式是否只允许读取，这样的存储卷需要以只读方式挂载
+func isReadOnlyAccessMode(capability *csi.VolumeCapability) bool {
+	switch capability.GetAccessMode().GetMode() {
+	case csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY, csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -461,10 +400,9 @@ func isKodoMounted(mountPath string) (bool, error) {
 
 func isMounted(mountPath, fsType string) (bool, error) {
 	info, err := mountinfo.GetMounts(func(i *mountinfo.Info) (skip bool, stop bool) {
-		// 全都不跳过
-		skip = false
-		// 找到了就直接停止
+		// 只保留匹配的挂载点，找到了就直接停止
 		stop = i.Mountpoint == mountPath && i.FSType == fsType
+		skip = !stop
 		return
 	})
 
@@ -475,13 +413,99 @@ func isMounted(mountPath, fsType string) (bool, error) {
 	return len(info) > 0, nil
 }
 
-func randomPassword(n int) string {
-	const choices = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789~`!@#$%^&*()-=_+[];'<,>.?/\\\""
-	return randomChoices(choices, n)
+// FUSE 进程退出后，访问挂载点会返回这些错误
+func isStaleMountError(err error) bool {
+	return errors.Is(err, unix.ENOTCONN) || errors.Is(err, unix.ECONNABORTED) || errors.Is(err, unix.EIO)
+}
+
+// prepareMountPoint 准备挂载点，返回挂载点是否已经被 fsType 正常挂载
+// 挂载点路径中包含 PV 名称，被同一类型的 FUSE 挂载说明挂载的就是这个存储卷，无需重新挂载
+// 如果之前的 FUSE 进程已经退出导致挂载点损坏，则先懒卸载，之后可以重新挂载
+func prepareMountPoint(functionName, mountPath, fsType string) (bool, error) {
+	mounted, err := isMounted(mountPath, fsType)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", functionName, err)
+	}
+	if mounted {
+		if _, err = os.Stat(mountPath); err == nil {
+			return true, nil
+		} else if !isStaleMountError(err) {
+			return false, fmt.Errorf("%s: stat mount path %s error: %w", functionName, mountPath, err)
+		}
+		log.Warnf("%s: mount path %s is stale: %s, unmount it lazily", functionName, mountPath, err)
+		if err = lazyUmount(mountPath); err != nil {
+			return false, fmt.Errorf("%s: failed to unmount stale mount path %s: %w", functionName, mountPath, err)
+		}
+	}
+	if err = ensureDirectoryCreated(mountPath); err != nil {
+		return false, fmt.Errorf("%s: create mount path %s error: %w", functionName, mountPath, err)
+	}
+	return false, nil
+}
+
+// getMountedCapacity 返回挂载点通过 statfs 报告的总容量，rclone 报告的是挂载时的 vfsdiskspacetotalsize
+// 总容量按块向下取整，这里补齐最后一个不完整的块
+func getMountedCapacity(mountPath string) (int64, error) {
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(mountPath, &statfs); err != nil {
+		return 0, fmt.Errorf("getMountedCapacity: statfs %s error: %w", mountPath, err)
+	}
+	return int64(statfs.Blocks+1) * int64(statfs.Bsize), nil
+}
+
+// getVolumeStats 通过 statfs 获取挂载点的容量和 inode 使用情况
+// 挂载点没有被挂载，或者 FUSE 进程已经退出导致挂载点失效时，返回异常的 VolumeCondition
+func getVolumeStats(functionName, volumePath, fsType string) (*csi.NodeGetVolumeStatsResponse, error) {
+	if mounted, err := isMounted(volumePath, fsType); err != nil {
+		return nil, fmt.Errorf("%s: %w", functionName, err)
+	} else if !mounted {
+		if _, err = os.Stat(volumePath); errors.Is(err, os.ErrNotExist) {
+			return nil, status.Errorf(codes.NotFound, "%s: volume path %s does not exist", functionName, volumePath)
+		}
+		return &csi.NodeGetVolumeStatsResponse{
+			VolumeCondition: &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("%s is not mounted", volumePath)},
+		}, nil
+	}
+
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(volumePath, &statfs); isStaleMountError(err) {
+		return &csi.NodeGetVolumeStatsResponse{
+			VolumeCondition: &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("%s is stale: %s", volumePath, err)},
+		}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: failed to statfs %s: %w", functionName, volumePath, err)
+	}
+	blockSize := int64(statfs.Bsize)
+	return &csi.NodeGetVolumeStatsResponse{
+		Usage: []*csi.VolumeUsage{
+			{
+				Unit:      csi.VolumeUsage_BYTES,
+				Total:     int64(statfs.Blocks) * blockSize,
+				Available: int64(statfs.Bavail) * blockSize,
+				Used:      int64(statfs.Blocks-statfs.Bfree) * blockSize,
+			},
+			{
+				Unit:      csi.VolumeUsage_INODES,
+				Total:     int64(statfs.Files),
+				Available: int64(statfs.Ffree),
+				Used:      int64(statfs.Files - statfs.Ffree),
+			},
+		},
+		VolumeCondition: &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"},
+	}, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
-func randomBucketName(n int) string {
-	const choices = "abcdefghijklmnopqrstuvwxyz0123456789"
+func randomPassword(n int) string {
+	const choices = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789~`!@#$%^&*()-=_+[];'<,>.?/\\\""
 	return randomChoices(choices, n)
 }
 
@@ -496,6 +520,111 @@ func randomChoices(choices string, n int) string {
 	return string(b)
 }
 
+// subDirPrefix 将 subdir 转换为对象名称的前缀
+func subDirPrefix(subDir string) string {
+	if subDir = strings.Trim(subDir, "/"); subDir == "" {
+		return ""
+	}
+	return subDir + "/"
+}
+
+// SUB_DIR_TEMPLATE_VARIABLES subdirTemplate 中可以使用的变量，以及其在 NodePublishVolume 参数中对应的键
+var SUB_DIR_TEMPLATE_VARIABLES = map[string]string{
+	"pod.name":            CONTEXT_POD_NAME,
+	"pod.namespace":       CONTEXT_POD_NAMESPACE,
+	"pod.uid":             CONTEXT_POD_UID,
+	"serviceAccount.name": CONTEXT_SERVICE_ACCOUNT_NAME,
+}
+
+// expandSubDirTemplate 使用 podInfoOnMount 传入的 Pod 信息展开 subdirTemplate，如 ${pod.namespace}/${pod.name}
+// 展开后的路径是相对于 subdir 的路径，不允许使用未知的变量，变量的值不能为空或者包含路径分隔符
+func expandSubDirTemplate(subDirTemplate string, volumeContext map[string]string) (string, error) {
+	var expandErr error
+	expanded := os.Expand(subDirTemplate, func(name string) string {
+		key, ok := SUB_DIR_TEMPLATE_VARIABLES[name]
+		if !ok {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("unknown variable %s", name)
+			}
+			return ""
+		}
+		value := volumeContext[key]
+		if value == "" || value == "." || value == ".." || strings.Contains(value, "/") {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("invalid value %q of variable %s, please enable podInfoOnMount of CSIDriver", value, name)
+			}
+			return ""
+		}
+		return value
+	})
+	if expandErr != nil {
+		return "", fmt.Errorf("expandSubDirTemplate: invalid subdir template %s: %w", subDirTemplate, expandErr)
+	}
+	expanded = strings.Trim(path.Clean("/"+expanded), "/")
+	if expanded == "" {
+		return "", fmt.Errorf("expandSubDirTemplate: subdir template %s is expanded to empty path", subDirTemplate)
+	}
+	return expanded, nil
+}
+
 func normalizePolicyName(s string) string {
 	return strings.ReplaceAll(s, "-", "")
 }
+
+const (
+	BUCKET_NAME_MIN_LENGTH  = 3
+	BUCKET_NAME_MAX_LENGTH  = 63
+	BUCKET_NAME_HASH_LENGTH = 8
+)
+
+type bucketNameTemplateData struct {
+	PVName    string
+	PVCName   string
+	Namespace string
+}
+
+// makeBucketName 根据 PV 名称或 bucketNameTemplate 生成 bucket 名称
+// 生成的名称只取决于输入，因此 CreateVolume 重试时总能找到之前创建的 bucket
+func makeBucketName(nameTemplate string, data bucketNameTemplateData) (string, error) {
+	name := data.PVName
+	if nameTemplate != "" {
+		if data.PVCName == "" || data.Namespace == "" {
+			return "", fmt.Errorf("makeBucketName: PVC name or namespace is empty, please enable --extra-create-metadata for csi-provisioner")
+		}
+		tmpl, err := template.New("bucketName").Parse(nameTemplate)
+		if err != nil {
+			return "", fmt.Errorf("makeBucketName: invalid bucket name template %s: %w", nameTemplate, err)
+		}
+		var buf strings.Builder
+		if err = tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("makeBucketName: failed to execute bucket name template %s: %w", nameTemplate, err)
+		}
+		name = buf.String()
+	}
+	return normalizeBucketName(name), nil
+}
+
+// normalizeBucketName 使名称满足 Kodo 的命名规则：只包含小写字母、数字和短划线，以字母或数字开头和结尾，长度为 3~63
+// 名称过长或过短时，会追加原始名称的哈希值以避免冲突
+func normalizeBucketName(name string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+	normalized = strings.Trim(normalized, "-")
+
+	if len(normalized) >= BUCKET_NAME_MIN_LENGTH && len(normalized) <= BUCKET_NAME_MAX_LENGTH {
+		return normalized
+	}
+	hash := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(hash[:])[:BUCKET_NAME_HASH_LENGTH]
+	if maxPrefixLength := BUCKET_NAME_MAX_LENGTH - BUCKET_NAME_HASH_LENGTH - 1; len(normalized) > maxPrefixLength {
+		normalized = strings.TrimRight(normalized[:maxPrefixLength], "-")
+	}
+	if normalized == "" {
+		return suffix
+	}
+	return normalized + "-" + suffix
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMakeBucketName(t *testing.T) {
+	const pvName = "kodo-6f1bd5e8-1f6a-4b5e-9d3a-0c8f1f2f6c1e"
+
+	// 未配置模板时直接使用 PV 名称，且多次生成的结果一致
+	name, err := makeBucketName("", bucketNameTemplateData{PVName: pvName})
+	assert.NoError(t, err)
+	assert.Equal(t, pvName, name)
+
+	data := bucketNameTemplateData{PVName: pvName, PVCName: "Data_Set.01", Namespace: "ml-team"}
+	name, err = makeBucketName("{{.Namespace}}-{{.PVCName}}", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "ml-team-data-set-01", name)
+
+	_, err = makeBucketName("{{.Namespace}}-{{.PVCName}}", bucketNameTemplateData{PVName: pvName})
+	assert.Error(t, err)
+
+	_, err = makeBucketName("{{.Namespace", data)
+	assert.Error(t, err)
+}
+
+func TestNormalizeBucketName(t *testing.T) {
+	assert.Equal(t, "abc", normalizeBucketName("abc"))
+	assert.Equal(t, "abc-def", normalizeBucketName("-ABC_def-"))
+
+	short := normalizeBucketName("a")
+	assert.True(t, strings.HasPrefix(short, "a-"))
+	assert.Len(t, short, 1+1+BUCKET_NAME_HASH_LENGTH)
+
+	longName := strings.Repeat("a", 100)
+	long := normalizeBucketName(longName)
+	assert.LessOrEqual(t, len(long), BUCKET_NAME_MAX_LENGTH)
+	assert.Equal(t, long, normalizeBucketName(longName))
+	assert.NotEqual(t, long, normalizeBucketName(longName+"b"))
+}
+
+func TestSubDirPrefix(t *testing.T) {
+	assert.Equal(t, "", subDirPrefix(""))
+	assert.Equal(t, "", subDirPrefix("/"))
+	assert.Equal(t, "data/", subDirPrefix("data"))
+	assert.Equal(t, "data/train/", subDirPrefix("/data/train/"))
+}
+
+func TestGetVolumeStatsNotMounted(t *testing.T) {
+	volumePath := t.TempDir()
+
+	// 挂载点存在但没有被挂载
+	resp, err := getVolumeStats("NodeGetVolumeStats", volumePath, FuseTypeKodo)
+	assert.NoError(t, err)
+	assert.Empty(t, resp.GetUsage())
+	assert.True(t, resp.GetVolumeCondition().GetAbnormal())
+
+	_, err = getVolumeStats("NodeGetVolumeStats", filepath.Join(volumePath, "not-exists"), FuseTypeKodo)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestPrepareMountPointNotMounted(t *testing.T) {
+	mountPath := filepath.Join(t.TempDir(), "mount")
+
+	// 挂载点不存在时会被创建
+	mounted, err := prepareMountPoint("NodePublishVolume", mountPath, FuseTypeKodo)
+	assert.NoError(t, err)
+	assert.False(t, mounted)
+	assert.DirExists(t, mountPath)
+
+	mounted, err = prepareMountPoint("NodePublishVolume", mountPath, FuseTypeKodoFS)
+	assert.NoError(t, err)
+	assert.False(t, mounted)
+}
+
+func TestExpandSubDirTemplate(t *testing.T) {
+	volumeContext := map[string]string{
+		CONTEXT_POD_NAME:             "web-0",
+		CONTEXT_POD_NAMESPACE:        "default",
+		CONTEXT_SERVICE_ACCOUNT_NAME: "web",
+	}
+
+	subDir, err := expandSubDirTemplate("${pod.namespace}/${pod.name}", volumeContext)
+	assert.NoError(t, err)
+	assert.Equal(t, "default/web-0", subDir)
+
+	subDir, err = expandSubDirTemplate("/users/${serviceAccount.name}/../${pod.name}/", volumeContext)
+	assert.NoError(t, err)
+	assert.Equal(t, "users/web-0", subDir)
+
+	// 未知的变量，Pod 信息缺失，以及展开后为空
+	for _, subDirTemplate := range []string{"${pod.ip}", "${pod.uid}", "/", "$pod.name/.."} {
+		_, err = expandSubDirTemplate(subDirTemplate, volumeContext)
+		assert.Error(t, err, subDirTemplate)
+	}
+	_, err = expandSubDirTemplate("${pod.name}", map[string]string{CONTEXT_POD_NAME: ".."})
+	assert.Error(t, err)
+}
+
+func TestValidateVolumeCapabilities(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	store := newVolumeStateStore(clientset, "kube-system", KodoDriverName)
+	state := newVolumeState("kodo-1")
+	state.Phase = volumePhaseCreated
+	assert.NoError(t, store.Save(ctx, state))
+
+	driver := csicommon.NewCSIDriver(TypePluginKodo, "test", "node-1")
+	driver.AddVolumeCapabilityAccessModes(SUPPORTED_ACCESS_MODES)
+	capability := func(mode csi.VolumeCapability_AccessMode_Mode) *csi.VolumeCapability {
+		return &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: mode},
+		}
+	}
+
+	resp, err := validateVolumeCapabilitiesOf(ctx, "ValidateVolumeCapabilities", TypePluginKodo, store, driver, &csi.ValidateVolumeCapabilitiesRequest{
+		VolumeId: "kodo-1",
+		VolumeCapabilities: []*csi.VolumeCapability{
+			capability(csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY),
+			capability(csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER),
+		},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.GetConfirmed())
+
+	// 不支持的访问模式和块设备
+	for _, unsupported := range []*csi.VolumeCapability{
+		capability(csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER),
+		{AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}},
+	} {
+		resp, err = validateVolumeCapabilitiesOf(ctx, "ValidateVolumeCapabilities", TypePluginKodo, store, driver, &csi.ValidateVolumeCapabilitiesRequest{
+			VolumeId:           "kodo-1",
+			VolumeCapabilities: []*csi.VolumeCapability{unsupported},
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, resp.GetConfirmed())
+		assert.NotEmpty(t, resp.GetMessage())
+	}
+
+	_, err = validateVolumeCapabilitiesOf(ctx, "ValidateVolumeCapabilities", TypePluginKodo, store, driver, &csi.ValidateVolumeCapabilitiesRequest{
+		VolumeId:           "kodo-2",
+		VolumeCapabilities: []*csi.VolumeCapability{capability(csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER)},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.True(t, isReadOnlyAccessMode(capability(csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY)))
+	assert.False(t, isReadOnlyAccessMode(capability(csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER)))
+	assert.False(t, isReadOnlyAccessMode(nil))
+}
+
+func TestGetMountedCapacity(t *testing.T) {
+	capacity, err := getMountedCapacity(t.TempDir())
+	assert.NoError(t, err)
+	assert.Greater(t, capacity, int64(0))
+
+	_, err = getMountedCapacity(filepath.Join(t.TempDir(), "not-exists"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/qiniu/kubernetes-csi-driver/qiniu"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+type volumePhase string
+
+const (
+	// 存储卷正在创建中，部分资源可能已经创建
+	volumePhaseCreating volumePhase = "creating"
+	// 存储卷已经创建完毕
+	volumePhaseCreated volumePhase = "created"
+)
+
+const (
+	VOLUME_STATE_DATA_KEY     = "state"
+	VOLUME_STATE_LABEL_DRIVER = "storage.qiniu.com/csi-driver"
+	VOLUME_STATE_LABEL_VOLUME = "storage.qiniu.com/csi-volume"
+)
+
+// 存储卷参数中的凭证不保存在 ConfigMap 中，而是保存在与 ConfigMap 同名的 Secret 中
+var volumeContextCredentialKeys = []string{FIELD_ACCESS_KEY, FIELD_SECRET_KEY, FIELD_ORIGINAL_ACCESS_KEY, FIELD_ORIGINAL_SECRET_KEY}
+
+// volumeState 记录动态创建存储卷的进度
+// Checkpoints 中记录已经创建成功的资源，重试的 CreateVolume 将复用这些资源而不是重新创建
+type volumeState struct {
+	VolumeId      string            `json:"volume_id"`
+	Phase         volumePhase       `json:"phase"`
+	Checkpoints   map[string]string `json:"checkpoints,omitempty"`
+	CapacityBytes int64             `json:"capacity_bytes,omitempty"`
+	VolumeContext map[string]string `json:"volume_context,omitempty"`
+	// 从数据源复制对象的进度，Provisioner 重启或切换 leader 后从中断处继续复制
+	CopyProgress *qiniu.CopyProgress `json:"copy_progress,omitempty"`
+	// PV 的回收策略，由回收器从 PV 中同步，PV 被删除后据此判断资源是否可以回收
+	ReclaimPolicy corev1.PersistentVolumeReclaimPolicy `json:"reclaim_policy,omitempty"`
+}
+
+func newVolumeState(volumeId string) *volumeState {
+	return &volumeState{
+		VolumeId:    volumeId,
+		Phase:       volumePhaseCreating,
+		Checkpoints: make(map[string]string),
+	}
+}
+
+func (state *volumeState) getCheckpoint(key string) (string, bool) {
+	value, ok := state.Checkpoints[key]
+	return value, ok
+}
+
+func (state *volumeState) toVolume() *csi.Volume {
+	return &csi.Volume{
+		CapacityBytes: state.CapacityBytes,
+		VolumeId:      state.VolumeId,
+		VolumeContext: state.VolumeContext,
+	}
+}
+
+// volumeStateStore 将 volumeState 持久化到 Kubernetes ConfigMap 中，每个存储卷对应一个 ConfigMap
+// 存储卷参数中的凭证保存在同名的 Secret 中，读取状态时再合并回参数
+type volumeStateStore struct {
+	client     kubernetes.Interface
+	namespace  string
+	driverName string
+}
+
+func newVolumeStateStore(client kubernetes.Interface, namespace, driverName string) *volumeStateStore {
+	return &volumeStateStore{client: client, namespace: namespace, driverName: driverName}
+}
+
+func (store *volumeStateStore) configMapName(volumeId string) string {
+	return fmt.Sprintf("%s-state-%s", store.driverName, volumeId)
+}
+
+// Get 获取存储卷的状态，如果不存在则返回 nil
+func (store *volumeStateStore) Get(ctx context.Context, volumeId string) (*volumeState, error) {
+	var state volumeState
+	if found, err := store.get(ctx, volumeId, &state); err != nil {
+		return nil, fmt.Errorf("volumeStateStore.Get: get state of volume %s error: %w", volumeId, err)
+	} else if !found {
+		return nil, nil
+	}
+	if state.Checkpoints == nil {
+		state.Checkpoints = make(map[string]string)
+	}
+	credentials, err := store.getCredentials(ctx, volumeId)
+	if err != nil {
+		return nil, fmt.Errorf("volumeStateStore.Get: get credentials of volume %s error: %w", volumeId, err)
+	}
+	state.VolumeContext = mergeCredentials(state.VolumeContext, credentials)
+	return &state, nil
+}
+
+// Save 创建或更新存储卷的状态，参数中的凭证先保存到 Secret 中
+func (store *volumeStateStore) Save(ctx context.Context, state *volumeState) error {
+	saved := *state
+	var credentials map[string]string
+	saved.VolumeContext, credentials = splitCredentials(state.VolumeContext)
+	if err := store.saveCredentials(ctx, state.VolumeId, credentials); err != nil {
+		return fmt.Errorf("volumeStateStore.Save: save credentials of volume %s error: %w", state.VolumeId, err)
+	}
+	if err := store.save(ctx, state.VolumeId, &saved); err != nil {
+		return fmt.Errorf("volumeStateStore.Save: save state of volume %s error: %w", state.VolumeId, err)
+	}
+	return nil
+}
+
+// SaveCheckpoint 记录一个已经创建成功的资源并立即持久化
+func (store *volumeStateStore) SaveCheckpoint(ctx context.Context, state *volumeState, key, value string) error {
+	state.Checkpoints[key] = value
+	return store.Save(ctx, state)
+}
+
+// Delete 删除存储卷的状态，如果不存在则直接返回
+func (store *volumeStateStore) Delete(ctx context.Context, volumeId string) error {
+	err := store.client.CoreV1().ConfigMaps(store.namespace).Delete(ctx, store.configMapName(volumeId), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("volumeStateStore.Delete: delete configmap of volume %s error: %w", volumeId, err)
+	}
+	err = store.client.CoreV1().Secrets(store.namespace).Delete(ctx, store.configMapName(volumeId), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("volumeStateStore.Delete: delete secret of volume %s error: %w", volumeId, err)
+	}
+	return nil
+}
+
+// List 列出当前驱动的所有存储卷状态
+func (store *volumeStateStore) List(ctx context.Context) ([]*volumeState, error) {
+	configMaps, err := store.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := store.listCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*volumeState, 0, len(configMaps))
+	for _, configMap := range configMaps {
+		var state volumeState
+		if err = parseConfigMapData(configMap, &state); err != nil {
+			return nil, err
+		}
+		if state.Checkpoints == nil {
+			state.Checkpoints = make(map[string]string)
+		}
+		state.VolumeContext = mergeCredentials(state.VolumeContext, credentials[state.VolumeId])
+		states = append(states, &state)
+	}
+	return states, nil
+}
+
+// GetVolumeContext 获取存储卷的参数，动态创建的存储卷从状态中获取，静态存储卷从与 VolumeHandle 同名的 PV 中获取
+func (store *volumeStateStore) GetVolumeContext(ctx context.Context, pluginType, volumeId string) (map[string]string, error) {
+	if state, err := store.Get(ctx, volumeId); err != nil {
+		return nil, fmt.Errorf("volumeStateStore.GetVolumeContext: get state of volume %s error: %w", volumeId, err)
+	} else if state != nil && state.Phase == volumePhaseCreated {
+		return state.VolumeContext, nil
+	}
+
+	pv, err := store.client.CoreV1().PersistentVolumes().Get(ctx, volumeId, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, status.Errorf(codes.NotFound, "volumeStateStore.GetVolumeContext: volume %s is not found", volumeId)
+	} else if err != nil {
+		return nil, fmt.Errorf("volumeStateStore.GetVolumeContext: get persistent volume %s error: %w", volumeId, err)
+	} else if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != pluginType || pv.Spec.CSI.VolumeHandle != volumeId {
+		return nil, status.Errorf(codes.NotFound, "volumeStateStore.GetVolumeContext: persistent volume %s is not a volume of %s with the same volume handle", volumeId, pluginType)
+	}
+	return pv.Spec.CSI.VolumeAttributes, nil
+}
+
+// get 将 id 对应的 ConfigMap 中的数据解析到 v 中，ConfigMap 不存在时返回 false
+func (store *volumeStateStore) get(ctx context.Context, id string, v interface{}) (bool, error) {
+	configMap, err := store.client.CoreV1().ConfigMaps(store.namespace).Get(ctx, store.configMapName(id), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("volumeStateStore.get: get configmap of %s error: %w", id, err)
+	}
+	return true, parseConfigMapData(configMap, v)
+}
+
+// save 将 v 序列化后创建或更新到 id 对应的 ConfigMap 中
+func (store *volumeStateStore) save(ctx context.Context, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("volumeStateStore.save: marshal state of %s error: %w", id, err)
+	}
+
+	configMaps := store.client.CoreV1().ConfigMaps(store.namespace)
+	name := store.configMapName(id)
+	configMap, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: store.namespace,
+				Labels: map[string]string{
+					VOLUME_STATE_LABEL_DRIVER: store.driverName,
+					VOLUME_STATE_LABEL_VOLUME: id,
+				},
+			},
+			Data: map[string]string{VOLUME_STATE_DATA_KEY: string(data)},
+		}
+		if _, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("volumeStateStore.save: create configmap of %s error: %w", id, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("volumeStateStore.save: get configmap of %s error: %w", id, err)
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string, 1)
+	}
+	configMap.Data[VOLUME_STATE_DATA_KEY] = string(data)
+	if _, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("volumeStateStore.save: update configmap of %s error: %w", id, err)
+	}
+	return nil
+}
+
+func (store *volumeStateStore) list(ctx context.Context) ([]*corev1.ConfigMap, error) {
+	selector := labels.SelectorFromSet(labels.Set{VOLUME_STATE_LABEL_DRIVER: store.driverName})
+	configMapList, err := store.client.CoreV1().ConfigMaps(store.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("volumeStateStore.list: list configmaps error: %w", err)
+	}
+	configMaps := make([]*corev1.ConfigMap, 0, len(configMapList.Items))
+	for i := range configMapList.Items {
+		configMaps = append(configMaps, &configMapList.Items[i])
+	}
+	return configMaps, nil
+}
+
+// getCredentials 读取 id 对应的 Secret 中的凭证，Secret 不存在时返回 nil
+func (store *volumeStateStore) getCredentials(ctx context.Context, id string) (map[string]string, error) {
+	secret, err := store.client.CoreV1().Secrets(store.namespace).Get(ctx, store.configMapName(id), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("volumeStateStore.getCredentials: get secret of %s error: %w", id, err)
+	}
+	return parseSecretData(secret), nil
+}
+
+// saveCredentials 将凭证创建或更新到 id 对应的 Secret 中，没有凭证时不创建 Secret
+func (store *volumeStateStore) saveCredentials(ctx context.Context, id string, credentials map[string]string) error {
+	if len(credentials) == 0 {
+		return nil
+	}
+	data := make(map[string][]byte, len(credentials))
+	for key, value := range credentials {
+		data[key] = []byte(value)
+	}
+
+	secrets := store.client.CoreV1().Secrets(store.namespace)
+	name := store.configMapName(id)
+	secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: store.namespace,
+				Labels: map[string]string{
+					VOLUME_STATE_LABEL_DRIVER: store.driverName,
+					VOLUME_STATE_LABEL_VOLUME: id,
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}
+		if _, err = secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("volumeStateStore.saveCredentials: create secret of %s error: %w", id, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("volumeStateStore.saveCredentials: get secret of %s error: %w", id, err)
+	} else if reflect.DeepEqual(parseSecretData(secret), credentials) {
+		return nil
+	}
+
+	secret.Data = data
+	if _, err = secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("volumeStateStore.saveCredentials: update secret of %s error: %w", id, err)
+	}
+	return nil
+}
+
+// listCredentials 列出当前驱动的所有凭证，key 为 Secret 对应的 id
+func (store *volumeStateStore) listCredentials(ctx context.Context) (map[string]map[string]string, error) {
+	selector := labels.SelectorFromSet(labels.Set{VOLUME_STATE_LABEL_DRIVER: store.driverName})
+	secretList, err := store.client.CoreV1().Secrets(store.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("volumeStateStore.listCredentials: list secrets error: %w", err)
+	}
+	credentials := make(map[string]map[string]string, len(secretList.Items))
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		credentials[secret.Labels[VOLUME_STATE_LABEL_VOLUME]] = parseSecretData(secret)
+	}
+	return credentials, nil
+}
+
+func parseSecretData(secret *corev1.Secret) map[string]string {
+	credentials := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		credentials[key] = string(value)
+	}
+	return credentials
+}
+
+// splitCredentials 返回不包含凭证的参数副本和其中的凭证，旧版本保存在 ConfigMap 中的凭证在下一次保存时被移到 Secret 中
+func splitCredentials(volumeContext map[string]string) (map[string]string, map[string]string) {
+	if volumeContext == nil {
+		return nil, nil
+	}
+	withoutCredentials := make(map[string]string, len(volumeContext))
+	for key, value := range volumeContext {
+		withoutCredentials[key] = value
+	}
+	credentials := make(map[string]string)
+	for _, key := range volumeContextCredentialKeys {
+		if value, ok := withoutCredentials[key]; ok {
+			credentials[key] = value
+			delete(withoutCredentials, key)
+		}
+	}
+	return withoutCredentials, credentials
+}
+
+// mergeCredentials 将 Secret 中的凭证合并回参数中
+func mergeCredentials(volumeContext, credentials map[string]string) map[string]string {
+	if len(credentials) == 0 {
+		return volumeContext
+	}
+	if volumeContext == nil {
+		volumeContext = make(map[string]string, len(credentials))
+	}
+	for key, value := range credentials {
+		volumeContext[key] = value
+	}
+	return volumeContext
+}
+
+func parseConfigMapData(configMap *corev1.ConfigMap, v interface{}) error {
+	data, ok := configMap.Data[VOLUME_STATE_DATA_KEY]
+	if !ok {
+		return fmt.Errorf("parseConfigMapData: configmap %s has no %s", configMap.Name, VOLUME_STATE_DATA_KEY)
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return fmt.Errorf("parseConfigMapData: failed to parse configmap %s: %w", configMap.Name, err)
+	}
+	return nil
+}
+
+const ROLLBACK_TIMEOUT = 2 * time.Minute
+
+// isTerminalError 判断 CreateVolume 的错误是否无法通过重试恢复
+// 超时、网络错误、保存状态失败等临时错误返回 false，这时应当保留已经创建的资源，由重试的 CreateVolume 继续创建
+func isTerminalError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.AlreadyExists, codes.FailedPrecondition, codes.OutOfRange:
+		return true
+	default:
+		return false
+	}
+}
+
+// rollbackStep 描述如何撤销一个由 checkpoint 记录的已创建资源
+type rollbackStep struct {
+	checkpoint string
+	undo       func(ctx context.Context) error
+}
+
+// Rollback 按 steps 的顺序撤销已经记录在 state 中的资源，steps 应当与创建顺序相反
+// 撤销成功的资源会从 state 中移除，撤销失败的资源会继续保留在 state 中，重试的 CreateVolume 将复用它们
+// 返回无法撤销的资源对应的 checkpoint
+func (store *volumeStateStore) Rollback(state *volumeState, steps []rollbackStep) []string {
+	// 原请求的 ctx 可能已经超时或被取消，这里使用独立的 ctx
+	ctx, cancel := context.WithTimeout(context.Background(), ROLLBACK_TIMEOUT)
+	defer cancel()
+
+	var leftovers []string
+	for _, step := range steps {
+		value, ok := state.getCheckpoint(step.checkpoint)
+		if !ok {
+			continue
+		}
+		if err := step.undo(ctx); err != nil {
+			log.Warnf("Rollback: failed to rollback %s (%s) of volume %s: %s", step.checkpoint, value, state.VolumeId, err)
+			leftovers = append(leftovers, step.checkpoint)
+		} else {
+			log.Infof("Rollback: %s (%s) of volume %s is rolled back", step.checkpoint, value, state.VolumeId)
+			delete(state.Checkpoints, step.checkpoint)
+		}
+	}
+
+	if len(leftovers) == 0 {
+		if err := store.Delete(ctx, state.VolumeId); err != nil {
+			log.Warnf("Rollback: %s", err)
+		}
+	} else {
+		log.Errorf("Rollback: volume %s is partially rolled back, leftovers: %s", state.VolumeId, strings.Join(leftovers, ", "))
+		if err := store.Save(ctx, state); err != nil {
+			log.Warnf("Rollback: %s", err)
+		}
+	}
+	return leftovers
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVolumeStateStore_SaveAndGet(t *testing.T) {
+	ctx := context.Background()
+	store := newVolumeStateStore(fake.NewSimpleClientset(), "kube-system", KodoDriverName)
+
+	state, err := store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	state = newVolumeState("kodo-test")
+	assert.NoError(t, store.SaveCheckpoint(ctx, state, CHECKPOINT_BUCKET_NAME, "kodo-test-bucket"))
+
+	// 模拟 Provisioner 重启后重新读取状态
+	resumed, err := store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	assert.Equal(t, volumePhaseCreating, resumed.Phase)
+	bucketName, ok := resumed.getCheckpoint(CHECKPOINT_BUCKET_NAME)
+	assert.True(t, ok)
+	assert.Equal(t, "kodo-test-bucket", bucketName)
+	_, ok = resumed.getCheckpoint(CHECKPOINT_IAM_USER_CREATED)
+	assert.False(t, ok)
+
+	resumed.Phase = volumePhaseCreated
+	resumed.CapacityBytes = 5 << 30
+	resumed.VolumeContext = map[string]string{FIELD_BUCKET_NAME: bucketName}
+	assert.NoError(t, store.Save(ctx, resumed))
+
+	created, err := store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	volume := created.toVolume()
+	assert.Equal(t, "kodo-test", volume.GetVolumeId())
+	assert.Equal(t, int64(5<<30), volume.GetCapacityBytes())
+	assert.Equal(t, "kodo-test-bucket", volume.GetVolumeContext()[FIELD_BUCKET_NAME])
+}
+
+func TestVolumeStateStore_ListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	kodoStore := newVolumeStateStore(clientset, "kube-system", KodoDriverName)
+	kodofsStore := newVolumeStateStore(clientset, "kube-system", KodoFSDriverName)
+
+	assert.NoError(t, kodoStore.Save(ctx, newVolumeState("kodo-1")))
+	assert.NoError(t, kodoStore.Save(ctx, newVolumeState("kodo-2")))
+	assert.NoError(t, kodofsStore.Save(ctx, newVolumeState("kodofs-1")))
+
+	states, err := kodoStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, states, 2)
+
+	assert.NoError(t, kodoStore.Delete(ctx, "kodo-1"))
+	// 重复删除不应该报错
+	assert.NoError(t, kodoStore.Delete(ctx, "kodo-1"))
+
+	states, err = kodoStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, states, 1)
+	assert.Equal(t, "kodo-2", states[0].VolumeId)
+
+	states, err = kodofsStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, states, 1)
+}
+
+func TestVolumeStateStore_Rollback(t *testing.T) {
+	ctx := context.Background()
+	store := newVolumeStateStore(fake.NewSimpleClientset(), "kube-system", KodoDriverName)
+
+	state := newVolumeState("kodo-test")
+	assert.NoError(t, store.SaveCheckpoint(ctx, state, CHECKPOINT_BUCKET_CREATED, "kodo-test"))
+	assert.NoError(t, store.SaveCheckpoint(ctx, state, CHECKPOINT_IAM_USER_CREATED, "kodo-test"))
+
+	var undone []string
+	undo := func(checkpoint string, err error) rollbackStep {
+		return rollbackStep{checkpoint: checkpoint, undo: func(context.Context) error {
+			undone = append(undone, checkpoint)
+			return err
+		}}
+	}
+
+	// 未创建的 IAM 策略不会被撤销，无法删除的 bucket 被保留在状态中
+	leftovers := store.Rollback(state, []rollbackStep{
+		undo(CHECKPOINT_IAM_POLICY_CREATED, nil),
+		undo(CHECKPOINT_IAM_USER_CREATED, nil),
+		undo(CHECKPOINT_BUCKET_CREATED, errors.New("bucket not empty")),
+	})
+	assert.Equal(t, []string{CHECKPOINT_IAM_USER_CREATED, CHECKPOINT_BUCKET_CREATED}, undone)
+	assert.Equal(t, []string{CHECKPOINT_BUCKET_CREATED}, leftovers)
+
+	saved, err := store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	_, ok := saved.getCheckpoint(CHECKPOINT_BUCKET_CREATED)
+	assert.True(t, ok)
+	_, ok = saved.getCheckpoint(CHECKPOINT_IAM_USER_CREATED)
+	assert.False(t, ok)
+
+	// 全部撤销成功后状态被删除
+	leftovers = store.Rollback(saved, []rollbackStep{undo(CHECKPOINT_BUCKET_CREATED, nil)})
+	assert.Empty(t, leftovers)
+	saved, err = store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	assert.Nil(t, saved)
+}
+
+func TestIsTerminalError(t *testing.T) {
+	assert.False(t, isTerminalError(nil))
+	assert.False(t, isTerminalError(errors.New("KodoClient.CreateBucket: send request err: i/o timeout")))
+	assert.False(t, isTerminalError(status.Error(codes.Aborted, "volume is being populated")))
+	assert.False(t, isTerminalError(status.Error(codes.Unavailable, "snapshot is not ready to use")))
+	assert.True(t, isTerminalError(status.Error(codes.InvalidArgument, "invalid parameter")))
+	assert.True(t, isTerminalError(status.Error(codes.AlreadyExists, "bucket already exists")))
+}
+
+func TestVolumeStateStore_GetVolumeContext(t *testing.T) {
+	ctx := context.Background()
+	// 静态存储卷按照与 VolumeHandle 同名的 PV 查找
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "kodofs-static"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           TypePluginKodoFS,
+					VolumeHandle:     "kodofs-static",
+					VolumeAttributes: map[string]string{FIELD_GATEWAY_ID: "static-gateway"},
+				},
+			},
+		},
+	}
+	store := newVolumeStateStore(fake.NewSimpleClientset(pv), "kube-system", KodoFSDriverName)
+
+	state := newVolumeState("kodofs-dynamic")
+	state.Phase = volumePhaseCreated
+	state.VolumeContext = map[string]string{FIELD_GATEWAY_ID: "dynamic-gateway"}
+	assert.NoError(t, store.Save(ctx, state))
+
+	volumeContext, err := store.GetVolumeContext(ctx, TypePluginKodoFS, "kodofs-dynamic")
+	assert.NoError(t, err)
+	assert.Equal(t, "dynamic-gateway", volumeContext[FIELD_GATEWAY_ID])
+
+	volumeContext, err = store.GetVolumeContext(ctx, TypePluginKodoFS, "kodofs-static")
+	assert.NoError(t, err)
+	assert.Equal(t, "static-gateway", volumeContext[FIELD_GATEWAY_ID])
+
+	_, err = store.GetVolumeContext(ctx, TypePluginKodo, "kodofs-static")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// VolumeHandle 与 PV 名称不同的静态存储卷无法找到
+	renamed := pv.DeepCopy()
+	renamed.Name = "kodofs-csi-pv"
+	renamed.Spec.CSI.VolumeHandle = "kodofs-renamed"
+	store = newVolumeStateStore(fake.NewSimpleClientset(renamed), "kube-system", KodoFSDriverName)
+	_, err = store.GetVolumeContext(ctx, TypePluginKodoFS, "kodofs-renamed")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestVolumeStateStore_Credentials(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	store := newVolumeStateStore(clientset, "kube-system", KodoDriverName)
+
+	state := newVolumeState("kodo-test")
+	state.Phase = volumePhaseCreated
+	state.VolumeContext = map[string]string{
+		FIELD_BUCKET_NAME:         "kodo-test-bucket",
+		FIELD_ACCESS_KEY:          "ak",
+		FIELD_SECRET_KEY:          "sk",
+		FIELD_ORIGINAL_ACCESS_KEY: "original-ak",
+		FIELD_ORIGINAL_SECRET_KEY: "original-sk",
+	}
+	assert.NoError(t, store.Save(ctx, state))
+	assert.Equal(t, "sk", state.VolumeContext[FIELD_SECRET_KEY])
+
+	// ConfigMap 中不保存凭证
+	configMap, err := clientset.CoreV1().ConfigMaps("kube-system").Get(ctx, store.configMapName("kodo-test"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, configMap.Data[VOLUME_STATE_DATA_KEY], "kodo-test-bucket")
+	assert.NotContains(t, configMap.Data[VOLUME_STATE_DATA_KEY], "original-ak")
+	assert.NotContains(t, configMap.Data[VOLUME_STATE_DATA_KEY], FIELD_SECRET_KEY)
+	secret, err := clientset.CoreV1().Secrets("kube-system").Get(ctx, store.configMapName("kodo-test"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("original-sk"), secret.Data[FIELD_ORIGINAL_SECRET_KEY])
+
+	resumed, err := store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	assert.Equal(t, state.VolumeContext, resumed.VolumeContext)
+	states, err := store.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, states, 1)
+	assert.Equal(t, state.VolumeContext, states[0].VolumeContext)
+
+	assert.NoError(t, store.Delete(ctx, "kodo-test"))
+	_, err = clientset.CoreV1().Secrets("kube-system").Get(ctx, store.configMapName("kodo-test"), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestVolumeStateStore_LegacyCredentials(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      KodoDriverName + "-state-kodo-test",
+			Namespace: "kube-system",
+			Labels:    map[string]string{VOLUME_STATE_LABEL_DRIVER: KodoDriverName, VOLUME_STATE_LABEL_VOLUME: "kodo-test"},
+		},
+		Data: map[string]string{VOLUME_STATE_DATA_KEY: `{"volume_id":"kodo-test","phase":"created","volume_context":{"accesskey":"ak","secretkey":"sk"}}`},
+	})
+	store := newVolumeStateStore(clientset, "kube-system", KodoDriverName)
+
+	// 旧版本保存在 ConfigMap 中的凭证仍然可以读取，再次保存时移到 Secret 中
+	state, err := store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk", state.VolumeContext[FIELD_SECRET_KEY])
+	assert.NoError(t, store.Save(ctx, state))
+	configMap, err := clientset.CoreV1().ConfigMaps("kube-system").Get(ctx, store.configMapName("kodo-test"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, configMap.Data[VOLUME_STATE_DATA_KEY], FIELD_SECRET_KEY)
+	state, err = store.Get(ctx, "kodo-test")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk", state.VolumeContext[FIELD_SECRET_KEY])
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ListVolumes 每页最多返回的存储卷数量，未指定 max_entries 时同样分页，避免一次请求检查所有存储卷
+	LIST_VOLUMES_MAX_ENTRIES = 100
+	// ListVolumes 同时检查健康状况的存储卷数量
+	LIST_VOLUMES_CHECK_CONCURRENCY = 8
+)
+
+// volumeConditionChecker 检查存储卷对应的 bucket 或 KodoFS 存储卷是否仍然存在
+type volumeConditionChecker func(ctx context.Context, volumeId string, volumeContext map[string]string) *csi.VolumeCondition
+
+// getVolumeStatus 获取存储卷的参数、容量、被发布到的节点和健康状况
+// 动态创建的存储卷从状态中获取，静态存储卷从 PV 中获取
+func getVolumeStatus(ctx context.Context, functionName, pluginType string, store *volumeStateStore, nodeStore *nodeStateStore,
+	volumeId string, checkVolumeCondition volumeConditionChecker) (*csi.ControllerGetVolumeResponse, error) {
+	var volume *csi.Volume
+	if state, err := store.Get(ctx, volumeId); err != nil {
+		return nil, fmt.Errorf("%s: get state of volume %s error: %w", functionName, volumeId, err)
+	} else if state != nil && state.Phase == volumePhaseCreated {
+		volume = state.toVolume()
+	} else if volumeContext, err := store.GetVolumeContext(ctx, pluginType, volumeId); err != nil {
+		return nil, err
+	} else {
+		volume = &csi.Volume{VolumeId: volumeId, VolumeContext: volumeContext}
+	}
+
+	publishedNodes, err := nodeStore.ListPublishedNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", functionName, err)
+	}
+	return &csi.ControllerGetVolumeResponse{
+		Volume: volume,
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			PublishedNodeIds: publishedNodes[volumeId],
+			VolumeCondition:  checkVolumeCondition(ctx, volumeId, volume.VolumeContext),
+		},
+	}, nil
+}
+
+// listVolumeStatuses 分页列出动态创建的存储卷，静态存储卷没有状态，不会被列出
+func listVolumeStatuses(ctx context.Context, functionName string, store *volumeStateStore, nodeStore *nodeStateStore,
+	req *csi.ListVolumesRequest, checkVolumeCondition volumeConditionChecker) (*csi.ListVolumesResponse, error) {
+	states, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", functionName, err)
+	}
+	var createdStates []*volumeState
+	for _, state := range states {
+		if state.Phase == volumePhaseCreated {
+			createdStates = append(createdStates, state)
+		}
+	}
+	sort.Slice(createdStates, func(i, j int) bool {
+		return createdStates[i].VolumeId < createdStates[j].VolumeId
+	})
+
+	maxEntries := req.GetMaxEntries()
+	if maxEntries <= 0 || maxEntries > LIST_VOLUMES_MAX_ENTRIES {
+		maxEntries = LIST_VOLUMES_MAX_ENTRIES
+	}
+	start, end, nextToken, err := paginate(functionName, req.GetStartingToken(), maxEntries, len(createdStates))
+	if err != nil {
+		return nil, err
+	}
+	publishedNodes, err := nodeStore.ListPublishedNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", functionName, err)
+	}
+	// 只检查当前页中的存储卷，每个存储卷都需要请求一次远程接口，所以并发检查
+	entries := make([]*csi.ListVolumesResponse_Entry, end-start)
+	var wg sync.WaitGroup
+	concurrency := make(chan struct{}, LIST_VOLUMES_CHECK_CONCURRENCY)
+	for i, state := range createdStates[start:end] {
+		entries[i] = &csi.ListVolumesResponse_Entry{
+			Volume: state.toVolume(),
+			Status: &csi.ListVolumesResponse_VolumeStatus{PublishedNodeIds: publishedNodes[state.VolumeId]},
+		}
+		wg.Add(1)
+		concurrency <- struct{}{}
+		go func(entryStatus *csi.ListVolumesResponse_VolumeStatus, state *volumeState) {
+			defer wg.Done()
+			defer func() { <-concurrency }()
+			entryStatus.VolumeCondition = checkVolumeCondition(ctx, state.VolumeId, state.VolumeContext)
+		}(entries[i].Status, state)
+	}
+	wg.Wait()
+	return &csi.ListVolumesResponse{Entries: entries, NextToken: nextToken}, nil
+}
+
+// paginate 计算分页的范围，token 为下一页第一项的下标，无效的 token 返回 Aborted
+func paginate(functionName, startingToken string, maxEntries int32, total int) (start, end int, nextToken string, err error) {
+	start, end = 0, total
+	if startingToken != "" {
+		if start, err = strconv.Atoi(startingToken); err != nil || start < 0 || start > total {
+			err = status.Errorf(codes.Aborted, "%s: invalid starting token %s", functionName, startingToken)
+			return
+		}
+	}
+	if maxEntries > 0 && start+int(maxEntries) < end {
+		end = start + int(maxEntries)
+		nextToken = strconv.Itoa(end)
+	}
+	return
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListVolumeStatuses(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	store := newVolumeStateStore(clientset, "kube-system", KodoDriverName)
+	nodeStore := newNodeStateStore(clientset, "kube-system", KodoDriverName)
+	for _, volumeId := range []string{"kodo-3", "kodo-1", "kodo-2"} {
+		state := newVolumeState(volumeId)
+		state.Phase = volumePhaseCreated
+		assert.NoError(t, store.Save(ctx, state))
+	}
+	// 创建中的存储卷不会被列出
+	assert.NoError(t, store.Save(ctx, newVolumeState("kodo-4")))
+	assert.NoError(t, nodeStore.AddPublishedVolume(ctx, "node-1", "kodo-2", "/target"))
+
+	checkVolumeCondition := func(ctx context.Context, volumeId string, volumeContext map[string]string) *csi.VolumeCondition {
+		return &csi.VolumeCondition{Abnormal: volumeId == "kodo-3"}
+	}
+
+	resp, err := listVolumeStatuses(ctx, "ListVolumes", store, nodeStore, &csi.ListVolumesRequest{MaxEntries: 2}, checkVolumeCondition)
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetEntries(), 2)
+	assert.Equal(t, "kodo-1", resp.GetEntries()[0].GetVolume().GetVolumeId())
+	assert.Equal(t, "kodo-2", resp.GetEntries()[1].GetVolume().GetVolumeId())
+	assert.Equal(t, []string{"node-1"}, resp.GetEntries()[1].GetStatus().GetPublishedNodeIds())
+	assert.Equal(t, "2", resp.GetNextToken())
+
+	resp, err = listVolumeStatuses(ctx, "ListVolumes", store, nodeStore, &csi.ListVolumesRequest{StartingToken: resp.GetNextToken()}, checkVolumeCondition)
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetEntries(), 1)
+	assert.Equal(t, "kodo-3", resp.GetEntries()[0].GetVolume().GetVolumeId())
+	assert.True(t, resp.GetEntries()[0].GetStatus().GetVolumeCondition().GetAbnormal())
+	assert.Empty(t, resp.GetNextToken())
+
+	// 未指定 max_entries 时同样按照最大数量分页
+	for i := 0; i < LIST_VOLUMES_MAX_ENTRIES; i++ {
+		state := newVolumeState(fmt.Sprintf("kodo-page-%03d", i))
+		state.Phase = volumePhaseCreated
+		assert.NoError(t, store.Save(ctx, state))
+	}
+	resp, err = listVolumeStatuses(ctx, "ListVolumes", store, nodeStore, &csi.ListVolumesRequest{}, checkVolumeCondition)
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetEntries(), LIST_VOLUMES_MAX_ENTRIES)
+	assert.Equal(t, strconv.Itoa(LIST_VOLUMES_MAX_ENTRIES), resp.GetNextToken())
+	for _, entry := range resp.GetEntries() {
+		assert.NotNil(t, entry.GetStatus().GetVolumeCondition())
+	}
+
+	_, err = listVolumeStatuses(ctx, "ListVolumes", store, nodeStore, &csi.ListVolumesRequest{StartingToken: "1000"}, checkVolumeCondition)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	volume, err := getVolumeStatus(ctx, "ControllerGetVolume", TypePluginKodo, store, nodeStore, "kodo-2", checkVolumeCondition)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node-1"}, volume.GetStatus().GetPublishedNodeIds())
+	assert.False(t, volume.GetStatus().GetVolumeCondition().GetAbnormal())
+
+	_, err = getVolumeStatus(ctx, "ControllerGetVolume", TypePluginKodo, store, nodeStore, "kodo-5", checkVolumeCondition)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
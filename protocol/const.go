@@ -2,24 +2,34 @@ package protocol
 
 const (
 	Version                = "v2"
+	GRPCVersion            = "v3"
 	InitKodoMountCmdName   = "init_kodo_mount"
 	InitKodoFsMountCmdName = "init_kodofs_mount"
 	KodoUmountCmdName      = "umount_kodo"
+	KodoFSUmountCmdName    = "umount_kodofs"
 	RequestDataCmdName     = "request_data"
 	ResponseDataCmdName    = "response_data"
 	TerminateCmdName       = "terminate"
 )
 
+// MetricsNamespace 插件、connector 和 qiniu 包中所有指标名称的前缀
+const MetricsNamespace = "qiniu_csi"
+
 type contextKey string
 
-const (
+// 可执行文件的路径，connector 可以通过配置文件修改
+var (
 	// KodoFS executable name
 	KodoFSCmd = "/usr/local/bin/kodofs"
 	// Rclone executable name
 	RcloneCmd = "/usr/local/bin/rclone"
+)
 
+const (
 	ContextKeyConfigFilePath contextKey = "config_file_path"
 	ContextKeyUserAgent      contextKey = "user_agent"
 	ContextKeyLogFilePath    contextKey = "log_file_path"
 	ContextKeyCacheDirPath   contextKey = "cache_dir_path"
+	ContextKeyRcSocketPath   contextKey = "rc_socket_path"
+	ContextKeyDefaultFlags   contextKey = "default_flags"
 )
@@ -0,0 +1,99 @@
+package protocol
+
+import (
+	"strings"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	"google.golang.org/grpc/codes"
+)
+
+// MountError 是 v2 协议中 TerminateCmd 携带的挂载失败原因，与 gRPC 的 connectorpb.MountError 相同
+type MountError struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Output  string `json:"output,omitempty"`
+}
+
+// 挂载命令输出中常见错误的特征，均为小写，按顺序匹配
+var mountErrorPatterns = []struct {
+	reason   connectorpb.MountErrorReason
+	patterns []string
+}{
+	{connectorpb.MountErrorReason_MOUNT_ERROR_REASON_AUTH_FAILED, []string{
+		"invalidaccesskeyid", "signaturedoesnotmatch", "accessdenied", "access denied",
+		"status code: 401", "status code: 403", "unauthorized", "invalid access token", "invalid token",
+	}},
+	{connectorpb.MountErrorReason_MOUNT_ERROR_REASON_BUCKET_NOT_FOUND, []string{
+		"nosuchbucket", "no such bucket", "bucket not found", "volume not found", "volume not exist",
+	}},
+	{connectorpb.MountErrorReason_MOUNT_ERROR_REASON_DNS_FAILURE, []string{
+		"no such host", "server misbehaving", "temporary failure in name resolution", "dial tcp: lookup",
+	}},
+	{connectorpb.MountErrorReason_MOUNT_ERROR_REASON_NETWORK_FAILURE, []string{
+		"connection refused", "connection reset by peer", "network is unreachable", "no route to host", "i/o timeout",
+	}},
+	{connectorpb.MountErrorReason_MOUNT_ERROR_REASON_FUSE_UNAVAILABLE, []string{
+		"/dev/fuse", "fusermount", "fuse: device not found", "fuse device not found",
+	}},
+}
+
+// ClassifyMountError 根据挂载命令的输出判断失败原因，返回原因和输出中相关的一行，
+// 无法判断时返回 UNKNOWN 和最后一个非空行
+func ClassifyMountError(output string) (connectorpb.MountErrorReason, string) {
+	lines := strings.Split(output, "\n")
+	for _, pattern := range mountErrorPatterns {
+		// 越靠后的输出越可能是真正的错误
+		for i := len(lines) - 1; i >= 0; i-- {
+			line := strings.ToLower(lines[i])
+			for _, p := range pattern.patterns {
+				if strings.Contains(line, p) {
+					return pattern.reason, strings.TrimSpace(lines[i])
+				}
+			}
+		}
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return connectorpb.MountErrorReason_MOUNT_ERROR_REASON_UNKNOWN, line
+		}
+	}
+	return connectorpb.MountErrorReason_MOUNT_ERROR_REASON_UNKNOWN, ""
+}
+
+// DescribeMountError 返回失败原因的描述
+func DescribeMountError(reason connectorpb.MountErrorReason) string {
+	switch reason {
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_AUTH_FAILED:
+		return "authentication failed"
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_BUCKET_NOT_FOUND:
+		return "bucket or volume not found"
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_DNS_FAILURE:
+		return "failed to resolve domain name"
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_NETWORK_FAILURE:
+		return "network failure"
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_FUSE_UNAVAILABLE:
+		return "FUSE is unavailable on the node"
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_TIMEOUT:
+		return "mount timed out"
+	default:
+		return "mount failed"
+	}
+}
+
+// MountErrorCode 返回失败原因对应的 gRPC 状态码
+func MountErrorCode(reason connectorpb.MountErrorReason) codes.Code {
+	switch reason {
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_AUTH_FAILED:
+		return codes.PermissionDenied
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_BUCKET_NOT_FOUND:
+		return codes.NotFound
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_DNS_FAILURE, connectorpb.MountErrorReason_MOUNT_ERROR_REASON_NETWORK_FAILURE:
+		return codes.Unavailable
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_FUSE_UNAVAILABLE:
+		return codes.FailedPrecondition
+	case connectorpb.MountErrorReason_MOUNT_ERROR_REASON_TIMEOUT:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestClassifyMountError(t *testing.T) {
+	cases := []struct {
+		output string
+		reason connectorpb.MountErrorReason
+		line   string
+	}{
+		{
+			output: "2023/01/01 00:00:00 NOTICE: mounting\n2023/01/01 00:00:01 ERROR : SignatureDoesNotMatch: The request signature we calculated does not match\n",
+			reason: connectorpb.MountErrorReason_MOUNT_ERROR_REASON_AUTH_FAILED,
+			line:   "2023/01/01 00:00:01 ERROR : SignatureDoesNotMatch: The request signature we calculated does not match",
+		},
+		{
+			output: "Fatal error: NoSuchBucket: The specified bucket does not exist",
+			reason: connectorpb.MountErrorReason_MOUNT_ERROR_REASON_BUCKET_NOT_FOUND,
+			line:   "Fatal error: NoSuchBucket: The specified bucket does not exist",
+		},
+		{
+			output: "dial tcp: lookup s3.cn-east-1.qiniucs.com on 10.0.0.10:53: read udp 10.0.0.1:1234->10.0.0.10:53: i/o timeout",
+			reason: connectorpb.MountErrorReason_MOUNT_ERROR_REASON_DNS_FAILURE,
+			line:   "dial tcp: lookup s3.cn-east-1.qiniucs.com on 10.0.0.10:53: read udp 10.0.0.1:1234->10.0.0.10:53: i/o timeout",
+		},
+		{
+			output: "dial tcp 10.0.0.1:443: connect: connection refused",
+			reason: connectorpb.MountErrorReason_MOUNT_ERROR_REASON_NETWORK_FAILURE,
+			line:   "dial tcp 10.0.0.1:443: connect: connection refused",
+		},
+		{
+			output: "mount helper error: fusermount3: fuse device not found, try 'modprobe fuse' first",
+			reason: connectorpb.MountErrorReason_MOUNT_ERROR_REASON_FUSE_UNAVAILABLE,
+			line:   "mount helper error: fusermount3: fuse device not found, try 'modprobe fuse' first",
+		},
+		{
+			output: "something went wrong\nexit status 1\n\n",
+			reason: connectorpb.MountErrorReason_MOUNT_ERROR_REASON_UNKNOWN,
+			line:   "exit status 1",
+		},
+		{
+			output: "",
+			reason: connectorpb.MountErrorReason_MOUNT_ERROR_REASON_UNKNOWN,
+			line:   "",
+		},
+	}
+	for _, c := range cases {
+		reason, line := ClassifyMountError(c.output)
+		assert.Equal(t, c.reason, reason, c.output)
+		assert.Equal(t, c.line, line, c.output)
+	}
+}
+
+func TestMountErrorCode(t *testing.T) {
+	assert.Equal(t, codes.PermissionDenied, MountErrorCode(connectorpb.MountErrorReason_MOUNT_ERROR_REASON_AUTH_FAILED))
+	assert.Equal(t, codes.NotFound, MountErrorCode(connectorpb.MountErrorReason_MOUNT_ERROR_REASON_BUCKET_NOT_FOUND))
+	assert.Equal(t, codes.Unavailable, MountErrorCode(connectorpb.MountErrorReason_MOUNT_ERROR_REASON_DNS_FAILURE))
+	assert.Equal(t, codes.FailedPrecondition, MountErrorCode(connectorpb.MountErrorReason_MOUNT_ERROR_REASON_FUSE_UNAVAILABLE))
+	assert.Equal(t, codes.DeadlineExceeded, MountErrorCode(connectorpb.MountErrorReason_MOUNT_ERROR_REASON_TIMEOUT))
+	assert.Equal(t, codes.Internal, MountErrorCode(connectorpb.MountErrorReason_MOUNT_ERROR_REASON_UNKNOWN))
+}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -46,10 +47,111 @@ type InitKodoMountCmd struct {
 	DebugHttp             bool    `json:"debug_http,omitempty"`
 	DebugFuse             bool    `json:"debug_fuse,omitempty"`
 	MayRunOnSystemd       bool    `json:"may_run_on_systemd"`
+	Uid                   *uint32 `json:"uid,omitempty"`
+	Gid                   *uint32 `json:"gid,omitempty"`
+	Umask                 string  `json:"umask,omitempty"`
+	DirPerms              string  `json:"dir_perms,omitempty"`
+	FilePerms             string  `json:"file_perms,omitempty"`
+	AllowOther            bool    `json:"allow_other,omitempty"`
 }
 
 func (*InitKodoMountCmd) Command() {}
 
+// NewInitKodoMountCmd 将 gRPC 请求转换为挂载命令
+func NewInitKodoMountCmd(m *connectorpb.KodoMount) *InitKodoMountCmd {
+	return &InitKodoMountCmd{
+		VolumeId:              m.VolumeId,
+		MountPath:             m.MountPath,
+		SubDir:                m.SubDir,
+		AccessKey:             m.AccessKey,
+		SecretKey:             m.SecretKey,
+		BucketId:              m.BucketId,
+		S3Region:              m.S3Region,
+		S3Endpoint:            m.S3Endpoint,
+		S3ForcePathStyle:      m.S3ForcePathStyle,
+		StorageClass:          m.StorageClass,
+		VfsCacheMode:          m.VfsCacheMode,
+		DirCacheDuration:      m.DirCacheDuration,
+		BufferSize:            m.BufferSize,
+		VfsCacheMaxAge:        m.VfsCacheMaxAge,
+		VfsCachePollInterval:  m.VfsCachePollInterval,
+		VfsWriteBack:          m.VfsWriteBack,
+		VfsCacheMaxSize:       m.VfsCacheMaxSize,
+		VfsReadAhead:          m.VfsReadAhead,
+		VfsFastFingerPrint:    m.VfsFastFingerPrint,
+		VfsReadChunkSize:      m.VfsReadChunkSize,
+		VfsReadChunkSizeLimit: m.VfsReadChunkSizeLimit,
+		NoCheckSum:            m.NoCheckSum,
+		NoModTime:             m.NoModTime,
+		NoSeek:                m.NoSeek,
+		ReadOnly:              m.ReadOnly,
+		VfsReadWait:           m.VfsReadWait,
+		VfsWriteWait:          m.VfsWriteWait,
+		Transfers:             m.Transfers,
+		VfsDiskSpaceTotalSize: m.VfsDiskSpaceTotalSize,
+		UploadCutoff:          m.UploadCutoff,
+		UploadChunkSize:       m.UploadChunkSize,
+		UploadConcurrency:     m.UploadConcurrency,
+		WriteBackCache:        m.WriteBackCache,
+		DebugHttp:             m.DebugHttp,
+		DebugFuse:             m.DebugFuse,
+		MayRunOnSystemd:       m.MayRunOnSystemd,
+		Uid:                   m.Uid,
+		Gid:                   m.Gid,
+		Umask:                 m.Umask,
+		DirPerms:              m.DirPerms,
+		FilePerms:             m.FilePerms,
+		AllowOther:            m.AllowOther,
+	}
+}
+
+func (c *InitKodoMountCmd) ToProto() *connectorpb.KodoMount {
+	return &connectorpb.KodoMount{
+		VolumeId:              c.VolumeId,
+		MountPath:             c.MountPath,
+		SubDir:                c.SubDir,
+		AccessKey:             c.AccessKey,
+		SecretKey:             c.SecretKey,
+		BucketId:              c.BucketId,
+		S3Region:              c.S3Region,
+		S3Endpoint:            c.S3Endpoint,
+		S3ForcePathStyle:      c.S3ForcePathStyle,
+		StorageClass:          c.StorageClass,
+		VfsCacheMode:          c.VfsCacheMode,
+		DirCacheDuration:      c.DirCacheDuration,
+		BufferSize:            c.BufferSize,
+		VfsCacheMaxAge:        c.VfsCacheMaxAge,
+		VfsCachePollInterval:  c.VfsCachePollInterval,
+		VfsWriteBack:          c.VfsWriteBack,
+		VfsCacheMaxSize:       c.VfsCacheMaxSize,
+		VfsReadAhead:          c.VfsReadAhead,
+		VfsFastFingerPrint:    c.VfsFastFingerPrint,
+		VfsReadChunkSize:      c.VfsReadChunkSize,
+		VfsReadChunkSizeLimit: c.VfsReadChunkSizeLimit,
+		NoCheckSum:            c.NoCheckSum,
+		NoModTime:             c.NoModTime,
+		NoSeek:                c.NoSeek,
+		ReadOnly:              c.ReadOnly,
+		VfsReadWait:           c.VfsReadWait,
+		VfsWriteWait:          c.VfsWriteWait,
+		Transfers:             c.Transfers,
+		VfsDiskSpaceTotalSize: c.VfsDiskSpaceTotalSize,
+		UploadCutoff:          c.UploadCutoff,
+		UploadChunkSize:       c.UploadChunkSize,
+		UploadConcurrency:     c.UploadConcurrency,
+		WriteBackCache:        c.WriteBackCache,
+		DebugHttp:             c.DebugHttp,
+		DebugFuse:             c.DebugFuse,
+		MayRunOnSystemd:       c.MayRunOnSystemd,
+		Uid:                   c.Uid,
+		Gid:                   c.Gid,
+		Umask:                 c.Umask,
+		DirPerms:              c.DirPerms,
+		FilePerms:             c.FilePerms,
+		AllowOther:            c.AllowOther,
+	}
+}
+
 // ExecCommand 实际上Kodo的挂载是基于rclone的，这里的ExecCommand是将配置转换为rclone的命令行参数并执行
 func (c *InitKodoMountCmd) ExecCommand(ctx context.Context) *exec.Cmd {
 	rcloneConfigFilePath := ctx.Value(ContextKeyConfigFilePath).(string)
@@ -77,6 +179,10 @@ func (c *InitKodoMountCmd) ExecCommand(ctx context.Context) *exec.Cmd {
 		"--daemon",
 		"--cache-dir", rcloneCacheDirPath,
 	}
+	// 开启远程控制，用于在卸载前等待缓存中的文件上传完成
+	if rcSocketPath, ok := ctx.Value(ContextKeyRcSocketPath).(string); ok && rcSocketPath != "" {
+		mountFlags = append(mountFlags, "--rc", "--rc-addr", "unix://"+rcSocketPath, "--rc-no-auth")
+	}
 	if c.DirCacheDuration != "" {
 		mountFlags = append(mountFlags, "--dir-cache-time", c.DirCacheDuration)
 	}
@@ -134,9 +240,31 @@ func (c *InitKodoMountCmd) ExecCommand(ctx context.Context) *exec.Cmd {
 	if c.DebugFuse {
 		mountFlags = append(mountFlags, "--debug-fuse")
 	}
+	if c.Uid != nil {
+		mountFlags = append(mountFlags, "--uid", formatUint(uint64(*c.Uid)))
+	}
+	if c.Gid != nil {
+		mountFlags = append(mountFlags, "--gid", formatUint(uint64(*c.Gid)))
+	}
+	if c.Umask != "" {
+		mountFlags = append(mountFlags, "--umask", c.Umask)
+	}
+	if c.DirPerms != "" {
+		mountFlags = append(mountFlags, "--dir-perms", c.DirPerms)
+	}
+	if c.FilePerms != "" {
+		mountFlags = append(mountFlags, "--file-perms", c.FilePerms)
+	}
+	if c.AllowOther {
+		mountFlags = append(mountFlags, "--allow-other")
+	}
 
-	// 拼接命令行参数
-	args := cmdFlags
+	// 拼接命令行参数，默认参数在前，使存储卷的参数可以覆盖默认参数
+	var args []string
+	if defaultFlags, ok := ctx.Value(ContextKeyDefaultFlags).([]string); ok {
+		args = append(args, defaultFlags...)
+	}
+	args = append(args, cmdFlags...)
 
 	// 拼接rclone挂载命令与相关参数
 	args = append(args, "mount")
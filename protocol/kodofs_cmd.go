@@ -4,6 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+
+	"github.com/qiniu/kubernetes-csi-driver/protocol/connectorpb"
+)
+
+const (
+	KodoFSMasterAddressPrompt = "please enter the master address(separate multiple addresses with commas):"
+	KodoFSAccessTokenPrompt   = "please enter the AccessToken:"
 )
 
 type InitKodoFSMountCmd struct {
@@ -12,15 +19,58 @@ type InitKodoFSMountCmd struct {
 	MountPath       string `json:"mount_path"`
 	SubDir          string `json:"sub_dir"`
 	MayRunOnSystemd bool   `json:"may_run_on_systemd"`
+	// MasterAddress 和 AccessToken 仍然由插件通过 RequestDataCmd 输入，
+	// 这里只用于 connector 在挂载损坏后自行重新挂载
+	MasterAddress string `json:"master_address,omitempty"`
+	AccessToken   string `json:"access_token,omitempty"`
+	ReadOnly      bool   `json:"read_only,omitempty"`
 }
 
 func (*InitKodoFSMountCmd) Command() {}
 
+// NewInitKodoFSMountCmd 将 gRPC 请求转换为挂载命令
+func NewInitKodoFSMountCmd(m *connectorpb.KodoFSMount) *InitKodoFSMountCmd {
+	return &InitKodoFSMountCmd{
+		VolumeId:        m.VolumeId,
+		GatewayID:       m.GatewayId,
+		MountPath:       m.MountPath,
+		SubDir:          m.SubDir,
+		MayRunOnSystemd: m.MayRunOnSystemd,
+		MasterAddress:   m.MasterAddress,
+		AccessToken:     m.AccessToken,
+		ReadOnly:        m.ReadOnly,
+	}
+}
+
+func (c *InitKodoFSMountCmd) ToProto() *connectorpb.KodoFSMount {
+	return &connectorpb.KodoFSMount{
+		VolumeId:        c.VolumeId,
+		GatewayId:       c.GatewayID,
+		MountPath:       c.MountPath,
+		SubDir:          c.SubDir,
+		MayRunOnSystemd: c.MayRunOnSystemd,
+		MasterAddress:   c.MasterAddress,
+		AccessToken:     c.AccessToken,
+		ReadOnly:        c.ReadOnly,
+	}
+}
+
 func (c *InitKodoFSMountCmd) ExecCommand(ctx context.Context) *exec.Cmd {
 	var args = []string{"mount", c.GatewayID, c.MountPath, "-s", c.SubDir, "--force_reinit"}
+	if c.ReadOnly {
+		// 通用的 FUSE 挂载选项，由内核拒绝写操作
+		args = append(args, "-o", "ro")
+	}
 	if c.MayRunOnSystemd {
 		return execOnSystemd(ctx, fmt.Sprintf("run-kodofs-%s-%s.service", c.VolumeId, randomName(8)), KodoFSCmd, args...)
 	} else {
 		return exec.CommandContext(ctx, KodoFSCmd, args...)
 	}
 }
+
+type KodoFSUmountCmd struct {
+	VolumeId  string `json:"volume_id"`
+	MountPath string `json:"mount_path"`
+}
+
+func (*KodoFSUmountCmd) Command() {}
@@ -24,7 +24,8 @@ type ResponseDataCmd struct {
 func (*ResponseDataCmd) Command() {}
 
 type TerminateCmd struct {
-	Code int `json:"code"`
+	Code  int         `json:"code"`
+	Error *MountError `json:"error,omitempty"`
 }
 
 func (*TerminateCmd) Command() {}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,6 +23,14 @@ var (
 	singleflightGroup singleflight.Group
 )
 
+// ErrAPINotSupported 表示服务端没有提供请求的接口，例如部分私有云环境不支持配额
+var ErrAPINotSupported = errors.New("api is not supported by the server")
+
+// isAPINotSupportedStatus 判断状态码是否表示服务端没有提供请求的接口
+func isAPINotSupportedStatus(statusCode int) bool {
+	return statusCode == http.StatusNotFound || statusCode == http.StatusMethodNotAllowed || statusCode == http.StatusNotImplemented
+}
+
 type KodoClient struct {
 	httpClient           *http.Client
 	ucUrl                *url.URL
@@ -54,6 +63,7 @@ func NewKodoClient(accessKey, secretKey string, ucUrl *url.URL, version, commitI
 	httpClient := new(http.Client)
 	transport := NewUserAgentTransport(fmt.Sprintf("QiniuCSIDriver/%s/%s/kodo", version, commitId), httpClient.Transport)
 	transport = NewQiniuAuthTransport(accessKey, secretKey, transport, false)
+	transport = NewMetricsTransport(transport)
 	httpClient.Transport = transport
 	return &KodoClient{httpClient: httpClient, ucUrl: ucUrl, accessKey: accessKey, secretKey: secretKey}
 }
@@ -63,7 +73,7 @@ func (client *KodoClient) CreateBucket(ctx context.Context, bucketName, regionID
 	requestUrl := client.ucUrl.String() + "/mkbucketv3/" + bucketName + "/region/" + regionID + "/private/true/nodomain/true"
 	if request, err := http.NewRequest(http.MethodPost, requestUrl, http.NoBody); err != nil {
 		return fmt.Errorf("KodoClient.CreateBucket: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.CreateBucket"))); err != nil {
 		return fmt.Errorf("KodoClient.CreateBucket: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -81,8 +91,118 @@ func (client *KodoClient) CreateBucket(ctx context.Context, bucketName, regionID
 	}
 }
 
+// SetBucketQuota 设置 bucket 的存储量配额，sizeBytes 为 -1 表示取消限额
+func (client *KodoClient) SetBucketQuota(ctx context.Context, bucketName string, sizeBytes int64) error {
+	requestUrl := fmt.Sprintf("%s/setbucketquota/%s/size/%d", client.ucUrl.String(), bucketName, sizeBytes)
+	if request, err := http.NewRequest(http.MethodPost, requestUrl, http.NoBody); err != nil {
+		return fmt.Errorf("KodoClient.SetBucketQuota: create request err: %w", err)
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.SetBucketQuota"))); err != nil {
+		return fmt.Errorf("KodoClient.SetBucketQuota: send request err: %w", err)
+	} else {
+		defer resp.Body.Close()
+		if bs, err := io.ReadAll(resp.Body); err != nil {
+			return fmt.Errorf("KodoClient.SetBucketQuota: read response err: %w", err)
+		} else if resp.StatusCode == http.StatusOK {
+			return nil
+		} else if isAPINotSupportedStatus(resp.StatusCode) {
+			return fmt.Errorf("KodoClient.SetBucketQuota: %w: %s", ErrAPINotSupported, resp.Status)
+		} else if errBody, err := parseKodoErrorFromResponseBody(bs); err != nil {
+			return err
+		} else if errBody != nil {
+			return errBody
+		} else {
+			return fmt.Errorf("KodoClient.SetBucketQuota: invalid status code: %s", resp.Status)
+		}
+	}
+}
+
+type BucketTag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// SetBucketTags 设置 bucket 的标签，会覆盖 bucket 已有的所有标签
+func (client *KodoClient) SetBucketTags(ctx context.Context, bucketName string, tags map[string]string) error {
+	type RequestBody struct {
+		Tags []BucketTag `json:"Tags"`
+	}
+	var requestBody RequestBody
+	for key, value := range tags {
+		requestBody.Tags = append(requestBody.Tags, BucketTag{Key: key, Value: value})
+	}
+	requestBodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("KodoClient.SetBucketTags: failed to marshal request body")
+	}
+	requestUrl := client.ucUrl.String() + "/bucketTagging?bucket=" + url.QueryEscape(bucketName)
+	if request, err := http.NewRequest(http.MethodPut, requestUrl, bytes.NewReader(requestBodyBytes)); err != nil {
+		return fmt.Errorf("KodoClient.SetBucketTags: create request err: %w", err)
+	} else {
+		request.Header.Set("Content-Type", "application/json")
+		if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.SetBucketTags"))); err != nil {
+			return fmt.Errorf("KodoClient.SetBucketTags: send request err: %w", err)
+		} else {
+			defer resp.Body.Close()
+			if bs, err := io.ReadAll(resp.Body); err != nil {
+				return fmt.Errorf("KodoClient.SetBucketTags: read response err: %w", err)
+			} else if resp.StatusCode == http.StatusOK {
+				return nil
+			} else if errBody, err := parseKodoErrorFromResponseBody(bs); err != nil {
+				return err
+			} else if errBody != nil {
+				return errBody
+			} else {
+				return fmt.Errorf("KodoClient.SetBucketTags: invalid status code: %s", resp.Status)
+			}
+		}
+	}
+}
+
+// GetBucketTags 获取 bucket 的标签，bucket 没有设置标签时返回空的 map
+func (client *KodoClient) GetBucketTags(ctx context.Context, bucketName string) (map[string]string, error) {
+	type ResponseBody struct {
+		Tags []BucketTag `json:"Tags"`
+	}
+	requestUrl := client.ucUrl.String() + "/bucketTagging?bucket=" + url.QueryEscape(bucketName)
+	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
+		return nil, fmt.Errorf("KodoClient.GetBucketTags: create request err: %w", err)
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.GetBucketTags"))); err != nil {
+		return nil, fmt.Errorf("KodoClient.GetBucketTags: send request err: %w", err)
+	} else {
+		defer resp.Body.Close()
+		if bs, err := io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("KodoClient.GetBucketTags: read response err: %w", err)
+		} else if resp.StatusCode == http.StatusOK {
+			var responseBody ResponseBody
+			if len(bytes.TrimSpace(bs)) > 0 {
+				if err = json.Unmarshal(bs, &responseBody); err != nil {
+					return nil, fmt.Errorf("KodoClient.GetBucketTags: parse response body err: %w", err)
+				}
+			}
+			tags := make(map[string]string, len(responseBody.Tags))
+			for _, tag := range responseBody.Tags {
+				tags[tag.Key] = tag.Value
+			}
+			return tags, nil
+		} else if resp.StatusCode == http.StatusNotFound {
+			return make(map[string]string), nil
+		} else if errBody, err := parseKodoErrorFromResponseBody(bs); err != nil {
+			return nil, err
+		} else if errBody != nil {
+			return nil, errBody
+		} else {
+			return nil, fmt.Errorf("KodoClient.GetBucketTags: invalid status code: %s", resp.Status)
+		}
+	}
+}
+
 func (client *KodoClient) CleanObjects(ctx context.Context, bucketName string) error {
-	listedObjectResults, err := client.listObjects(ctx, bucketName)
+	return client.DeleteObjectsByPrefix(ctx, bucketName, "")
+}
+
+// DeleteObjectsByPrefix 删除 bucket 中所有以 prefix 开头的对象
+func (client *KodoClient) DeleteObjectsByPrefix(ctx context.Context, bucketName, prefix string) error {
+	listedObjectResults, err := client.listObjects(ctx, bucketName, prefix)
 	if err != nil {
 		return err
 	}
@@ -140,7 +260,7 @@ func (client *KodoClient) CreateIAMUser(ctx context.Context, userName, password
 		return fmt.Errorf("KodoClient.CreateIAMUser: create request err: %w", err)
 	} else {
 		request.Header.Set("Content-Type", "application/json")
-		if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+		if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.CreateIAMUser"))); err != nil {
 			return fmt.Errorf("KodoClient.CreateIAMUser: send request err: %w", err)
 		} else {
 			defer resp.Body.Close()
@@ -191,7 +311,7 @@ func (client *KodoClient) getFirstIAMUserKeyPair(ctx context.Context, userName s
 	requestUrl := apiEndpoint.String() + "/iam/v1/users/" + userName + "/keypairs"
 	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
 		return nil, fmt.Errorf("KodoClient.getFirstIAMUserKeyPair: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.getFirstIAMUserKeyPair"))); err != nil {
 		return nil, fmt.Errorf("KodoClient.getFirstIAMUserKeyPair: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -234,7 +354,7 @@ func (client *KodoClient) createIAMUserKeyPair(ctx context.Context, userName str
 	requestUrl := apiEndpoint.String() + "/iam/v1/users/" + userName + "/keypairs"
 	if request, err := http.NewRequest(http.MethodPost, requestUrl, http.NoBody); err != nil {
 		return nil, fmt.Errorf("KodoClient.createIAMUserKeyPair: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.createIAMUserKeyPair"))); err != nil {
 		return nil, fmt.Errorf("KodoClient.createIAMUserKeyPair: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -267,7 +387,7 @@ func (client *KodoClient) DeleteIAMUser(ctx context.Context, userName string) er
 	requestUrl := apiEndpoint.String() + "/iam/v1/users/" + userName
 	if request, err := http.NewRequest(http.MethodDelete, requestUrl, http.NoBody); err != nil {
 		return fmt.Errorf("KodoClient.DeleteIAMUser: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.DeleteIAMUser"))); err != nil {
 		return fmt.Errorf("KodoClient.DeleteIAMUser: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -322,7 +442,7 @@ func (client *KodoClient) CreateIAMPolicy(ctx context.Context, name, bucketName
 		return fmt.Errorf("KodoClient.CreateIAMPolicy: create request err: %w", err)
 	} else {
 		request.Header.Set("Content-Type", "application/json")
-		if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+		if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.CreateIAMPolicy"))); err != nil {
 			return fmt.Errorf("KodoClient.CreateIAMPolicy: send request err: %w", err)
 		} else {
 			defer resp.Body.Close()
@@ -351,7 +471,7 @@ func (client *KodoClient) DeleteIAMPolicy(ctx context.Context, name string) erro
 	requestUrl := apiEndpoint.String() + "/iam/v1/policies/" + name
 	if request, err := http.NewRequest(http.MethodDelete, requestUrl, http.NoBody); err != nil {
 		return fmt.Errorf("KodoClient.DeleteIAMPolicy: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.DeleteIAMPolicy"))); err != nil {
 		return fmt.Errorf("KodoClient.DeleteIAMPolicy: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -390,7 +510,7 @@ func (client *KodoClient) GrantIAMPolicyToUser(ctx context.Context, userName str
 		return fmt.Errorf("KodoClient.GrantIAMPolicyToUser: create request err: %w", err)
 	} else {
 		request.Header.Set("Content-Type", "application/json")
-		if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+		if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.GrantIAMPolicyToUser"))); err != nil {
 			return fmt.Errorf("KodoClient.GrantIAMPolicyToUser: send request err: %w", err)
 		} else {
 			defer resp.Body.Close()
@@ -430,7 +550,7 @@ func (client *KodoClient) RevokeIAMPolicyFromUser(ctx context.Context, userName
 		return fmt.Errorf("KodoClient.RevokeIAMPolicyFromUser: create request err: %w", err)
 	} else {
 		request.Header.Set("Content-Type", "application/json")
-		if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+		if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.RevokeIAMPolicyFromUser"))); err != nil {
 			return fmt.Errorf("KodoClient.RevokeIAMPolicyFromUser: send request err: %w", err)
 		} else {
 			defer resp.Body.Close()
@@ -449,15 +569,150 @@ func (client *KodoClient) RevokeIAMPolicyFromUser(ctx context.Context, userName
 	}
 }
 
+// GetIAMUsers 列出所有 IAM 用户的名称
+func (client *KodoClient) GetIAMUsers(ctx context.Context) ([]string, error) {
+	return client.listIAMAliases(ctx, "/iam/v1/users", "KodoClient.GetIAMUsers")
+}
+
+// GetIAMPolicies 列出所有 IAM 策略的名称
+func (client *KodoClient) GetIAMPolicies(ctx context.Context) ([]string, error) {
+	return client.listIAMAliases(ctx, "/iam/v1/policies", "KodoClient.GetIAMPolicies")
+}
+
+func (client *KodoClient) listIAMAliases(ctx context.Context, path, functionName string) ([]string, error) {
+	type ResponseBody struct {
+		Data struct {
+			Count int `json:"count"`
+			List  []struct {
+				Alias string `json:"alias"`
+			} `json:"list"`
+		} `json:"data"`
+	}
+	const PAGE_SIZE = 100
+
+	apiEndpoint, err := client.GetCentralApiEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	} else if apiEndpoint == nil {
+		return nil, fmt.Errorf("%s: cannot get api endpoint of central region", functionName)
+	}
+
+	var aliases []string
+	for page := 1; ; page++ {
+		values := make(url.Values, 2)
+		values.Set("page", fmt.Sprintf("%d", page))
+		values.Set("page_size", fmt.Sprintf("%d", PAGE_SIZE))
+		requestUrl := apiEndpoint.String() + path + "?" + values.Encode()
+		var responseBody ResponseBody
+		if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
+			return nil, fmt.Errorf("%s: create request err: %w", functionName, err)
+		} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, functionName))); err != nil {
+			return nil, fmt.Errorf("%s: send request err: %w", functionName, err)
+		} else {
+			bs, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: read response err: %w", functionName, err)
+			} else if resp.StatusCode != http.StatusOK {
+				if errBody, err := parseKodoErrorFromResponseBody(bs); err != nil {
+					return nil, err
+				} else if errBody != nil {
+					return nil, errBody
+				} else {
+					return nil, fmt.Errorf("%s: invalid status code: %s", functionName, resp.Status)
+				}
+			} else if err = json.Unmarshal(bs, &responseBody); err != nil {
+				return nil, fmt.Errorf("%s: parse response body err: %w", functionName, err)
+			}
+		}
+		for _, item := range responseBody.Data.List {
+			aliases = append(aliases, item.Alias)
+		}
+		if len(responseBody.Data.List) < PAGE_SIZE || len(aliases) >= responseBody.Data.Count {
+			return aliases, nil
+		}
+	}
+}
+
+type BucketUsage struct {
+	SizeBytes   int64
+	ObjectCount int64
+}
+
+// GetBucketUsage 从统计接口获取 bucket 最近一次统计的存储量和文件数，统计数据有一定的延迟
+func (client *KodoClient) GetBucketUsage(ctx context.Context, bucketName string) (*BucketUsage, error) {
+	var (
+		usage BucketUsage
+		err   error
+	)
+	if usage.SizeBytes, err = client.getLatestBucketStatistic(ctx, "/v6/space", "KodoClient.GetBucketUsage", bucketName); err != nil {
+		return nil, err
+	} else if usage.ObjectCount, err = client.getLatestBucketStatistic(ctx, "/v6/count", "KodoClient.GetBucketUsage", bucketName); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (client *KodoClient) getLatestBucketStatistic(ctx context.Context, path, functionName, bucketName string) (int64, error) {
+	type ResponseBody struct {
+		Times []int64 `json:"times"`
+		Datas []int64 `json:"datas"`
+	}
+	const TIME_FORMAT = "20060102150405"
+
+	apiEndpoint, err := client.GetCentralApiEndpoint(ctx)
+	if err != nil {
+		return 0, err
+	} else if apiEndpoint == nil {
+		return 0, fmt.Errorf("%s: cannot get api endpoint of central region", functionName)
+	}
+
+	// 统计数据按天汇总，查询最近两天以保证至少有一个数据点
+	now := time.Now()
+	values := make(url.Values, 4)
+	values.Set("bucket", bucketName)
+	values.Set("begin", now.Add(-48*time.Hour).Format(TIME_FORMAT))
+	values.Set("end", now.Format(TIME_FORMAT))
+	values.Set("g", "day")
+	requestUrl := apiEndpoint.String() + path + "?" + values.Encode()
+	var responseBody ResponseBody
+	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
+		return 0, fmt.Errorf("%s: create request err: %w", functionName, err)
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, functionName))); err != nil {
+		return 0, fmt.Errorf("%s: send request err: %w", functionName, err)
+	} else {
+		defer resp.Body.Close()
+		if bs, err := io.ReadAll(resp.Body); err != nil {
+			return 0, fmt.Errorf("%s: read response err: %w", functionName, err)
+		} else if resp.StatusCode != http.StatusOK {
+			if errBody, err := parseKodoErrorFromResponseBody(bs); err != nil {
+				return 0, err
+			} else if errBody != nil {
+				return 0, errBody
+			} else {
+				return 0, fmt.Errorf("%s: invalid status code: %s", functionName, resp.Status)
+			}
+		} else if err = json.Unmarshal(bs, &responseBody); err != nil {
+			return 0, fmt.Errorf("%s: parse response body err: %w", functionName, err)
+		}
+	}
+	if len(responseBody.Datas) == 0 {
+		return 0, nil
+	}
+	return responseBody.Datas[len(responseBody.Datas)-1], nil
+}
+
 type ListedObjectResult struct {
 	ObjectName string
+	Size       int64
 	Error      error
 }
 
-func (client *KodoClient) listObjects(ctx context.Context, bucketName string) (<-chan ListedObjectResult, error) {
+func (client *KodoClient) listObjects(ctx context.Context, bucketName, prefix string) (<-chan ListedObjectResult, error) {
 	type (
 		ListedObjectItem struct {
 			ObjectName string `json:"key"`
+			Size       int64  `json:"fsize"`
 		}
 		ListedObject struct {
 			Marker string           `json:"marker"`
@@ -480,15 +735,18 @@ func (client *KodoClient) listObjects(ctx context.Context, bucketName string) (<
 	}
 
 	sendListObjectsRequest := func(ctx context.Context, marker string) (<-chan ListedObject, error) {
-		values := make(url.Values, 2)
+		values := make(url.Values, 3)
 		values.Set("bucket", bucketName)
+		if prefix != "" {
+			values.Set("prefix", prefix)
+		}
 		if marker != "" {
 			values.Set("marker", marker)
 		}
 		listUrl := rsfEndpoint.String() + "/v2/list?" + values.Encode()
 		if request, err := http.NewRequest(http.MethodPost, listUrl, http.NoBody); err != nil {
 			return nil, fmt.Errorf("KodoClient.listObjects: create request err: %w", err)
-		} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+		} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.listObjects"))); err != nil {
 			return nil, fmt.Errorf("KodoClient.listObjects: send request err: %w", err)
 		} else {
 			if resp.StatusCode == http.StatusOK {
@@ -545,7 +803,7 @@ func (client *KodoClient) listObjects(ctx context.Context, bucketName string) (<
 				case <-ctx.Done():
 					listedObjectNamesChan <- ListedObjectResult{Error: ctx.Err()}
 					return
-				case listedObjectNamesChan <- ListedObjectResult{ObjectName: listedObject.Item.ObjectName}:
+				case listedObjectNamesChan <- ListedObjectResult{ObjectName: listedObject.Item.ObjectName, Size: listedObject.Item.Size}:
 				}
 			}
 			if lastMarker == "" {
@@ -584,7 +842,7 @@ func (client *KodoClient) deleteObjects(ctx context.Context, bucketName string,
 		requestUrl := rsfEndpoint.String() + "/batch"
 		if request, err := http.NewRequest(http.MethodPost, requestUrl, strings.NewReader(values.Encode())); err != nil {
 			return fmt.Errorf("KodoClient.deleteObjects: create request err: %w", err)
-		} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+		} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.deleteObjects"))); err != nil {
 			return fmt.Errorf("KodoClient.deleteObjects: send request err: %w", err)
 		} else {
 			defer resp.Body.Close()
@@ -670,11 +928,224 @@ func (client *KodoClient) deleteObjects(ctx context.Context, bucketName string,
 	}
 }
 
+// CopyProgress 记录 ResumeCopyObjects 的进度，名称不大于 LastObjectName 的源对象都已经复制完成
+type CopyProgress struct {
+	LastObjectName string `json:"last_object_name,omitempty"`
+	CopiedBytes    int64  `json:"copied_bytes,omitempty"`
+}
+
+// CopyObjects 在服务端将 srcBucketName 中所有以 srcPrefix 开头的对象复制到 dstBucketName 中，并将对象名称中的 srcPrefix 替换为 dstPrefix
+// 两个 bucket 必须位于同一区域，目标对象已经存在时将被覆盖，返回复制的对象总大小
+func (client *KodoClient) CopyObjects(ctx context.Context, srcBucketName, srcPrefix, dstBucketName, dstPrefix string) (int64, error) {
+	return client.ResumeCopyObjects(ctx, srcBucketName, srcPrefix, dstBucketName, dstPrefix, CopyProgress{}, nil)
+}
+
+// ResumeCopyObjects 与 CopyObjects 相同，但跳过 progress 中已经复制完成的对象
+// 列举结果按对象名称排序，每当排在前面的对象全部复制完成时调用 onProgress 报告新的进度，onProgress 不会被并发调用
+func (client *KodoClient) ResumeCopyObjects(ctx context.Context, srcBucketName, srcPrefix, dstBucketName, dstPrefix string,
+	progress CopyProgress, onProgress func(CopyProgress)) (int64, error) {
+	type BatchOperationResult struct {
+		Code int `json:"code"`
+		Data struct {
+			Error string `json:"error"`
+		} `json:"data"`
+	}
+
+	bucket, err := client.FindBucketByName(ctx, srcBucketName, true)
+	if err != nil {
+		return 0, err
+	} else if bucket == nil {
+		return 0, fmt.Errorf("KodoClient.CopyObjects: cannot find bucket %s", srcBucketName)
+	}
+
+	rsEndpoint, err := client.GetRsEndpoint(ctx, bucket.KodoRegionID)
+	if err != nil {
+		return 0, err
+	} else if rsEndpoint == nil {
+		return 0, fmt.Errorf("KodoClient.CopyObjects: cannot get rs endpoint of %s", srcBucketName)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	listedObjectResults, err := client.listObjects(ctx, srcBucketName, srcPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	encodeEntry := func(bucket, objectName string) string {
+		entry := fmt.Sprintf("%s:%s", bucket, objectName)
+		return base64.URLEncoding.EncodeToString([]byte(entry))
+	}
+
+	sendCopyObjectsRequest := func(ctx context.Context, objectNames []string) error {
+		values := make(url.Values, 1)
+		for _, objectName := range objectNames {
+			dstObjectName := dstPrefix + strings.TrimPrefix(objectName, srcPrefix)
+			values.Add("op", "/copy/"+encodeEntry(srcBucketName, objectName)+"/"+encodeEntry(dstBucketName, dstObjectName)+"/force/true")
+		}
+		requestUrl := rsEndpoint.String() + "/batch"
+		if request, err := http.NewRequest(http.MethodPost, requestUrl, strings.NewReader(values.Encode())); err != nil {
+			return fmt.Errorf("KodoClient.CopyObjects: create request err: %w", err)
+		} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.CopyObjects"))); err != nil {
+			return fmt.Errorf("KodoClient.CopyObjects: send request err: %w", err)
+		} else {
+			defer resp.Body.Close()
+			if bs, err := io.ReadAll(resp.Body); err != nil {
+				return fmt.Errorf("KodoClient.CopyObjects: read response err: %w", err)
+			} else if resp.StatusCode == http.StatusOK {
+				return nil
+			} else if resp.StatusCode == 298 {
+				// 部分操作失败，需要逐个检查结果
+				var results []BatchOperationResult
+				if err = json.Unmarshal(bs, &results); err != nil {
+					return fmt.Errorf("KodoClient.CopyObjects: parse response body err: %w", err)
+				}
+				for i, result := range results {
+					if result.Code != http.StatusOK && i < len(objectNames) {
+						return fmt.Errorf("KodoClient.CopyObjects: failed to copy %s: %s (%d)", objectNames[i], result.Data.Error, result.Code)
+					}
+				}
+				return nil
+			} else if errBody, err := parseKodoErrorFromResponseBody(bs); err != nil {
+				return err
+			} else if errBody != nil {
+				return errBody
+			} else {
+				return fmt.Errorf("KodoClient.CopyObjects: invalid status code: %s", resp.Status)
+			}
+		}
+	}
+
+	type CopyBatch struct {
+		seq         int
+		objectNames []string
+		size        int64
+	}
+
+	// 各个 worker 完成的顺序不确定，只有序号连续的批次全部完成后才推进进度
+	var (
+		progressLock     sync.Mutex
+		nextSeq          int
+		completedBatches = make(map[int]*CopyBatch)
+	)
+	completeBatch := func(batch *CopyBatch) {
+		progressLock.Lock()
+		defer progressLock.Unlock()
+		completedBatches[batch.seq] = batch
+		advanced := false
+		for {
+			completed, ok := completedBatches[nextSeq]
+			if !ok {
+				break
+			}
+			delete(completedBatches, nextSeq)
+			nextSeq++
+			progress.LastObjectName = completed.objectNames[len(completed.objectNames)-1]
+			progress.CopiedBytes += completed.size
+			advanced = true
+		}
+		if advanced && onProgress != nil {
+			onProgress(progress)
+		}
+	}
+
+	const (
+		WORKER_COUNT   = 10
+		MAX_BATCH_SIZE = 100
+	)
+	var (
+		batchCopyObjectsChan = make(chan *CopyBatch, WORKER_COUNT)
+		errorsChan           = make(chan error, WORKER_COUNT+1)
+		resumedAfter         = progress.LastObjectName
+		totalSize            = progress.CopiedBytes
+		wg                   sync.WaitGroup
+	)
+	defer close(errorsChan)
+
+	for i := 0; i < WORKER_COUNT; i++ {
+		go func(workerId int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					errorsChan <- ctx.Err()
+					return
+				case batchCopyObjects, ok := <-batchCopyObjectsChan:
+					if !ok {
+						return
+					}
+					if err := sendCopyObjectsRequest(ctx, batchCopyObjects.objectNames); err != nil {
+						errorsChan <- err
+						// 取消其他 worker 和列举对象的 goroutine，避免阻塞
+						cancel()
+						return
+					}
+					completeBatch(batchCopyObjects)
+				}
+			}
+		}(i)
+		wg.Add(1)
+	}
+	go func() {
+		defer wg.Done()
+		defer close(batchCopyObjectsChan)
+		batch := &CopyBatch{objectNames: make([]string, 0, MAX_BATCH_SIZE)}
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				errorsChan <- ctx.Err()
+				return
+			case listedObjectResult, ok := <-listedObjectResults:
+				if !ok {
+					break loop
+				} else if listedObjectResult.Error != nil {
+					errorsChan <- listedObjectResult.Error
+					cancel()
+					return
+				} else if resumedAfter != "" && listedObjectResult.ObjectName <= resumedAfter {
+					// 之前已经复制完成
+					continue
+				}
+				totalSize += listedObjectResult.Size
+				batch.objectNames = append(batch.objectNames, listedObjectResult.ObjectName)
+				batch.size += listedObjectResult.Size
+				if len(batch.objectNames) >= MAX_BATCH_SIZE {
+					select {
+					case <-ctx.Done():
+						errorsChan <- ctx.Err()
+						return
+					case batchCopyObjectsChan <- batch:
+					}
+					batch = &CopyBatch{seq: batch.seq + 1, objectNames: make([]string, 0, MAX_BATCH_SIZE)}
+				}
+			}
+		}
+		if len(batch.objectNames) > 0 {
+			select {
+			case <-ctx.Done():
+				errorsChan <- ctx.Err()
+			case batchCopyObjectsChan <- batch:
+			}
+		}
+	}()
+	wg.Add(1)
+
+	wg.Wait()
+	select {
+	case err := <-errorsChan:
+		return 0, err
+	default:
+		return totalSize, nil
+	}
+}
+
 func (client *KodoClient) DeleteBucket(ctx context.Context, bucketName string) error {
 	requestUrl := client.ucUrl.String() + "/drop/" + bucketName
 	if request, err := http.NewRequest(http.MethodPost, requestUrl, http.NoBody); err != nil {
 		return fmt.Errorf("KodoClient.DeleteBucket: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.DeleteBucket"))); err != nil {
 		return fmt.Errorf("KodoClient.DeleteBucket: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -878,7 +1349,7 @@ func (client *KodoClient) getRegions(ctx context.Context) ([]*Region, error) {
 	requestUrl := client.ucUrl.String() + "/regions"
 	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
 		return nil, fmt.Errorf("KodoClient.getRegions: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.getRegions"))); err != nil {
 		return nil, fmt.Errorf("KodoClient.getRegions: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -957,7 +1428,7 @@ func (client *KodoClient) getBuckets(ctx context.Context) ([]*Bucket, error) {
 	requestUrl := client.ucUrl.String() + "/v2/buckets?shared=rd"
 	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
 		return nil, fmt.Errorf("KodoClient.getBuckets: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoClient.getBuckets"))); err != nil {
 		return nil, fmt.Errorf("KodoClient.getBuckets: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -19,6 +19,7 @@ func NewKodoFSClient(accessKey, secretKey string, masterUrl *url.URL, version, c
 	httpClient := new(http.Client)
 	transport := NewUserAgentTransport(fmt.Sprintf("QiniuCSIDriver/%s/%s/kodofs", version, commitId), httpClient.Transport)
 	transport = NewQiniuAuthTransport(accessKey, secretKey, transport, true)
+	transport = NewMetricsTransport(transport)
 	httpClient.Transport = transport
 	return &KodoFSClient{httpClient: httpClient, masterUrl: masterUrl}
 }
@@ -51,7 +52,7 @@ func (client *KodoFSClient) CreateVolume(ctx context.Context, volumeName, descri
 	}
 	request.Header.Set("Content-Type", "application/json")
 	var response Response
-	if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.CreateVolume"))); err != nil {
 		return "", fmt.Errorf("KodoFSClient.CreateVolume: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -95,7 +96,7 @@ func (client *KodoFSClient) CreateAccessPoint(ctx context.Context, volumeName, d
 	}
 	request.Header.Set("Content-Type", "application/json")
 	var response Response
-	if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.CreateAccessPoint"))); err != nil {
 		return "", fmt.Errorf("KodoFSClient.CreateAccessPoint: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -123,7 +124,7 @@ func (client *KodoFSClient) GetAccessToken(ctx context.Context, accessPointId st
 	requestUrl := client.masterUrl.String() + "/v1/kodofs-master/accessPoint/info?" + queryPairs.Encode()
 	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
 		return "", fmt.Errorf("KodoFSClient.GetAccessToken: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.GetAccessToken"))); err != nil {
 		return "", fmt.Errorf("KodoFSClient.GetAccessToken: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -154,7 +155,7 @@ func (client *KodoFSClient) RemoveAccessPoint(ctx context.Context, accessPointId
 	requestUrl := client.masterUrl.String() + "/v1/kodofs-master/accessPoint/remove"
 	if request, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(body)); err != nil {
 		return fmt.Errorf("KodoFSClient.RemoveAccessPoint: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.RemoveAccessPoint"))); err != nil {
 		return fmt.Errorf("KodoFSClient.RemoveAccessPoint: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -176,7 +177,7 @@ func (client *KodoFSClient) IsVolumeExists(ctx context.Context, volumeName strin
 	requestUrl := client.masterUrl.String() + "/v1/kodofs-master/volume/info?" + queryPairs.Encode()
 	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
 		return false, fmt.Errorf("KodoFSClient.IsVolumeExists: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.IsVolumeExists"))); err != nil {
 		return false, fmt.Errorf("KodoFSClient.IsVolumeExists: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -196,6 +197,37 @@ func (client *KodoFSClient) IsVolumeExists(ctx context.Context, volumeName strin
 	}
 }
 
+// GetVolumeGatewayId 获取存储卷的 gateway id，与 CreateVolume 返回的相同
+func (client *KodoFSClient) GetVolumeGatewayId(ctx context.Context, volumeName string) (string, error) {
+	type Response struct {
+		GatewayId string `json:"volume"`
+	}
+	var response Response
+	queryPairs := make(url.Values)
+	queryPairs.Add("volume", volumeName)
+	requestUrl := client.masterUrl.String() + "/v1/kodofs-master/volume/info?" + queryPairs.Encode()
+	if request, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody); err != nil {
+		return "", fmt.Errorf("KodoFSClient.GetVolumeGatewayId: create request err: %w", err)
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.GetVolumeGatewayId"))); err != nil {
+		return "", fmt.Errorf("KodoFSClient.GetVolumeGatewayId: send request err: %w", err)
+	} else {
+		defer resp.Body.Close()
+		if bs, err := io.ReadAll(resp.Body); err != nil {
+			return "", fmt.Errorf("KodoFSClient.GetVolumeGatewayId: read response err: %w", err)
+		} else if errBody, err := parseKodoFSErrorFromResponseBody(bs); err != nil {
+			return "", err
+		} else if errBody != nil {
+			return "", errBody
+		} else if err = json.Unmarshal(bs, &response); err != nil {
+			return "", fmt.Errorf("KodoFSClient.GetVolumeGatewayId: parse response body err: %w", err)
+		} else if response.GatewayId == "" {
+			return "", fmt.Errorf("KodoFSClient.GetVolumeGatewayId: gateway id of volume %s is empty", volumeName)
+		} else {
+			return response.GatewayId, nil
+		}
+	}
+}
+
 func (client *KodoFSClient) RenameVolume(ctx context.Context, oldVolumeName, newVolumeName string) error {
 	type Request struct {
 		OldVolumeName string `json:"oldVolumeName"`
@@ -211,7 +243,7 @@ func (client *KodoFSClient) RenameVolume(ctx context.Context, oldVolumeName, new
 	requestUrl := client.masterUrl.String() + "/v1/kodofs-master/volume/rename"
 	if request, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(body)); err != nil {
 		return fmt.Errorf("KodoFSClient.RenameVolume: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.RenameVolume"))); err != nil {
 		return fmt.Errorf("KodoFSClient.RenameVolume: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -227,6 +259,39 @@ func (client *KodoFSClient) RenameVolume(ctx context.Context, oldVolumeName, new
 	}
 }
 
+func (client *KodoFSClient) SetVolumeQuota(ctx context.Context, volumeName string, quotaBytes int64) error {
+	type Request struct {
+		Volume string `json:"volume"`
+		Quota  int64  `json:"quota"`
+	}
+	body, err := json.Marshal(&Request{
+		Volume: volumeName,
+		Quota:  quotaBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("KodoFSClient.SetVolumeQuota: marshal json request body err: %w", err)
+	}
+	requestUrl := client.masterUrl.String() + "/v1/kodofs-master/volume/quota"
+	if request, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(body)); err != nil {
+		return fmt.Errorf("KodoFSClient.SetVolumeQuota: create request err: %w", err)
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.SetVolumeQuota"))); err != nil {
+		return fmt.Errorf("KodoFSClient.SetVolumeQuota: send request err: %w", err)
+	} else {
+		defer resp.Body.Close()
+		if bs, err := io.ReadAll(resp.Body); err != nil {
+			return fmt.Errorf("KodoFSClient.SetVolumeQuota: read response err: %w", err)
+		} else if isAPINotSupportedStatus(resp.StatusCode) {
+			return fmt.Errorf("KodoFSClient.SetVolumeQuota: %w: %s", ErrAPINotSupported, resp.Status)
+		} else if errBody, err := parseKodoFSErrorFromResponseBody(bs); err != nil {
+			return err
+		} else if errBody != nil {
+			return errBody
+		} else {
+			return nil
+		}
+	}
+}
+
 func (client *KodoFSClient) RemoveVolume(ctx context.Context, volumeName string) error {
 	type Request struct {
 		Volume string `json:"volume"`
@@ -240,7 +305,7 @@ func (client *KodoFSClient) RemoveVolume(ctx context.Context, volumeName string)
 	requestUrl := client.masterUrl.String() + "/v1/kodofs-master/volume/remove"
 	if request, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(body)); err != nil {
 		return fmt.Errorf("KodoFSClient.RemoveVolume: create request err: %w", err)
-	} else if resp, err := client.httpClient.Do(request.WithContext(ctx)); err != nil {
+	} else if resp, err := client.httpClient.Do(request.WithContext(withAPIName(ctx, "KodoFSClient.RemoveVolume"))); err != nil {
 		return fmt.Errorf("KodoFSClient.RemoveVolume: send request err: %w", err)
 	} else {
 		defer resp.Body.Close()
@@ -0,0 +1,67 @@
+package qiniu
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/qiniu/kubernetes-csi-driver/protocol"
+)
+
+type apiNameKey struct{}
+
+var (
+	apiRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: protocol.MetricsNamespace,
+		Name:      "api_requests_total",
+		Help:      "Number of HTTP requests sent by KodoClient and KodoFSClient, code is error if no response is received.",
+	}, []string{"api", "code"})
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: protocol.MetricsNamespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of HTTP requests sent by KodoClient and KodoFSClient.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"api"})
+)
+
+// Collectors 返回 KodoClient 和 KodoFSClient 请求的指标，由使用者注册
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{apiRequestsTotal, apiRequestDuration}
+}
+
+// withAPIName 在 ctx 中记录请求所属的 API，作为指标的标签
+func withAPIName(ctx context.Context, api string) context.Context {
+	return context.WithValue(ctx, apiNameKey{}, api)
+}
+
+// MetricsTransport 记录每个请求的状态码和耗时
+type MetricsTransport struct {
+	transport http.RoundTripper
+}
+
+func NewMetricsTransport(transport http.RoundTripper) http.RoundTripper {
+	return &MetricsTransport{transport: transport}
+}
+
+func (t *MetricsTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	api, ok := request.Context().Value(apiNameKey{}).(string)
+	if !ok {
+		api = "unknown"
+	}
+	innerTransport := t.transport
+	if innerTransport == nil {
+		innerTransport = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := innerTransport.RoundTrip(request)
+	apiRequestDuration.WithLabelValues(api).Observe(time.Since(start).Seconds())
+	if err != nil {
+		apiRequestsTotal.WithLabelValues(api, "error").Inc()
+	} else {
+		apiRequestsTotal.WithLabelValues(api, strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	return resp, err
+}
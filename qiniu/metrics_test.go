@@ -0,0 +1,32 @@
+package qiniu
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewMetricsTransport(nil)}
+	request, err := http.NewRequestWithContext(withAPIName(context.Background(), "KodoClient.test"), http.MethodGet, server.URL, http.NoBody)
+	assert.NoError(t, err)
+	resp, err := client.Do(request)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, float64(1), testutil.ToFloat64(apiRequestsTotal.WithLabelValues("KodoClient.test", "404")))
+
+	request, err = http.NewRequest(http.MethodGet, "http://127.0.0.1:0", http.NoBody)
+	assert.NoError(t, err)
+	_, err = client.Do(request)
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(apiRequestsTotal.WithLabelValues("unknown", "error")))
+}